@@ -41,6 +41,7 @@
       nixosModules.default = { config, lib, pkgs, ... }:
         let
           cfg = config.services.connectionInfo;
+          upstream = "${if cfg.tls.certFile != null then "https" else "http"}://127.0.0.1:${toString cfg.port}";
         in
         {
           options.services.connectionInfo = {
@@ -65,6 +66,132 @@
               description = "The connectionInfo package to use";
             };
 
+            trustedProxies = lib.mkOption {
+              type = lib.types.listOf lib.types.str;
+              default = [ "127.0.0.0/8" "::1/128" ];
+              description = "CIDR ranges of reverse proxies whose X-Forwarded-For and X-Real-IP headers are trusted. The default trusts the built-in nginx on loopback. An empty list trusts no proxy.";
+              example = [ "127.0.0.0/8" "::1/128" "10.0.0.0/8" ];
+            };
+
+            proxyProtocol = {
+              enable = lib.mkOption {
+                type = lib.types.bool;
+                default = false;
+                description = "Expect a PROXY protocol (v1 or v2) header on connections from the allowed upstreams, as sent by HAProxy or a TCP load balancer.";
+              };
+
+              allowedUpstreams = lib.mkOption {
+                type = lib.types.listOf lib.types.str;
+                default = [ ];
+                description = "CIDR ranges of load balancers allowed to send PROXY protocol headers. Connections from other addresses are served without one. Must not be empty when proxyProtocol.enable is set.";
+                example = [ "10.0.0.0/8" ];
+              };
+            };
+
+            geoip.databases = lib.mkOption {
+              type = lib.types.listOf lib.types.str;
+              default = [ ];
+              description = "Paths of MaxMind DB (MMDB) files, such as GeoLite2-City and GeoLite2-ASN or the DB-IP lite equivalents, used to show the location and network of the client IP. Files are reloaded when they change on disk or on `systemctl reload connectionInfo`. When several databases provide a field, the first one listed wins.";
+              example = [ "/var/lib/GeoIP/GeoLite2-City.mmdb" "/var/lib/GeoIP/GeoLite2-ASN.mmdb" ];
+            };
+
+            userAgentRules = lib.mkOption {
+              type = lib.types.nullOr lib.types.str;
+              default = null;
+              description = "Path of a user-agent rules file in the format of ua-parser's regexes.yaml, such as the one from uap-core, used instead of the built-in browser and OS rules. The file is checked at startup and reloaded when it changes on disk or on `systemctl reload connectionInfo`; an invalid new version is logged and the previous rules stay in use.";
+              example = "/var/lib/ua-parser/regexes.yaml";
+            };
+
+            reverseDns = {
+              enable = lib.mkOption {
+                type = lib.types.bool;
+                default = false;
+                description = "Look up the reverse DNS (PTR) names of the client IP and check whether they resolve back to it.";
+              };
+
+              resolver = lib.mkOption {
+                type = lib.types.nullOr lib.types.str;
+                default = null;
+                description = "Name server to query, as an address or address:port. null uses the system's configured name servers.";
+                example = "127.0.0.53";
+              };
+
+              timeout = lib.mkOption {
+                type = lib.types.str;
+                default = "1s";
+                description = "Upper bound on each lookup, including the forward confirmation, as a Go duration.";
+                example = "500ms";
+              };
+
+              verifyBots = lib.mkOption {
+                type = lib.types.bool;
+                default = false;
+                description = "Check clients claiming to be a search engine bot, such as Googlebot, against the PTR names of their address. Works without enable, in which case only those clients are looked up.";
+              };
+            };
+
+            tls = {
+              certFile = lib.mkOption {
+                type = lib.types.nullOr lib.types.str;
+                default = null;
+                description = "PEM certificate chain. When set together with keyFile, the service speaks HTTPS itself and reports TLS connection details. The files are reloaded when they change or on `systemctl reload connectionInfo`.";
+                example = "/var/lib/acme/example.com/fullchain.pem";
+              };
+
+              keyFile = lib.mkOption {
+                type = lib.types.nullOr lib.types.str;
+                default = null;
+                description = "PEM private key for certFile.";
+                example = "/var/lib/acme/example.com/key.pem";
+              };
+
+              group = lib.mkOption {
+                type = lib.types.nullOr lib.types.str;
+                default = null;
+                description = "Supplementary group that can read certFile and keyFile, such as the group of an ACME certificate.";
+                example = "acme";
+              };
+
+              clientAuth = lib.mkOption {
+                type = lib.types.enum [ "none" "request" "verify-if-given" "require" ];
+                default = "none";
+                description = "Whether to ask clients for a certificate. `request` accepts any certificate and reports whether it verifies; `verify-if-given` and `require` reject connections whose certificate does not verify against clientCA, `require` also those without one.";
+              };
+
+              clientCA = lib.mkOption {
+                type = lib.types.nullOr lib.types.str;
+                default = null;
+                description = "PEM bundle of the CAs client certificates are verified against. `null` uses the system's roots. Read at startup only.";
+                example = "/etc/ssl/clients-ca.pem";
+              };
+            };
+
+            redaction = {
+              headers = lib.mkOption {
+                type = lib.types.listOf lib.types.str;
+                default = [ "Authorization" "Proxy-Authorization" "Cookie" "Set-Cookie" "X-Api-Key" "Api-Key" "X-Auth-Token" "X-Access-Token" "X-Csrf-Token" "X-Xsrf-Token" "X-Amz-Security-Token" ];
+                description = "Headers whose values are masked in reports, keeping only their first characters and length. Matched case-insensitively. An empty list masks no header by name.";
+              };
+
+              patterns = lib.mkOption {
+                type = lib.types.listOf lib.types.str;
+                default = [ ''(?i)\bbearer\s+([^\s,]+)'' ''\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*'' ];
+                description = "Go regular expressions masked wherever they match in other header values and in the raw request body; only the first capturing group is masked if there is one. Must not contain literal whitespace; use \\s. The defaults match bearer tokens and JSON Web Tokens.";
+              };
+
+              keep = lib.mkOption {
+                type = lib.types.ints.unsigned;
+                default = 4;
+                description = "Leading characters kept of each masked value. At most a quarter of a value is kept.";
+              };
+
+              allowReveal = lib.mkOption {
+                type = lib.types.bool;
+                default = false;
+                description = "Allow `?reveal=1` to show masked values. Anyone who can reach the service can then read the credentials in their own requests, including those added by proxies in front of it.";
+              };
+            };
+
             basePath = lib.mkOption {
               type = lib.types.str;
               default = "/connectionInfo";
@@ -101,6 +228,17 @@
           };
 
           config = lib.mkIf cfg.enable {
+            assertions = [
+              {
+                assertion = (cfg.tls.certFile == null) == (cfg.tls.keyFile == null);
+                message = "services.connectionInfo.tls.certFile and tls.keyFile must be set together";
+              }
+              {
+                assertion = cfg.tls.certFile != null || (cfg.tls.clientAuth == "none" && cfg.tls.clientCA == null);
+                message = "services.connectionInfo.tls.clientAuth and tls.clientCA require tls.certFile";
+              }
+            ];
+
             systemd.services.connectionInfo = {
               description = "Connection Info Service";
               wantedBy = [ "multi-user.target" ];
@@ -108,6 +246,33 @@
 
               environment = {
                 PORT = toString cfg.port;
+                TRUSTED_PROXIES = if cfg.trustedProxies == [ ] then "none" else lib.concatStringsSep "," cfg.trustedProxies;
+                REDACT_HEADERS = if cfg.redaction.headers == [ ] then "none" else lib.concatStringsSep "," cfg.redaction.headers;
+                REDACT_PATTERNS = if cfg.redaction.patterns == [ ] then "none" else lib.concatStringsSep " " cfg.redaction.patterns;
+                REDACT_KEEP = toString cfg.redaction.keep;
+              } // lib.optionalAttrs cfg.redaction.allowReveal {
+                REDACT_REVEAL = "true";
+              } // lib.optionalAttrs (cfg.geoip.databases != [ ]) {
+                GEOIP_DATABASES = lib.concatStringsSep "," cfg.geoip.databases;
+              } // lib.optionalAttrs (cfg.userAgentRules != null) {
+                USER_AGENT_RULES = cfg.userAgentRules;
+              } // lib.optionalAttrs cfg.reverseDns.enable {
+                REVERSE_DNS = "true";
+              } // lib.optionalAttrs cfg.reverseDns.verifyBots {
+                VERIFY_BOTS = "true";
+              } // lib.optionalAttrs (cfg.reverseDns.enable || cfg.reverseDns.verifyBots) {
+                REVERSE_DNS_TIMEOUT = cfg.reverseDns.timeout;
+              } // lib.optionalAttrs ((cfg.reverseDns.enable || cfg.reverseDns.verifyBots) && cfg.reverseDns.resolver != null) {
+                REVERSE_DNS_RESOLVER = cfg.reverseDns.resolver;
+              } // lib.optionalAttrs (cfg.tls.certFile != null) {
+                TLS_CERT = cfg.tls.certFile;
+                TLS_KEY = cfg.tls.keyFile;
+                TLS_CLIENT_AUTH = cfg.tls.clientAuth;
+              } // lib.optionalAttrs (cfg.tls.clientCA != null) {
+                TLS_CLIENT_CA = cfg.tls.clientCA;
+              } // lib.optionalAttrs cfg.proxyProtocol.enable {
+                PROXY_PROTOCOL = "true";
+                PROXY_PROTOCOL_ALLOWED = lib.concatStringsSep "," cfg.proxyProtocol.allowedUpstreams;
               };
 
               serviceConfig = {
@@ -115,6 +280,8 @@
                 ExecStart = "${cfg.package}/bin/connectionInfo";
                 Restart = "on-failure";
                 RestartSec = "5s";
+                ExecReload = "${pkgs.coreutils}/bin/kill -HUP $MAINPID";
+                SupplementaryGroups = lib.optional (cfg.tls.group != null) cfg.tls.group;
 
                 # Security hardening
                 DynamicUser = true;
@@ -149,7 +316,7 @@
                   forceSSL = cfg.nginx.forceSSL;
                   enableACME = cfg.nginx.enableACME;
                   locations."/" = {
-                    proxyPass = "http://127.0.0.1:${toString cfg.port}";
+                    proxyPass = upstream;
                   };
                 };
               })
@@ -165,7 +332,7 @@
                   };
                   # Proxy with prefix stripping (trailing slash on proxy_pass)
                   locations."${cfg.basePath}/" = {
-                    proxyPass = "http://127.0.0.1:${toString cfg.port}/";
+                    proxyPass = "${upstream}/";
                   };
                 };
               })
@@ -0,0 +1,130 @@
+package clienthello
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// maxHelloSize bounds how much of a connection is buffered while looking for
+// the end of the ClientHello. Real ClientHellos, even with post-quantum key
+// shares, are a few kilobytes.
+const maxHelloSize = 64 << 10
+
+// Listener wraps a net.Listener so that every accepted connection records
+// its ClientHello. It belongs directly beneath the TLS listener.
+type Listener struct {
+	net.Listener
+}
+
+// Accept waits for the next connection.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{Conn: c}, nil
+}
+
+// Conn passes reads through unchanged while keeping a copy of the bytes up
+// to the end of the ClientHello, which it then decodes.
+type Conn struct {
+	net.Conn
+
+	mu    sync.Mutex
+	buf   []byte // Bytes read so far; released once done
+	done  bool
+	hello *Hello
+	err   error
+}
+
+// Read reads from the underlying connection.
+func (c *Conn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.capture(b[:n])
+	}
+	return n, err
+}
+
+// capture appends data read from the connection and decodes the ClientHello
+// once it is complete.
+func (c *Conn) capture(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done {
+		return
+	}
+	c.buf = append(c.buf, data...)
+
+	msg, complete, err := reassemble(c.buf)
+	switch {
+	case err != nil:
+		c.err = err
+	case complete:
+		c.hello, c.err = Parse(msg)
+	case len(c.buf) > maxHelloSize:
+		c.err = fmt.Errorf("%w: larger than %d bytes", errMalformed, maxHelloSize)
+	default:
+		return
+	}
+	c.done, c.buf = true, nil
+}
+
+// reassemble extracts the first handshake message from the TLS records in
+// buf, which may be split across several records. It reports whether the
+// message is complete.
+func reassemble(buf []byte) ([]byte, bool, error) {
+	var msg []byte
+	for len(buf) >= 5 {
+		if buf[0] != recordTypeHandshake {
+			return nil, false, ErrNotTLS
+		}
+		length := int(buf[3])<<8 | int(buf[4])
+		if len(buf) < 5+length {
+			break
+		}
+		msg = append(msg, buf[5:5+length]...)
+		buf = buf[5+length:]
+
+		if len(msg) >= 4 {
+			if msg[0] != handshakeTypeClientHello {
+				return nil, false, ErrNotTLS
+			}
+			end := 4 + (int(msg[1])<<16 | int(msg[2])<<8 | int(msg[3]))
+			if len(msg) >= end {
+				return msg[:end], true, nil
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+// Hello returns the decoded ClientHello, or nil and the reason it could not
+// be captured. Before the handshake completes, both are nil.
+func (c *Conn) Hello() (*Hello, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hello, c.err
+}
+
+// NetConn returns the underlying connection.
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the recording connection.
+func NewContext(ctx context.Context, c *Conn) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the connection stored by NewContext, or nil if the
+// request did not arrive through one.
+func FromContext(ctx context.Context) *Conn {
+	c, _ := ctx.Value(contextKey{}).(*Conn)
+	return c
+}
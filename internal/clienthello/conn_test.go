@@ -0,0 +1,115 @@
+package clienthello
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// captureClient runs a crypto/tls client handshake into a Conn and returns
+// the ClientHello it recorded. The handshake itself is never completed.
+func captureClient(t *testing.T, config *tls.Config) *Hello {
+	t.Helper()
+
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		tls.Client(client, config).Handshake()
+		client.Close()
+	}()
+
+	conn := &Conn{Conn: server}
+	buf := make([]byte, 512)
+	for {
+		if hello, err := conn.Hello(); hello != nil || err != nil {
+			if err != nil {
+				t.Fatalf("Hello() error = %v", err)
+			}
+			return hello
+		}
+		if _, err := conn.Read(buf); err != nil {
+			t.Fatalf("Read() error = %v before the ClientHello was complete", err)
+		}
+	}
+}
+
+func TestConn_CapturesClientHello(t *testing.T) {
+	hello := captureClient(t, &tls.Config{ServerName: "example.com", NextProtos: []string{"h2", "http/1.1"}})
+
+	if hello.ServerName != "example.com" {
+		t.Errorf("ServerName = %q, want example.com", hello.ServerName)
+	}
+	if !slices.Equal(hello.ALPN, []string{"h2", "http/1.1"}) {
+		t.Errorf("ALPN = %v", hello.ALPN)
+	}
+	if !slices.Contains(hello.SupportedVersions, tls.VersionTLS13) {
+		t.Errorf("SupportedVersions = %v, want TLS 1.3 offered", hello.SupportedVersions)
+	}
+	for _, ext := range []uint16{extServerName, extSupportedGroups, extSignatureAlgorithms, extALPN, extSupportedVersions} {
+		if !slices.Contains(hello.Extensions, ext) {
+			t.Errorf("Extensions = %v, want 0x%04x", hello.Extensions, ext)
+		}
+	}
+	if ja4, _ := hello.JA4(); !strings.HasPrefix(ja4, "t13d") || !strings.Contains(ja4, "h2_") {
+		t.Errorf("JA4() = %q, want a TLS 1.3 fingerprint with SNI and h2", ja4)
+	}
+
+	hello = captureClient(t, &tls.Config{InsecureSkipVerify: true, MaxVersion: tls.VersionTLS12})
+	if hello.ServerName != "" || hello.ALPN != nil {
+		t.Errorf("ServerName, ALPN = %q, %v; want none", hello.ServerName, hello.ALPN)
+	}
+	if ja4, _ := hello.JA4(); !strings.HasPrefix(ja4, "t12i") || !strings.Contains(ja4, "00_") {
+		t.Errorf("JA4() = %q, want a TLS 1.2 fingerprint without SNI or ALPN", ja4)
+	}
+}
+
+func TestConn_SplitRecords(t *testing.T) {
+	msg := captureClient(t, &tls.Config{ServerName: "example.com"}).Raw
+
+	// Split the message across two records, delivered in small reads
+	var stream []byte
+	for _, part := range [][]byte{msg[:10], msg[10:]} {
+		stream = append(stream, recordTypeHandshake, 3, 1, byte(len(part)>>8), byte(len(part)))
+		stream = append(stream, part...)
+	}
+
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		for i := 0; i < len(stream); i += 7 {
+			client.Write(stream[i:min(i+7, len(stream))])
+		}
+		client.Close()
+	}()
+
+	conn := &Conn{Conn: server}
+	if _, err := io.Copy(io.Discard, conn); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	hello, err := conn.Hello()
+	if err != nil || hello == nil || hello.ServerName != "example.com" {
+		t.Errorf("Hello() = %+v, %v; want the reassembled ClientHello", hello, err)
+	}
+}
+
+func TestConn_NotTLS(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		client.Write([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+		client.Close()
+	}()
+
+	conn := &Conn{Conn: server}
+	data, err := io.ReadAll(conn)
+	if err != nil || !strings.HasPrefix(string(data), "GET / ") {
+		t.Fatalf("ReadAll() = %q, %v; want the data passed through", data, err)
+	}
+	if hello, err := conn.Hello(); hello != nil || !errors.Is(err, ErrNotTLS) {
+		t.Errorf("Hello() = %v, %v; want ErrNotTLS", hello, err)
+	}
+}
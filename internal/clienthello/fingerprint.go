@@ -0,0 +1,157 @@
+package clienthello
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JA3 returns the JA3 string of the ClientHello and its MD5 hash, the JA3
+// fingerprint. The string lists, in decimal and with GREASE values removed,
+// the legacy version, cipher suites, extensions, supported groups and EC
+// point formats, in the order the client sent them.
+//
+// Specification: https://github.com/salesforce/ja3
+func (h *Hello) JA3() (string, string) {
+	formats := make([]uint16, len(h.ECPointFormats))
+	for i, f := range h.ECPointFormats {
+		formats[i] = uint16(f)
+	}
+
+	s := strings.Join([]string{
+		strconv.Itoa(int(h.Version)),
+		joinDecimal(h.CipherSuites),
+		joinDecimal(h.Extensions),
+		joinDecimal(h.SupportedGroups),
+		joinDecimal(formats),
+	}, ",")
+	sum := md5.Sum([]byte(s))
+	return s, hex.EncodeToString(sum[:])
+}
+
+// JA4 returns the JA4 fingerprint of the ClientHello, e.g.
+// "t13d1516h2_8daaf6152771_e5627efa2ab1", and its raw form JA4_r, in which
+// the second and third parts are the hashed lists themselves.
+//
+// The first part summarizes the transport (always TCP here), the highest
+// offered version, whether SNI was sent, the number of cipher suites and
+// extensions, and the first ALPN protocol. The second part hashes the sorted
+// cipher suites; the third the sorted extensions, without SNI and ALPN,
+// followed by the signature algorithms in the order sent. GREASE values are
+// ignored throughout.
+//
+// Specification: https://github.com/FoxIO-LLC/ja4/blob/main/technical_details/JA4.md
+func (h *Hello) JA4() (string, string) {
+	ciphers := withoutGREASE(h.CipherSuites)
+	extensions := withoutGREASE(h.Extensions)
+
+	sni := "i"
+	if slices.Contains(extensions, extServerName) {
+		sni = "d"
+	}
+	prefix := fmt.Sprintf("t%s%s%02d%02d%s", h.ja4Version(), sni, min(len(ciphers), 99), min(len(extensions), 99), h.ja4ALPN())
+
+	slices.Sort(ciphers)
+	cipherList := joinHex(ciphers)
+
+	var hashed []uint16
+	for _, ext := range extensions {
+		if ext != extServerName && ext != extALPN {
+			hashed = append(hashed, ext)
+		}
+	}
+	slices.Sort(hashed)
+	extList := joinHex(hashed)
+	if sigs := withoutGREASE(h.SignatureAlgorithms); len(sigs) > 0 {
+		extList += "_" + joinHex(sigs)
+	}
+
+	ja4 := prefix + "_" + truncatedHash(cipherList, len(ciphers)) + "_" + truncatedHash(extList, len(hashed))
+	raw := prefix + "_" + cipherList + "_" + extList
+	return ja4, raw
+}
+
+// ja4Version returns the two-character code of the highest version offered.
+func (h *Hello) ja4Version() string {
+	version := h.Version
+	if versions := withoutGREASE(h.SupportedVersions); len(versions) > 0 {
+		version = slices.Max(versions)
+	}
+	switch version {
+	case 0x0304:
+		return "13"
+	case 0x0303:
+		return "12"
+	case 0x0302:
+		return "11"
+	case 0x0301:
+		return "10"
+	case 0x0300:
+		return "s3"
+	case 0x0002:
+		return "s2"
+	default:
+		return "00"
+	}
+}
+
+// ja4ALPN returns the first and last characters of the first ALPN protocol,
+// or of its hex encoding if either is not alphanumeric, or "00" if none.
+func (h *Hello) ja4ALPN() string {
+	if len(h.ALPN) == 0 || h.ALPN[0] == "" {
+		return "00"
+	}
+	proto := h.ALPN[0]
+	first, last := proto[0], proto[len(proto)-1]
+	if isAlphanumeric(first) && isAlphanumeric(last) {
+		return string([]byte{first, last})
+	}
+	encoded := hex.EncodeToString([]byte(proto))
+	return string([]byte{encoded[0], encoded[len(encoded)-1]})
+}
+
+func isAlphanumeric(b byte) bool {
+	return '0' <= b && b <= '9' || 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z'
+}
+
+// truncatedHash returns the first 12 hex digits of the SHA-256 of s, or
+// zeros if the list it encodes is empty.
+func truncatedHash(s string, n int) string {
+	if n == 0 {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func withoutGREASE(values []uint16) []uint16 {
+	var out []uint16
+	for _, v := range values {
+		if !IsGREASE(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// joinDecimal formats the non-GREASE values in decimal, separated by dashes.
+func joinDecimal(values []uint16) string {
+	var parts []string
+	for _, v := range withoutGREASE(values) {
+		parts = append(parts, strconv.Itoa(int(v)))
+	}
+	return strings.Join(parts, "-")
+}
+
+// joinHex formats values as 4-digit lower-case hex, separated by commas.
+func joinHex(values []uint16) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%04x", v)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,72 @@
+package clienthello
+
+import "testing"
+
+// The expected fingerprints are the examples from the JA3 and JA4
+// specifications, with GREASE values added, which must not change them.
+
+func TestHello_JA3(t *testing.T) {
+	h := &Hello{
+		Version:         769,
+		CipherSuites:    []uint16{0x0a0a, 47, 53, 5, 10, 49161, 49162, 49171, 49172, 50, 56, 19, 4},
+		Extensions:      []uint16{0x1a1a, 0, 10, 11},
+		SupportedGroups: []uint16{0x2a2a, 23, 24, 25},
+		ECPointFormats:  []uint8{0},
+	}
+
+	s, hash := h.JA3()
+	if want := "769,47-53-5-10-49161-49162-49171-49172-50-56-19-4,0-10-11,23-24-25,0"; s != want {
+		t.Errorf("JA3() string = %q, want %q", s, want)
+	}
+	if want := "ada70206e40642a3e4461f35503241d5"; hash != want {
+		t.Errorf("JA3() hash = %q, want %q", hash, want)
+	}
+}
+
+func TestHello_JA4(t *testing.T) {
+	h := &Hello{
+		Version: 0x0303,
+		CipherSuites: []uint16{
+			0x3a3a, 0x1301, 0x1302, 0x1303, 0xc02b, 0xc02f, 0xc02c, 0xc030,
+			0xcca9, 0xcca8, 0xc013, 0xc014, 0x009c, 0x009d, 0x002f, 0x0035,
+		},
+		Extensions: []uint16{
+			0x4a4a, 0x0000, 0x0017, 0xff01, 0x000a, 0x000b, 0x0023, 0x0010, 0x0005,
+			0x000d, 0x0012, 0x0033, 0x002d, 0x002b, 0x001b, 0x4469, 0x0015,
+		},
+		SupportedVersions:   []uint16{0x5a5a, 0x0304, 0x0303},
+		SignatureAlgorithms: []uint16{0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601},
+		ALPN:                []string{"h2", "http/1.1"},
+	}
+
+	ja4, raw := h.JA4()
+	if want := "t13d1516h2_8daaf6152771_e5627efa2ab1"; ja4 != want {
+		t.Errorf("JA4() = %q, want %q", ja4, want)
+	}
+	wantRaw := "t13d1516h2_002f,0035,009c,009d,1301,1302,1303,c013,c014,c02b,c02c,c02f,c030,cca8,cca9_" +
+		"0005,000a,000b,000d,0012,0015,0017,001b,0023,002b,002d,0033,4469,ff01_" +
+		"0403,0804,0401,0503,0805,0501,0806,0601"
+	if raw != wantRaw {
+		t.Errorf("JA4() raw = %q, want %q", raw, wantRaw)
+	}
+}
+
+func TestHello_JA4Prefix(t *testing.T) {
+	tests := []struct {
+		name string
+		h    Hello
+		want string
+	}{
+		{"empty", Hello{}, "t00i000000_000000000000_000000000000"},
+		{"legacy version, no SNI", Hello{Version: 0x0301, CipherSuites: []uint16{0x002f}, Extensions: []uint16{0x0017}}, "t10i0101"},
+		{"non-alphanumeric ALPN", Hello{ALPN: []string{"\x01x\xff"}}, "t00i00000f"},
+		{"single-character ALPN", Hello{ALPN: []string{"x"}}, "t00i0000xx"},
+	}
+
+	for _, tt := range tests {
+		got, _ := tt.h.JA4()
+		if len(got) < len(tt.want) || got[:len(tt.want)] != tt.want {
+			t.Errorf("%s: JA4() = %q, want prefix %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package clienthello captures the TLS ClientHello a connection begins with
+// and computes the JA3 and JA4 fingerprints of the client that sent it.
+//
+// ClientHello format: https://www.rfc-editor.org/rfc/rfc8446#section-4.1.2
+package clienthello
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Hello is a decoded ClientHello. Lists keep the order the client sent them
+// in, including GREASE values.
+type Hello struct {
+	Raw                 []byte // Handshake message, without record framing
+	Version             uint16 // legacy_version; the real version is in SupportedVersions
+	CipherSuites        []uint16
+	CompressionMethods  []uint8
+	Extensions          []uint16 // Extension types
+	ServerName          string
+	SupportedVersions   []uint16
+	SupportedGroups     []uint16
+	ECPointFormats      []uint8
+	SignatureAlgorithms []uint16
+	ALPN                []string
+}
+
+// Extension types decoded by Parse or treated specially by JA4.
+const (
+	extServerName          = 0x0000
+	extSupportedGroups     = 0x000a
+	extECPointFormats      = 0x000b
+	extSignatureAlgorithms = 0x000d
+	extALPN                = 0x0010
+	extSupportedVersions   = 0x002b
+)
+
+const (
+	recordTypeHandshake      = 22
+	handshakeTypeClientHello = 1
+)
+
+var (
+	// ErrNotTLS is returned when a connection does not begin with a TLS
+	// handshake record.
+	ErrNotTLS = errors.New("clienthello: not a TLS handshake")
+
+	errMalformed = errors.New("clienthello: malformed ClientHello")
+)
+
+// IsGREASE reports whether v is one of the reserved GREASE values
+// (RFC 8701), such as 0x0a0a or 0xfafa, which clients send to keep servers
+// tolerant of unknown values.
+func IsGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// Parse decodes a ClientHello handshake message: the 4-byte handshake
+// header followed by the body.
+func Parse(msg []byte) (*Hello, error) {
+	if len(msg) < 4 || msg[0] != handshakeTypeClientHello {
+		return nil, fmt.Errorf("%w: not a ClientHello message", errMalformed)
+	}
+	if int(msg[1])<<16|int(msg[2])<<8|int(msg[3]) != len(msg)-4 {
+		return nil, fmt.Errorf("%w: length mismatch", errMalformed)
+	}
+
+	h := &Hello{Raw: msg}
+	s := reader(msg[4:])
+	var random, sessionID, ciphers, compression, extensions reader
+	if !s.uint16(&h.Version) || !s.bytes(32, &random) || !s.vector8(&sessionID) ||
+		!s.vector16(&ciphers) || !s.vector8(&compression) {
+		return nil, fmt.Errorf("%w: truncated", errMalformed)
+	}
+	if len(ciphers)%2 != 0 {
+		return nil, fmt.Errorf("%w: odd cipher suite list", errMalformed)
+	}
+	h.CipherSuites = ciphers.uint16s()
+	h.CompressionMethods = []uint8(compression)
+
+	// Extensions are optional before TLS 1.3
+	if len(s) == 0 {
+		return h, nil
+	}
+	if !s.vector16(&extensions) || len(s) != 0 {
+		return nil, fmt.Errorf("%w: bad extensions block", errMalformed)
+	}
+	for len(extensions) > 0 {
+		var typ uint16
+		var data reader
+		if !extensions.uint16(&typ) || !extensions.vector16(&data) {
+			return nil, fmt.Errorf("%w: truncated extension", errMalformed)
+		}
+		h.Extensions = append(h.Extensions, typ)
+		if err := h.parseExtension(typ, data); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// parseExtension decodes the contents of the extensions that are shown or
+// fingerprinted. Others are only recorded by type.
+func (h *Hello) parseExtension(typ uint16, data reader) error {
+	var list reader
+	switch typ {
+	case extServerName:
+		if !data.vector16(&list) {
+			return fmt.Errorf("%w: bad server_name", errMalformed)
+		}
+		for len(list) > 0 {
+			var nameType uint8
+			var name reader
+			if !list.uint8(&nameType) || !list.vector16(&name) {
+				return fmt.Errorf("%w: bad server_name", errMalformed)
+			}
+			if nameType == 0 {
+				h.ServerName = string(name)
+			}
+		}
+	case extSupportedGroups:
+		if !data.vector16(&list) || len(list)%2 != 0 {
+			return fmt.Errorf("%w: bad supported_groups", errMalformed)
+		}
+		h.SupportedGroups = list.uint16s()
+	case extECPointFormats:
+		if !data.vector8(&list) {
+			return fmt.Errorf("%w: bad ec_point_formats", errMalformed)
+		}
+		h.ECPointFormats = []uint8(list)
+	case extSignatureAlgorithms:
+		if !data.vector16(&list) || len(list)%2 != 0 {
+			return fmt.Errorf("%w: bad signature_algorithms", errMalformed)
+		}
+		h.SignatureAlgorithms = list.uint16s()
+	case extALPN:
+		if !data.vector16(&list) {
+			return fmt.Errorf("%w: bad ALPN", errMalformed)
+		}
+		for len(list) > 0 {
+			var proto reader
+			if !list.vector8(&proto) {
+				return fmt.Errorf("%w: bad ALPN", errMalformed)
+			}
+			h.ALPN = append(h.ALPN, string(proto))
+		}
+	case extSupportedVersions:
+		if !data.vector8(&list) || len(list)%2 != 0 {
+			return fmt.Errorf("%w: bad supported_versions", errMalformed)
+		}
+		h.SupportedVersions = list.uint16s()
+	}
+	return nil
+}
+
+// reader consumes big-endian fields from the front of a byte slice.
+type reader []byte
+
+func (r *reader) uint8(v *uint8) bool {
+	if len(*r) < 1 {
+		return false
+	}
+	*v = (*r)[0]
+	*r = (*r)[1:]
+	return true
+}
+
+func (r *reader) uint16(v *uint16) bool {
+	if len(*r) < 2 {
+		return false
+	}
+	*v = uint16((*r)[0])<<8 | uint16((*r)[1])
+	*r = (*r)[2:]
+	return true
+}
+
+func (r *reader) bytes(n int, v *reader) bool {
+	if len(*r) < n {
+		return false
+	}
+	*v = (*r)[:n]
+	*r = (*r)[n:]
+	return true
+}
+
+// vector8 reads a vector with a one-byte length prefix.
+func (r *reader) vector8(v *reader) bool {
+	var n uint8
+	return r.uint8(&n) && r.bytes(int(n), v)
+}
+
+// vector16 reads a vector with a two-byte length prefix.
+func (r *reader) vector16(v *reader) bool {
+	var n uint16
+	return r.uint16(&n) && r.bytes(int(n), v)
+}
+
+// uint16s decodes the remaining bytes, an even number, as a list.
+func (r reader) uint16s() []uint16 {
+	list := make([]uint16, 0, len(r)/2)
+	for i := 0; i+1 < len(r); i += 2 {
+		list = append(list, uint16(r[i])<<8|uint16(r[i+1]))
+	}
+	return list
+}
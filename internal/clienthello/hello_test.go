@@ -0,0 +1,68 @@
+package clienthello
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIsGREASE(t *testing.T) {
+	for _, v := range []uint16{0x0a0a, 0x1a1a, 0xaaaa, 0xfafa} {
+		if !IsGREASE(v) {
+			t.Errorf("IsGREASE(0x%04x) = false, want true", v)
+		}
+	}
+	for _, v := range []uint16{0x0000, 0x0a1a, 0x1301, 0x0b0b, 0x0a0b} {
+		if IsGREASE(v) {
+			t.Errorf("IsGREASE(0x%04x) = true, want false", v)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	body := []byte{0x03, 0x03}               // legacy_version
+	body = append(body, make([]byte, 32)...) // random
+	body = append(body, 0)                   // session ID
+	body = append(body, 0, 4, 0x13, 0x01, 0xc0, 0x2f)
+	body = append(body, 1, 0) // compression
+	extensions := []byte{
+		0x00, 0x00, 0, 16, 0, 14, 0, 0, 11, 'e', 'x', 'a', 'm', 'p', 'l', 'e', '.', 'c', 'o', 'm',
+		0x00, 0x0a, 0, 6, 0, 4, 0x00, 0x1d, 0x00, 0x17,
+		0x00, 0x0b, 0, 2, 1, 0,
+		0x00, 0x10, 0, 5, 0, 3, 2, 'h', '2',
+		0x00, 0x2b, 0, 5, 4, 0x03, 0x04, 0x03, 0x03,
+		0xff, 0x01, 0, 1, 0,
+	}
+	body = append(body, byte(len(extensions)>>8), byte(len(extensions)))
+	body = append(body, extensions...)
+	msg := append([]byte{handshakeTypeClientHello, 0, byte(len(body) >> 8), byte(len(body))}, body...)
+
+	h, err := Parse(msg)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if h.Version != 0x0303 || len(h.CipherSuites) != 2 || h.CipherSuites[1] != 0xc02f {
+		t.Errorf("Version, CipherSuites = 0x%04x, %v", h.Version, h.CipherSuites)
+	}
+	if h.ServerName != "example.com" || len(h.ALPN) != 1 || h.ALPN[0] != "h2" {
+		t.Errorf("ServerName, ALPN = %q, %v", h.ServerName, h.ALPN)
+	}
+	if len(h.SupportedGroups) != 2 || len(h.ECPointFormats) != 1 || len(h.SupportedVersions) != 2 {
+		t.Errorf("SupportedGroups, ECPointFormats, SupportedVersions = %v, %v, %v", h.SupportedGroups, h.ECPointFormats, h.SupportedVersions)
+	}
+	if want := []uint16{0x0000, 0x000a, 0x000b, 0x0010, 0x002b, 0xff01}; !slices.Equal(h.Extensions, want) {
+		t.Errorf("Extensions = %v, want %v", h.Extensions, want)
+	}
+
+	invalid := map[string][]byte{
+		"empty":           nil,
+		"server hello":    append([]byte{2}, msg[1:]...),
+		"length mismatch": msg[:len(msg)-1],
+		"truncated body":  append([]byte{handshakeTypeClientHello, 0, 0, 10}, body[:10]...),
+		"trailing bytes":  append([]byte{handshakeTypeClientHello, 0, byte((len(body) + 1) >> 8), byte(len(body) + 1)}, append(body, 0)...),
+	}
+	for name, msg := range invalid {
+		if _, err := Parse(msg); err == nil {
+			t.Errorf("%s: Parse() should fail", name)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package clienthello
+
+import "crypto/tls"
+
+// Registered names of the values in a ClientHello, from the IANA TLS
+// parameter registries. Values that are not listed have no name.
+
+// VersionName returns the name of a protocol version, e.g. "TLS 1.3".
+func VersionName(v uint16) string {
+	switch {
+	case IsGREASE(v):
+		return "GREASE"
+	case v == 0x0300:
+		return "SSL 3.0"
+	case v == 0x0002:
+		return "SSL 2.0"
+	case v >= 0x0301 && v <= 0x0304:
+		return tls.VersionName(v)
+	}
+	return ""
+}
+
+// CipherSuiteName returns the name of a cipher suite, e.g.
+// "TLS_AES_128_GCM_SHA256".
+func CipherSuiteName(v uint16) string {
+	if IsGREASE(v) {
+		return "GREASE"
+	}
+	return cipherSuiteNames[v]
+}
+
+// ExtensionName returns the name of an extension type, e.g. "key_share".
+func ExtensionName(v uint16) string {
+	if IsGREASE(v) {
+		return "GREASE"
+	}
+	return extensionNames[v]
+}
+
+// GroupName returns the name of a supported group, e.g. "x25519".
+func GroupName(v uint16) string {
+	if IsGREASE(v) {
+		return "GREASE"
+	}
+	return groupNames[v]
+}
+
+// SignatureSchemeName returns the name of a signature scheme, e.g.
+// "ecdsa_secp256r1_sha256".
+func SignatureSchemeName(v uint16) string {
+	if IsGREASE(v) {
+		return "GREASE"
+	}
+	return signatureSchemeNames[v]
+}
+
+// PointFormatName returns the name of an EC point format.
+func PointFormatName(v uint8) string {
+	return pointFormatNames[v]
+}
+
+var cipherSuiteNames = func() map[uint16]string {
+	names := map[uint16]string{
+		// Suites crypto/tls does not implement but clients commonly offer
+		0x0033: "TLS_DHE_RSA_WITH_AES_128_CBC_SHA",
+		0x0039: "TLS_DHE_RSA_WITH_AES_256_CBC_SHA",
+		0x0067: "TLS_DHE_RSA_WITH_AES_128_CBC_SHA256",
+		0x006b: "TLS_DHE_RSA_WITH_AES_256_CBC_SHA256",
+		0x009e: "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256",
+		0x009f: "TLS_DHE_RSA_WITH_AES_256_GCM_SHA384",
+		0x003d: "TLS_RSA_WITH_AES_256_CBC_SHA256",
+		0x00ff: "TLS_EMPTY_RENEGOTIATION_INFO_SCSV",
+		0x1304: "TLS_AES_128_CCM_SHA256",
+		0x1305: "TLS_AES_128_CCM_8_SHA256",
+		0x5600: "TLS_FALLBACK_SCSV",
+		0xc024: "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA384",
+		0xc028: "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA384",
+		0xccaa: "TLS_DHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+	}
+	for _, suites := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, s := range suites {
+			names[s.ID] = s.Name
+		}
+	}
+	return names
+}()
+
+var extensionNames = map[uint16]string{
+	0x0000: "server_name",
+	0x0001: "max_fragment_length",
+	0x0005: "status_request",
+	0x000a: "supported_groups",
+	0x000b: "ec_point_formats",
+	0x000d: "signature_algorithms",
+	0x000e: "use_srtp",
+	0x000f: "heartbeat",
+	0x0010: "application_layer_protocol_negotiation",
+	0x0011: "status_request_v2",
+	0x0012: "signed_certificate_timestamp",
+	0x0015: "padding",
+	0x0016: "encrypt_then_mac",
+	0x0017: "extended_master_secret",
+	0x001b: "compress_certificate",
+	0x001c: "record_size_limit",
+	0x0022: "delegated_credential",
+	0x0023: "session_ticket",
+	0x0029: "pre_shared_key",
+	0x002a: "early_data",
+	0x002b: "supported_versions",
+	0x002c: "cookie",
+	0x002d: "psk_key_exchange_modes",
+	0x002f: "certificate_authorities",
+	0x0031: "post_handshake_auth",
+	0x0032: "signature_algorithms_cert",
+	0x0033: "key_share",
+	0x0039: "quic_transport_parameters",
+	0x3374: "next_protocol_negotiation",
+	0x4469: "application_settings_old",
+	0x44cd: "application_settings",
+	0xfe0d: "encrypted_client_hello",
+	0xff01: "renegotiation_info",
+}
+
+var groupNames = map[uint16]string{
+	0x0017: "secp256r1",
+	0x0018: "secp384r1",
+	0x0019: "secp521r1",
+	0x001d: "x25519",
+	0x001e: "x448",
+	0x0100: "ffdhe2048",
+	0x0101: "ffdhe3072",
+	0x0102: "ffdhe4096",
+	0x0103: "ffdhe6144",
+	0x0104: "ffdhe8192",
+	0x11eb: "SecP256r1MLKEM768",
+	0x11ec: "X25519MLKEM768",
+	0x11ed: "SecP384r1MLKEM1024",
+	0x6399: "X25519Kyber768Draft00",
+}
+
+var signatureSchemeNames = map[uint16]string{
+	0x0201: "rsa_pkcs1_sha1",
+	0x0203: "ecdsa_sha1",
+	0x0401: "rsa_pkcs1_sha256",
+	0x0403: "ecdsa_secp256r1_sha256",
+	0x0501: "rsa_pkcs1_sha384",
+	0x0503: "ecdsa_secp384r1_sha384",
+	0x0601: "rsa_pkcs1_sha512",
+	0x0603: "ecdsa_secp521r1_sha512",
+	0x0804: "rsa_pss_rsae_sha256",
+	0x0805: "rsa_pss_rsae_sha384",
+	0x0806: "rsa_pss_rsae_sha512",
+	0x0807: "ed25519",
+	0x0808: "ed448",
+	0x0809: "rsa_pss_pss_sha256",
+	0x080a: "rsa_pss_pss_sha384",
+	0x080b: "rsa_pss_pss_sha512",
+	0x081a: "ecdsa_brainpoolP256r1tls13_sha256",
+	0x081b: "ecdsa_brainpoolP384r1tls13_sha384",
+	0x081c: "ecdsa_brainpoolP512r1tls13_sha512",
+}
+
+var pointFormatNames = map[uint8]string{
+	0: "uncompressed",
+	1: "ansiX962_compressed_prime",
+	2: "ansiX962_compressed_char2",
+}
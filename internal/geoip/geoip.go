@@ -0,0 +1,213 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultReloadInterval is how often Watch checks database files for changes.
+const DefaultReloadInterval = time.Minute
+
+// Location is what the configured databases know about an IP address.
+// Fields a database does not provide are left empty.
+type Location struct {
+	CountryCode    string  // ISO 3166-1 alpha-2 code, e.g. "DE"
+	Country        string  // English country name
+	RegionCode     string  // ISO 3166-2 subdivision code, without the country prefix
+	Region         string  // English name of the largest subdivision
+	City           string  // English city name
+	HasCoordinates bool    // Latitude and Longitude are set
+	Latitude       float64 // Approximate latitude
+	Longitude      float64 // Approximate longitude
+	AccuracyRadius uint    // Radius around the coordinates, in kilometres
+	TimeZone       string  // IANA time zone, e.g. "Europe/Berlin"
+	ASN            uint    // Autonomous system number
+	Organization   string  // Organization that owns the autonomous system
+}
+
+// DB is an MMDB file loaded into memory. Reload replaces its contents when
+// the file changes on disk; lookups running at the time keep using the old
+// contents. It is safe for concurrent use.
+type DB struct {
+	Path string
+
+	reader atomic.Pointer[Reader]
+
+	mu      sync.Mutex // Serializes reloads
+	modTime time.Time
+	size    int64
+}
+
+// Open loads the MMDB file at path.
+func Open(path string) (*DB, error) {
+	db := &DB{Path: path}
+	if _, err := db.Reload(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Reload loads the file again if its modification time or size changed since
+// it was last loaded, and reports whether it did. If the new file cannot be
+// read or parsed, the previous contents stay in use; a file that cannot be
+// parsed is reported once and not parsed again until it changes.
+func (db *DB) Reload() (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	fi, err := os.Stat(db.Path)
+	if err != nil {
+		return false, err
+	}
+	if db.reader.Load() != nil && fi.ModTime().Equal(db.modTime) && fi.Size() == db.size {
+		return false, nil
+	}
+
+	buf, err := os.ReadFile(db.Path)
+	if err != nil {
+		return false, err
+	}
+	r, err := NewReader(buf)
+	if err != nil {
+		// An invalid file is only tried again once it changes
+		db.modTime, db.size = fi.ModTime(), fi.Size()
+		return false, fmt.Errorf("%s: %w", db.Path, err)
+	}
+
+	db.reader.Store(r)
+	db.modTime, db.size = fi.ModTime(), fi.Size()
+	return true, nil
+}
+
+// Metadata returns the metadata of the loaded file.
+func (db *DB) Metadata() Metadata {
+	return db.reader.Load().Metadata
+}
+
+// Databases is a set of databases consulted together, such as a City and
+// an ASN database.
+type Databases []*DB
+
+// OpenAll opens the MMDB files at paths.
+func OpenAll(paths []string) (Databases, error) {
+	dbs := make(Databases, 0, len(paths))
+	for _, path := range paths {
+		db, err := Open(path)
+		if err != nil {
+			return nil, err
+		}
+		dbs = append(dbs, db)
+	}
+	return dbs, nil
+}
+
+// Lookup returns what the databases know about addr, or nil if none has an
+// entry for it. When several databases provide a field, the first one in
+// the list wins. Lookup errors are treated as missing entries.
+func (dbs Databases) Lookup(addr netip.Addr) *Location {
+	var loc *Location
+	for _, db := range dbs {
+		record, _, err := db.reader.Load().Lookup(addr)
+		if err != nil || record == nil {
+			continue
+		}
+		if loc == nil {
+			loc = &Location{}
+		}
+		loc.merge(record)
+	}
+	return loc
+}
+
+// Watch reloads each database whose file changed, checking every interval
+// until stop is closed. Failures are logged; an invalid file is retried
+// once it changes.
+func (dbs Databases) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		dbs.Check()
+	}
+}
+
+// Check reloads each database whose file changed, logging the outcome.
+func (dbs Databases) Check() {
+	for _, db := range dbs {
+		reloaded, err := db.Reload()
+		switch {
+		case err != nil && !errors.Is(err, os.ErrNotExist):
+			log.Printf("GeoIP reload failed: %v", err)
+		case err != nil:
+			log.Printf("GeoIP database %s is missing; keeping the loaded copy", db.Path)
+		case reloaded:
+			log.Printf("Reloaded GeoIP database %s (%s)", db.Path, db.Metadata().DatabaseType)
+		}
+	}
+}
+
+// merge fills the empty fields of loc from an MMDB record in the GeoIP2 /
+// GeoLite2 schema, which the DB-IP lite databases share.
+func (loc *Location) merge(record map[string]any) {
+	setString(&loc.CountryCode, lookupPath(record, "country", "iso_code"))
+	setString(&loc.Country, englishName(lookupPath(record, "country")))
+	if subdivisions, ok := record["subdivisions"].([]any); ok && len(subdivisions) > 0 {
+		setString(&loc.RegionCode, lookupPath(subdivisions[0], "iso_code"))
+		setString(&loc.Region, englishName(subdivisions[0]))
+	}
+	setString(&loc.City, englishName(lookupPath(record, "city")))
+	setString(&loc.TimeZone, lookupPath(record, "location", "time_zone"))
+
+	if !loc.HasCoordinates {
+		lat, latOK := lookupPath(record, "location", "latitude").(float64)
+		lon, lonOK := lookupPath(record, "location", "longitude").(float64)
+		if latOK && lonOK {
+			loc.HasCoordinates = true
+			loc.Latitude, loc.Longitude = lat, lon
+		}
+	}
+	if loc.AccuracyRadius == 0 {
+		loc.AccuracyRadius = uint(toUint(lookupPath(record, "location", "accuracy_radius")))
+	}
+
+	if loc.ASN == 0 {
+		loc.ASN = uint(toUint(record["autonomous_system_number"]))
+	}
+	setString(&loc.Organization, record["autonomous_system_organization"])
+}
+
+// lookupPath follows keys through nested maps and returns the value found,
+// or nil.
+func lookupPath(v any, keys ...string) any {
+	for _, key := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+// englishName returns the English entry of a record's "names" map.
+func englishName(v any) any {
+	return lookupPath(v, "names", "en")
+}
+
+// setString sets *dst to v if *dst is empty and v is a string.
+func setString(dst *string, v any) {
+	if s, ok := v.(string); ok && *dst == "" {
+		*dst = s
+	}
+}
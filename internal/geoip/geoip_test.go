@@ -0,0 +1,247 @@
+package geoip
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var cityRecord = map[string]any{
+	"city":    map[string]any{"names": map[string]any{"en": "Berlin", "de": "Berlin"}},
+	"country": map[string]any{"iso_code": "DE", "names": map[string]any{"en": "Germany", "de": "Deutschland"}},
+	"location": map[string]any{
+		"accuracy_radius": uint16(20),
+		"latitude":        52.5196,
+		"longitude":       13.4069,
+		"time_zone":       "Europe/Berlin",
+	},
+	"subdivisions": []any{
+		map[string]any{"iso_code": "BE", "names": map[string]any{"en": "Land Berlin"}},
+	},
+}
+
+var asnRecord = map[string]any{
+	"autonomous_system_number":       uint32(64500),
+	"autonomous_system_organization": "Example Networks",
+}
+
+func TestReader_Lookup(t *testing.T) {
+	for _, ipVersion := range []int{4, 6} {
+		for _, recordSize := range []int{24, 28, 32} {
+			networks := map[string]map[string]any{
+				"192.0.2.0/24":      {"name": "v4"},
+				"198.51.100.128/25": {"name": "v4-upper"},
+			}
+			// IPv6 lookups in an IPv4 database find nothing
+			var v6Name any
+			var v6Prefix string
+			if ipVersion == 6 {
+				networks["2001:db8::/32"] = map[string]any{"name": "v6"}
+				v6Name, v6Prefix = "v6", "2001:db8::/32"
+			}
+
+			r, err := NewReader(buildMMDB(t, ipVersion, recordSize, networks))
+			if err != nil {
+				t.Fatalf("v%d/%d: NewReader() error = %v", ipVersion, recordSize, err)
+			}
+			if r.Metadata.DatabaseType != "Test-City" || r.Metadata.RecordSize != uint(recordSize) || r.Metadata.IPVersion != ipVersion {
+				t.Errorf("v%d/%d: Metadata = %+v", ipVersion, recordSize, r.Metadata)
+			}
+
+			tests := []struct {
+				addr       string
+				wantName   any
+				wantPrefix string
+			}{
+				{"192.0.2.77", "v4", "192.0.2.0/24"},
+				{"::ffff:192.0.2.1", "v4", "192.0.2.0/24"},
+				{"198.51.100.200", "v4-upper", "198.51.100.128/25"},
+				{"198.51.100.1", nil, "198.51.100.0/25"},
+				{"2001:db8:1::1", v6Name, v6Prefix},
+			}
+
+			for _, tt := range tests {
+				record, prefix, err := r.Lookup(netip.MustParseAddr(tt.addr))
+				if err != nil {
+					t.Errorf("v%d/%d: Lookup(%s) error = %v", ipVersion, recordSize, tt.addr, err)
+					continue
+				}
+				var name any
+				if record != nil {
+					name = record["name"]
+				}
+				if name != tt.wantName {
+					t.Errorf("v%d/%d: Lookup(%s) name = %v, want %v", ipVersion, recordSize, tt.addr, name, tt.wantName)
+				}
+				if tt.wantPrefix != "" && prefix.String() != tt.wantPrefix {
+					t.Errorf("v%d/%d: Lookup(%s) prefix = %v, want %s", ipVersion, recordSize, tt.addr, prefix, tt.wantPrefix)
+				}
+			}
+		}
+	}
+}
+
+func TestReader_DataTypes(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	r, err := NewReader(buildMMDB(t, 6, 28, map[string]map[string]any{
+		"192.0.2.0/24": {"double": 1.5, "bool": true, "long": long, "big": uint64(1) << 40, "list": []any{"a", uint16(2)}},
+		// Sorted after the first record, which starts at data offset 0
+		"198.51.100.0/24": {"alias": pointer(0)},
+	}))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+
+	record, _, err := r.Lookup(netip.MustParseAddr("192.0.2.1"))
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	want := map[string]any{"double": 1.5, "bool": true, "long": long, "big": uint64(1) << 40, "list": []any{"a", uint64(2)}}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("Lookup() = %v, want %v", record, want)
+	}
+
+	record, _, err = r.Lookup(netip.MustParseAddr("198.51.100.1"))
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if !reflect.DeepEqual(record["alias"], want) {
+		t.Errorf("pointer resolved to %v, want %v", record["alias"], want)
+	}
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	valid := buildMMDB(t, 6, 24, map[string]map[string]any{"192.0.2.0/24": {"name": "v4"}})
+
+	// A node count whose tree size wraps around to zero
+	huge := append(make([]byte, 6+dataSectionSeparator), metadataMarker...)
+	huge = append(huge, encodeValue(t, map[string]any{
+		"binary_format_major_version": uint16(2),
+		"ip_version":                  uint16(6),
+		"node_count":                  uint64(1 << 62),
+		"record_size":                 uint16(24),
+	})...)
+
+	tests := map[string][]byte{
+		"empty":              nil,
+		"no marker":          []byte("not a database"),
+		"truncated tree":     valid[len(valid)/2:],
+		"truncated metadata": valid[:len(valid)-3],
+		"huge node count":    huge,
+	}
+	for name, buf := range tests {
+		if _, err := NewReader(buf); err == nil {
+			t.Errorf("%s: NewReader() should fail", name)
+		}
+	}
+}
+
+func TestDatabases_Lookup(t *testing.T) {
+	dir := t.TempDir()
+	cityPath := filepath.Join(dir, "city.mmdb")
+	asnPath := filepath.Join(dir, "asn.mmdb")
+	writeFile(t, cityPath, buildMMDB(t, 6, 24, map[string]map[string]any{"192.0.2.0/24": cityRecord}))
+	writeFile(t, asnPath, buildMMDB(t, 6, 24, map[string]map[string]any{"192.0.0.0/16": asnRecord}))
+
+	dbs, err := OpenAll([]string{cityPath, asnPath})
+	if err != nil {
+		t.Fatalf("OpenAll() error = %v", err)
+	}
+
+	got := dbs.Lookup(netip.MustParseAddr("192.0.2.10"))
+	want := &Location{
+		CountryCode:    "DE",
+		Country:        "Germany",
+		RegionCode:     "BE",
+		Region:         "Land Berlin",
+		City:           "Berlin",
+		HasCoordinates: true,
+		Latitude:       52.5196,
+		Longitude:      13.4069,
+		AccuracyRadius: 20,
+		TimeZone:       "Europe/Berlin",
+		ASN:            64500,
+		Organization:   "Example Networks",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lookup() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := dbs.Lookup(netip.MustParseAddr("192.0.99.1")); got == nil || got.ASN != 64500 || got.Country != "" {
+		t.Errorf("Lookup() outside the city network = %+v, want ASN only", got)
+	}
+	if got := dbs.Lookup(netip.MustParseAddr("203.0.113.1")); got != nil {
+		t.Errorf("Lookup() of an unknown address = %+v, want nil", got)
+	}
+
+	if _, err := OpenAll([]string{filepath.Join(dir, "missing.mmdb")}); err == nil {
+		t.Error("OpenAll() should fail for a missing file")
+	}
+}
+
+func TestDB_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asn.mmdb")
+	writeFile(t, path, buildMMDB(t, 6, 24, map[string]map[string]any{"192.0.2.0/24": asnRecord}))
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	dbs := Databases{db}
+	addr := netip.MustParseAddr("192.0.2.1")
+
+	if reloaded, err := db.Reload(); reloaded || err != nil {
+		t.Errorf("Reload() of an unchanged file = %v, %v; want false, nil", reloaded, err)
+	}
+
+	updated := map[string]any{"autonomous_system_number": uint32(64501), "autonomous_system_organization": "Renamed"}
+	writeFile(t, path, buildMMDB(t, 6, 24, map[string]map[string]any{"192.0.2.0/24": updated}))
+	touch(t, path, time.Now().Add(time.Minute))
+
+	if reloaded, err := db.Reload(); !reloaded || err != nil {
+		t.Fatalf("Reload() of a changed file = %v, %v; want true, nil", reloaded, err)
+	}
+	if got := dbs.Lookup(addr); got == nil || got.ASN != 64501 {
+		t.Errorf("Lookup() after reload = %+v, want ASN 64501", got)
+	}
+
+	writeFile(t, path, []byte("half-written download"))
+	touch(t, path, time.Now().Add(2*time.Minute))
+
+	if _, err := db.Reload(); err == nil {
+		t.Error("Reload() of a corrupt file should fail")
+	}
+	if got := dbs.Lookup(addr); got == nil || got.ASN != 64501 {
+		t.Errorf("Lookup() after a failed reload = %+v, want the previous contents", got)
+	}
+
+	// The corrupt file is reported once, until it changes again
+	if reloaded, err := db.Reload(); reloaded || err != nil {
+		t.Errorf("Reload() of the unchanged corrupt file = %v, %v; want false, nil", reloaded, err)
+	}
+	writeFile(t, path, buildMMDB(t, 6, 24, map[string]map[string]any{"192.0.2.0/24": asnRecord}))
+	touch(t, path, time.Now().Add(3*time.Minute))
+	if reloaded, err := db.Reload(); !reloaded || err != nil {
+		t.Errorf("Reload() of the fixed file = %v, %v; want true, nil", reloaded, err)
+	}
+}
+
+func writeFile(t *testing.T, path string, buf []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// touch sets the modification time explicitly, since a rewrite within the
+// file system's timestamp granularity would otherwise go unnoticed.
+func touch(t *testing.T, path string, mtime time.Time) {
+	t.Helper()
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
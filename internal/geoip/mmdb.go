@@ -0,0 +1,396 @@
+// Package geoip looks up the location and network owner of IP addresses in
+// local MaxMind DB (MMDB) files such as GeoLite2-City, GeoLite2-ASN or the
+// DB-IP lite databases. It never makes network calls.
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+)
+
+// metadataMarker precedes the metadata map at the end of every MMDB file.
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// maxMetadataSize bounds how far from the end of the file the metadata
+// marker is searched for.
+const maxMetadataSize = 128 * 1024
+
+// maxDepth bounds the nesting of maps and arrays, so a corrupt file cannot
+// exhaust the stack.
+const maxDepth = 32
+
+// dataSectionSeparator is the number of zero bytes between the search tree
+// and the data section.
+const dataSectionSeparator = 16
+
+// Metadata describes an MMDB file.
+type Metadata struct {
+	DatabaseType string // e.g. "GeoLite2-City", "GeoLite2-ASN", "DBIP-City-Lite"
+	BuildEpoch   uint64 // Build time, in seconds since the Unix epoch
+	IPVersion    int    // 4 or 6
+	NodeCount    uint
+	RecordSize   uint // Bits per search tree record: 24, 28 or 32
+}
+
+// Reader decodes an MMDB file held in memory. It is safe for concurrent use.
+type Reader struct {
+	Metadata Metadata
+
+	tree      []byte // Binary search tree
+	data      []byte // Data section
+	ipv4Start uint   // Node reached after the 96 zero bits of ::/96
+}
+
+// NewReader parses an MMDB file. buf is retained and must not be modified.
+func NewReader(buf []byte) (*Reader, error) {
+	start := len(buf) - maxMetadataSize
+	if start < 0 {
+		start = 0
+	}
+	i := bytes.LastIndex(buf[start:], metadataMarker)
+	if i < 0 {
+		return nil, errors.New("geoip: not an MMDB file: metadata marker not found")
+	}
+	metaStart := start + i + len(metadataMarker)
+
+	d := decoder{buf: buf[metaStart:]}
+	v, _, err := d.decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: invalid metadata: %w", err)
+	}
+	meta, ok := v.(map[string]any)
+	if !ok {
+		return nil, errors.New("geoip: invalid metadata: not a map")
+	}
+
+	r := &Reader{}
+	r.Metadata.DatabaseType, _ = meta["database_type"].(string)
+	r.Metadata.BuildEpoch = toUint(meta["build_epoch"])
+	r.Metadata.IPVersion = int(toUint(meta["ip_version"]))
+	r.Metadata.NodeCount = uint(toUint(meta["node_count"]))
+	r.Metadata.RecordSize = uint(toUint(meta["record_size"]))
+
+	if major := toUint(meta["binary_format_major_version"]); major != 2 {
+		return nil, fmt.Errorf("geoip: unsupported binary format version %d", major)
+	}
+	switch r.Metadata.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("geoip: unsupported record size %d", r.Metadata.RecordSize)
+	}
+	if r.Metadata.IPVersion != 4 && r.Metadata.IPVersion != 6 {
+		return nil, fmt.Errorf("geoip: unsupported IP version %d", r.Metadata.IPVersion)
+	}
+
+	// Each node holds two records. The count is checked against the file
+	// size before multiplying, so a corrupt one cannot overflow the product
+	nodeSize := r.Metadata.RecordSize / 4
+	markerStart := uint(start + i)
+	if r.Metadata.NodeCount > markerStart/nodeSize {
+		return nil, errors.New("geoip: search tree exceeds file size")
+	}
+	treeSize := r.Metadata.NodeCount * nodeSize
+	if treeSize+dataSectionSeparator > markerStart {
+		return nil, errors.New("geoip: search tree exceeds file size")
+	}
+	r.tree = buf[:treeSize]
+	r.data = buf[treeSize+dataSectionSeparator : markerStart]
+
+	if r.Metadata.IPVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < r.Metadata.NodeCount; i++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start = node
+	}
+
+	return r, nil
+}
+
+// Lookup returns the data record for addr and the network it belongs to.
+// It returns a nil record if the database has no entry for the address.
+func (r *Reader) Lookup(addr netip.Addr) (map[string]any, netip.Prefix, error) {
+	addr = addr.Unmap().WithZone("")
+	if !addr.IsValid() {
+		return nil, netip.Prefix{}, errors.New("geoip: invalid address")
+	}
+
+	var node uint
+	var bits []byte
+	switch {
+	case addr.Is4():
+		b := addr.As4()
+		bits = b[:]
+		node = r.ipv4Start
+	case r.Metadata.IPVersion == 6:
+		b := addr.As16()
+		bits = b[:]
+	default:
+		// IPv6 address in an IPv4-only database
+		return nil, netip.Prefix{}, nil
+	}
+
+	depth := 0
+	for ; depth < len(bits)*8 && node < r.Metadata.NodeCount; depth++ {
+		bit := (bits[depth/8] >> (7 - depth%8)) & 1
+		node = r.record(node, uint(bit))
+	}
+
+	prefix, _ := addr.Prefix(depth)
+
+	if node == r.Metadata.NodeCount {
+		return nil, prefix, nil
+	}
+	if node < r.Metadata.NodeCount {
+		return nil, prefix, errors.New("geoip: search tree is deeper than the address")
+	}
+
+	offset := node - r.Metadata.NodeCount - dataSectionSeparator
+	d := decoder{buf: r.data}
+	v, _, err := d.decode(offset, 0)
+	if err != nil {
+		return nil, prefix, fmt.Errorf("geoip: invalid data record: %w", err)
+	}
+	record, ok := v.(map[string]any)
+	if !ok {
+		return nil, prefix, errors.New("geoip: data record is not a map")
+	}
+	return record, prefix, nil
+}
+
+// record returns the left (bit 0) or right (bit 1) record of a tree node.
+func (r *Reader) record(node, bit uint) uint {
+	switch r.Metadata.RecordSize {
+	case 24:
+		b := r.tree[node*6+bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.tree[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(r.tree[node*8+bit*4:]))
+	}
+}
+
+// Data section field types.
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+var errTruncated = errors.New("unexpected end of data")
+
+// decoder decodes values from an MMDB data or metadata section. Pointers are
+// offsets from the start of buf.
+type decoder struct {
+	buf []byte
+}
+
+// decode decodes the value at offset and returns it with the offset of the
+// next value. Maps decode to map[string]any, arrays to []any, integers to
+// uint64 or int32, uint128 to *big.Int and floats to float64.
+func (d decoder) decode(offset uint, depth int) (any, uint, error) {
+	if depth > maxDepth {
+		return nil, 0, errors.New("data nested too deeply")
+	}
+	typ, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ == typePointer {
+		target, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		v, _, err := d.decode(target, depth+1)
+		return v, next, err
+	}
+
+	switch typ {
+	case typeMap:
+		m := make(map[string]any, min(size, 64))
+		for i := uint(0); i < size; i++ {
+			k, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+			v, next, err := d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = v
+			offset = next
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]any, 0, min(size, 64))
+		for i := uint(0); i < size; i++ {
+			v, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, v)
+			offset = next
+		}
+		return a, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(d.buf)) {
+		return nil, 0, errTruncated
+	}
+	b := d.buf[offset : offset+size]
+	next := offset + size
+
+	switch typ {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		return append([]byte(nil), b...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid double size %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid float size %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("invalid integer size %d", size)
+		}
+		var n uint64
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		return n, next, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("invalid int32 size %d", size)
+		}
+		var n uint32
+		for _, c := range b {
+			n = n<<8 | uint32(c)
+		}
+		return int32(n), next, nil
+	case typeUint128:
+		if size > 16 {
+			return nil, 0, fmt.Errorf("invalid uint128 size %d", size)
+		}
+		return new(big.Int).SetBytes(b), next, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported data type %d", typ)
+	}
+}
+
+// control decodes the control byte at offset and returns the field type, its
+// payload size and the offset of the payload. For pointers, size holds the
+// control byte's low five bits.
+func (d decoder) control(offset uint) (typ int, size uint, next uint, err error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, errTruncated
+	}
+	ctrl := d.buf[offset]
+	offset++
+
+	typ = int(ctrl >> 5)
+	if typ == typePointer {
+		return typ, uint(ctrl & 0x1f), offset, nil
+	}
+	if typ == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, errTruncated
+		}
+		typ = 7 + int(d.buf[offset])
+		offset++
+	}
+
+	size = uint(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28 // 29, 30 and 31 are followed by 1, 2 and 3 size bytes
+		if offset+n > uint(len(d.buf)) {
+			return 0, 0, 0, errTruncated
+		}
+		var extra uint
+		for _, c := range d.buf[offset : offset+n] {
+			extra = extra<<8 | uint(c)
+		}
+		offset += n
+		switch size {
+		case 29:
+			size = 29 + extra
+		case 30:
+			size = 285 + extra
+		default:
+			size = 65821 + extra
+		}
+	}
+	return typ, size, offset, nil
+}
+
+// pointer decodes a pointer payload, given the low five bits of its control
+// byte, and returns the target offset and the offset after the pointer.
+func (d decoder) pointer(ctrl, offset uint) (uint, uint, error) {
+	n := (ctrl>>3)&0x3 + 1
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, errTruncated
+	}
+
+	var p uint
+	if n < 4 {
+		p = ctrl & 0x7
+	}
+	for _, c := range d.buf[offset : offset+n] {
+		p = p<<8 | uint(c)
+	}
+
+	switch n {
+	case 2:
+		p += 2048
+	case 3:
+		p += 526336
+	}
+	return p, offset + n, nil
+}
+
+func toUint(v any) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int32:
+		if n >= 0 {
+			return uint64(n)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,202 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"math"
+	"net/netip"
+	"sort"
+	"testing"
+)
+
+// pointer is a data section pointer in a value passed to encodeValue.
+type pointer uint
+
+// buildMMDB writes a minimal MMDB file mapping each network to its record.
+// IPv4 networks in an IPv6 database are stored under ::/96.
+func buildMMDB(t *testing.T, ipVersion, recordSize int, networks map[string]map[string]any) []byte {
+	t.Helper()
+
+	const (
+		empty = -1
+		leaf  = -2
+	)
+	type child struct {
+		node int // Index of the child node, empty, or leaf
+		data int // Data section offset, for leaf children
+	}
+	nodes := [][2]child{{{node: empty}, {node: empty}}}
+
+	// Insert networks in a fixed order so the output is deterministic
+	prefixes := make([]string, 0, len(networks))
+	for network := range networks {
+		prefixes = append(prefixes, network)
+	}
+	sort.Strings(prefixes)
+
+	var data []byte
+	for _, network := range prefixes {
+		prefix := netip.MustParsePrefix(network)
+		offset := len(data)
+		data = append(data, encodeValue(t, networks[network])...)
+
+		var bits []byte
+		length := prefix.Bits()
+		if prefix.Addr().Is4() {
+			b := prefix.Addr().As4()
+			bits = b[:]
+			if ipVersion == 6 {
+				var b16 [16]byte
+				copy(b16[12:], b[:])
+				bits = b16[:]
+				length += 96
+			}
+		} else {
+			b := prefix.Addr().As16()
+			bits = b[:]
+		}
+
+		node := 0
+		for depth := 0; depth < length; depth++ {
+			bit := (bits[depth/8] >> (7 - depth%8)) & 1
+			if depth == length-1 {
+				nodes[node][bit] = child{node: leaf, data: offset}
+				break
+			}
+			if nodes[node][bit].node < 0 {
+				nodes = append(nodes, [2]child{{node: empty}, {node: empty}})
+				nodes[node][bit] = child{node: len(nodes) - 1}
+			}
+			node = nodes[node][bit].node
+		}
+	}
+
+	nodeCount := len(nodes)
+	var tree []byte
+	for _, n := range nodes {
+		var records [2]uint32
+		for i, c := range n {
+			switch c.node {
+			case empty:
+				records[i] = uint32(nodeCount)
+			case leaf:
+				records[i] = uint32(nodeCount + dataSectionSeparator + c.data)
+			default:
+				records[i] = uint32(c.node)
+			}
+		}
+		switch recordSize {
+		case 24:
+			tree = append(tree, byte(records[0]>>16), byte(records[0]>>8), byte(records[0]),
+				byte(records[1]>>16), byte(records[1]>>8), byte(records[1]))
+		case 28:
+			tree = append(tree, byte(records[0]>>16), byte(records[0]>>8), byte(records[0]),
+				byte(records[0]>>20&0xf0|records[1]>>24&0x0f),
+				byte(records[1]>>16), byte(records[1]>>8), byte(records[1]))
+		case 32:
+			tree = binary.BigEndian.AppendUint32(tree, records[0])
+			tree = binary.BigEndian.AppendUint32(tree, records[1])
+		}
+	}
+
+	buf := append(tree, make([]byte, dataSectionSeparator)...)
+	buf = append(buf, data...)
+	buf = append(buf, metadataMarker...)
+	buf = append(buf, encodeValue(t, map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1700000000),
+		"database_type":               "Test-City",
+		"description":                 map[string]any{"en": "Test database"},
+		"ip_version":                  uint16(ipVersion),
+		"languages":                   []any{"en"},
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+	})...)
+	return buf
+}
+
+// encodeValue encodes v in the MMDB data section format.
+func encodeValue(t *testing.T, v any) []byte {
+	t.Helper()
+
+	switch v := v.(type) {
+	case string:
+		return append(encodeControl(typeString, len(v)), v...)
+	case float64:
+		return binary.BigEndian.AppendUint64(encodeControl(typeDouble, 8), math.Float64bits(v))
+	case uint16:
+		return encodeUint(typeUint16, uint64(v))
+	case uint32:
+		return encodeUint(typeUint32, uint64(v))
+	case uint64:
+		return encodeUint(typeUint64, v)
+	case bool:
+		n := 0
+		if v {
+			n = 1
+		}
+		return encodeControl(typeBool, n)
+	case pointer:
+		switch {
+		case v < 2048:
+			return []byte{typePointer<<5 | byte(v>>8), byte(v)}
+		case v < 526336:
+			v -= 2048
+			return []byte{typePointer<<5 | 1<<3 | byte(v>>16), byte(v >> 8), byte(v)}
+		default:
+			t.Fatalf("pointer %d too large for the test writer", v)
+		}
+	case []any:
+		b := encodeControl(typeArray, len(v))
+		for _, item := range v {
+			b = append(b, encodeValue(t, item)...)
+		}
+		return b
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		b := encodeControl(typeMap, len(v))
+		for _, key := range keys {
+			b = append(b, encodeValue(t, key)...)
+			b = append(b, encodeValue(t, v[key])...)
+		}
+		return b
+	}
+	t.Fatalf("encodeValue: unsupported type %T", v)
+	return nil
+}
+
+func encodeUint(typ int, n uint64) []byte {
+	var payload []byte
+	for ; n > 0; n >>= 8 {
+		payload = append([]byte{byte(n)}, payload...)
+	}
+	return append(encodeControl(typ, len(payload)), payload...)
+}
+
+// encodeControl encodes a control byte with its extended type and size bytes.
+func encodeControl(typ, size int) []byte {
+	var sizeBits byte
+	var extra []byte
+	switch {
+	case size < 29:
+		sizeBits = byte(size)
+	case size < 285:
+		sizeBits, extra = 29, []byte{byte(size - 29)}
+	case size < 65821:
+		sizeBits, extra = 30, []byte{byte((size - 285) >> 8), byte(size - 285)}
+	default:
+		n := size - 65821
+		sizeBits, extra = 31, []byte{byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+
+	b := []byte{byte(typ)<<5 | sizeBits}
+	if typ > 7 {
+		b = []byte{sizeBits, byte(typ - 7)}
+	}
+	return append(b, extra...)
+}
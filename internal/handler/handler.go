@@ -1,52 +1,264 @@
 package handler
 
 import (
+	"context"
+	"crypto/x509"
 	"net/http"
+	"net/netip"
 	"sort"
 	"strings"
 	"time"
 
+	"connectionInfo/internal/geoip"
 	"connectionInfo/internal/parser"
+	"connectionInfo/internal/proxyproto"
+	"connectionInfo/internal/rdns"
+	"connectionInfo/internal/redact"
 	"connectionInfo/internal/render"
+	"connectionInfo/internal/wire"
 )
 
 // Handler handles HTTP requests for the connectionInfo service.
-type Handler struct{}
+type Handler struct {
+	trustedProxies parser.TrustedProxies
+	geoip          geoip.Databases
+	rdns           Resolver
+	botResolver    Resolver
+	uaRules        *parser.RulesFile
+	clientCAs      *x509.CertPool
+	redaction      redact.Policy
+}
+
+// Resolver looks up the reverse DNS names of an address. *rdns.Resolver
+// implements it.
+type Resolver interface {
+	Lookup(ctx context.Context, addr netip.Addr) rdns.Result
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithTrustedProxies sets the networks whose forwarding headers are trusted
+// when resolving the client IP. The default is parser.DefaultTrustedProxies.
+func WithTrustedProxies(trusted parser.TrustedProxies) Option {
+	return func(h *Handler) {
+		h.trustedProxies = trusted
+	}
+}
+
+// WithGeoIP sets the databases used to look up the location and network of
+// the client IP. Without them the report has no location.
+func WithGeoIP(dbs geoip.Databases) Option {
+	return func(h *Handler) {
+		h.geoip = dbs
+	}
+}
+
+// WithReverseDNS sets the resolver used to look up the PTR names of the
+// client IP. Without one the report has no reverse DNS.
+func WithReverseDNS(r Resolver) Option {
+	return func(h *Handler) {
+		h.rdns = r
+	}
+}
+
+// WithBotVerification sets the resolver used to verify clients claiming to
+// be a search engine bot whose operator publishes its crawlers' domains. A
+// claim is verified if the client IP has a forward-confirmed PTR name in one
+// of those domains. Without a resolver claims are not checked.
+func WithBotVerification(r Resolver) Option {
+	return func(h *Handler) {
+		h.botResolver = r
+	}
+}
+
+// WithUserAgentRules sets the rules file used to parse User-Agents, which
+// replaces the built-in parser.DefaultRules.
+func WithUserAgentRules(f *parser.RulesFile) Option {
+	return func(h *Handler) {
+		h.uaRules = f
+	}
+}
+
+// WithClientCAs sets the certificate authorities that client certificates
+// are verified against when the TLS handshake did not verify them. The
+// default is the system roots.
+func WithClientCAs(pool *x509.CertPool) Option {
+	return func(h *Handler) {
+		h.clientCAs = pool
+	}
+}
+
+// WithRedaction sets which header values are masked in reports, and
+// whether ?reveal=1 may show them. The default is redact.Default().
+func WithRedaction(p redact.Policy) Option {
+	return func(h *Handler) {
+		h.redaction = p
+	}
+}
 
 // New creates a new Handler.
-func New() *Handler {
-	return &Handler{}
+func New(opts ...Option) *Handler {
+	h := &Handler{
+		trustedProxies: parser.DefaultTrustedProxies,
+		redaction:      redact.Default(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // ServeHTTP handles all incoming HTTP requests.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Only serve the root path
-	if r.URL.Path != "/" {
+	// Only serve the root path and the format aliases
+	switch r.URL.Path {
+	case "/", "/json", "/ip", "/raw":
+	default:
 		http.Error(w, "404 Not Found", http.StatusNotFound)
 		return
 	}
 
+	// Ask browsers for the high-entropy Client Hints
+	hints := strings.Join(parser.HighEntropyHints, ", ")
+	w.Header().Set("Accept-CH", hints)
+
+	rules := parser.DefaultRules
+	if h.uaRules != nil {
+		rules = h.uaRules.Rules()
+	}
+	ua := rules.ParseUserAgent(r.Header.Get("User-Agent"))
+	ua.MergeClientHints(parser.ParseClientHints(r.Header))
+
+	// The chosen format depends on Accept and, for the default, User-Agent
+	w.Header().Set("Vary", "Accept, User-Agent")
+
+	// Choose the format first, so that errors and the plain IP do not wait
+	// on lookups they never show
+	renderer, status := selectRenderer(r, ua)
+	switch status {
+	case http.StatusBadRequest:
+		http.Error(w, "400 Bad Request: unknown format", status)
+		return
+	case http.StatusNotAcceptable:
+		http.Error(w, "406 Not Acceptable\n\nAvailable media types: "+strings.Join(render.MediaTypes(), ", "), status)
+		return
+	}
+
+	// Reports also depend on the Client Hints (RFC 8942, section 3.2). Only
+	// the page has browsers retry the first request with all of them, as
+	// Critical-CH makes them, rather than wait for the next.
+	switch renderer.Format {
+	case "ip", "raw":
+	default:
+		w.Header().Set("Vary", "Accept, User-Agent, "+strings.Join(parser.ClientHintHeaders, ", "))
+	}
+	if renderer.Format == "html" {
+		w.Header().Set("Critical-CH", hints)
+	}
+
+	chain := h.trustedProxies.Chain(r)
+
+	// Connections through a PROXY protocol upstream report the client
+	// address from the PROXY header as RemoteAddr
+	var proxyHeader *proxyproto.Header
+	if conn := proxyproto.FromContext(r.Context()); conn != nil {
+		proxyHeader = conn.Header()
+		if conn.Proxied() {
+			chain = parser.WithProxyPeer(chain, conn.PeerAddr().String())
+		}
+	}
+
 	// Build connection info
+	clientIP := parser.ClientOf(chain)
+	head := requestHead(r)
 	info := render.ConnectionInfo{
-		ClientIP:      parser.GetClientIP(r),
+		ClientIP:      clientIP,
+		ClientIPInfo:  parser.ClassifyIP(clientIP),
 		RawRemoteAddr: r.RemoteAddr,
 		Method:        r.Method,
+		Protocol:      r.Proto,
 		Path:          r.URL.Path,
 		QueryParams:   r.URL.Query(),
 		Headers:       extractHeaders(r),
-		UserAgent:     parser.ParseUserAgent(r.Header.Get("User-Agent")),
+		HeaderOrder:   headerOrder(head),
+		RawRequest:    rawRequest(r, head),
+		Cookies:       parser.ParseCookies(r.Header.Values("Cookie")),
+		Forwarded:     parser.ParseForwarded(r.Header.Values("Forwarded")),
+		Chain:         chain,
+		ProxyProtocol: proxyHeader,
+		TLS:           h.tlsInfo(r),
+		HTTP2:         http2Info(r),
+		UserAgent:     ua,
+		Negotiation:   negotiation(r),
 		Timestamp:     time.Now().UTC(),
 	}
+	if addr, err := netip.ParseAddr(clientIP); err == nil && renderer.Format != "ip" {
+		if len(h.geoip) > 0 {
+			info.Location = h.geoip.Lookup(addr)
+		}
+		if h.rdns != nil {
+			result := h.rdns.Lookup(r.Context(), addr)
+			info.ReverseDNS = &result
+		}
+		if bot := info.UserAgent.Bot; bot != nil && len(bot.Domains) > 0 && h.botResolver != nil {
+			h.verifyBot(r.Context(), bot, addr, info.ReverseDNS)
+		}
+	}
+
+	// Values are masked unless the policy allows revealing them and the
+	// request asks to, in which case the response must not be cached
+	if h.redaction.AllowReveal && r.URL.Query().Get("reveal") == "1" {
+		w.Header().Set("Cache-Control", "no-store")
+	} else {
+		info.Redacted = h.redact(&info)
+	}
 
 	// Set content type and render
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := render.Render(w, info); err != nil {
+	w.Header().Set("Content-Type", renderer.ContentType)
+	if err := renderer.Render(w, info); err != nil {
 		// If rendering fails, return a generic error (no details exposed)
 		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// selectRenderer picks the output renderer for a request. Explicit choices
+// (the /ip, /raw and /json paths, then ?format=) win; otherwise the Accept header
+// is negotiated, with ties going to plain text for command-line clients and
+// to HTML for everyone else. The status is 200 on success, 400 for an
+// unknown ?format= value and 406 when no renderer satisfies Accept.
+func selectRenderer(r *http.Request, ua parser.UserAgentInfo) (render.Renderer, int) {
+	format := r.URL.Query().Get("format")
+	switch r.URL.Path {
+	case "/ip":
+		format = "ip"
+	case "/raw":
+		format = "raw"
+	case "/json":
+		format = "json"
+	}
+
+	if format != "" {
+		renderer, ok := render.Lookup(format)
+		if !ok {
+			return render.Renderer{}, http.StatusBadRequest
+		}
+		return renderer, http.StatusOK
+	}
+
+	preferred := "html"
+	if ua.CLI {
+		preferred = "text"
+	}
+
+	renderer, ok := render.Negotiate(parser.ParseAccept(r.Header.Get("Accept")), preferred)
+	if !ok {
+		return render.Renderer{}, http.StatusNotAcceptable
+	}
+	return renderer, http.StatusOK
+}
+
 // extractHeaders extracts all headers from the request and returns them sorted alphabetically.
 func extractHeaders(r *http.Request) []render.HeaderPair {
 	var headers []render.HeaderPair
@@ -67,3 +279,62 @@ func extractHeaders(r *http.Request) []render.HeaderPair {
 
 	return headers
 }
+
+// negotiation parses the content negotiation headers of r. Repeated headers
+// are one list, as if their values had been sent comma-separated.
+func negotiation(r *http.Request) render.NegotiationInfo {
+	list := func(name string) string {
+		return strings.Join(r.Header.Values(name), ",")
+	}
+	return render.NegotiationInfo{
+		Languages:  parser.ParseAcceptLanguage(list("Accept-Language")),
+		MediaTypes: parser.PreferredMediaRanges(parser.ParseAccept(list("Accept"))),
+		Encodings:  parser.ParseAcceptEncoding(list("Accept-Encoding")),
+	}
+}
+
+// requestHead returns the head of an HTTP/1.x request as the client sent it,
+// or nil for HTTP/2 and for connections that were not recorded.
+func requestHead(r *http.Request) *wire.Head {
+	conn := wire.FromContext(r.Context())
+	if r.ProtoMajor != 1 || conn == nil {
+		return nil
+	}
+	return conn.Head(r.Method, r.RequestURI)
+}
+
+// headerOrder lists the headers of head in the order sent, with their
+// original casing and repeats, or returns nil without a head.
+func headerOrder(head *wire.Head) *render.HeaderOrderInfo {
+	if head == nil {
+		return nil
+	}
+	info := &render.HeaderOrderInfo{
+		Headers:     make([]render.HeaderPair, 0, len(head.Fields)),
+		Fingerprint: head.OrderHash(),
+	}
+	for _, f := range head.Fields {
+		info.Headers = append(info.Headers, render.HeaderPair{Name: f.Name, Value: f.Value})
+	}
+	return info
+}
+
+// verifyBot checks a bot's claim against the PTR names of its address,
+// reusing the report's reverse DNS result if there is one.
+func (h *Handler) verifyBot(ctx context.Context, bot *parser.Bot, addr netip.Addr, result *rdns.Result) {
+	if result == nil {
+		lookup := h.botResolver.Lookup(ctx, addr)
+		result = &lookup
+	}
+	if result.Error != "" {
+		bot.VerifyError = result.Error
+		return
+	}
+	var confirmed []string
+	for _, n := range result.Names {
+		if n.Confirmed {
+			confirmed = append(confirmed, n.Name)
+		}
+	}
+	bot.Verify(confirmed)
+}
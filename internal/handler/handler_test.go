@@ -1,10 +1,27 @@
 package handler
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"connectionInfo/internal/geoip"
+	"connectionInfo/internal/parser"
+	"connectionInfo/internal/proxyproto"
+	"connectionInfo/internal/rdns"
+	"connectionInfo/internal/wire"
 )
 
 func TestHandler_RootPath(t *testing.T) {
@@ -81,7 +98,7 @@ func TestHandler_XForwardedFor(t *testing.T) {
 	h := New()
 
 	req := httptest.NewRequest("GET", "/", nil)
-	req.RemoteAddr = "10.0.0.1:12345"
+	req.RemoteAddr = "127.0.0.1:12345"
 	req.Header.Set("X-Forwarded-For", "203.0.113.50, 70.41.3.18")
 
 	rr := httptest.NewRecorder()
@@ -217,3 +234,825 @@ func TestHandler_HeadersSorted(t *testing.T) {
 		t.Errorf("headers should be sorted alphabetically")
 	}
 }
+
+// dialRecording starts a server for h whose connections are recorded, like
+// the real server's, and returns a connection to it.
+func dialRecording(t *testing.T, h http.Handler) net.Conn {
+	t.Helper()
+
+	srv := httptest.NewUnstartedServer(h)
+	srv.Listener = &wire.Listener{Listener: srv.Listener}
+	srv.Config.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		return wire.NewContext(ctx, c.(*wire.Conn))
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestHandler_HeaderOrder(t *testing.T) {
+	conn := dialRecording(t, New())
+
+	// Two requests on one connection, the first with a body
+	io.WriteString(conn, "POST /json HTTP/1.1\r\nHost: example.com\r\nContent-Length: 4\r\n\r\nbody"+
+		"GET /json HTTP/1.1\r\nhost: example.com\r\nzebra: 1\r\nX-Dup: a\r\nACCEPT: */*\r\nX-Dup: b\r\n\r\n")
+	br := bufio.NewReader(conn)
+	for i := range 2 {
+		resp, err := http.ReadResponse(br, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got struct {
+			HeaderOrder *struct {
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				Fingerprint string `json:"fingerprint"`
+			} `json:"header_order"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("response is not valid JSON: %v", err)
+		}
+		if got.HeaderOrder == nil {
+			t.Fatalf("request %d: header_order = null for a recorded connection", i)
+		}
+		if i == 0 {
+			continue
+		}
+
+		var sent []string
+		for _, h := range got.HeaderOrder.Headers {
+			sent = append(sent, h.Name+": "+h.Value)
+		}
+		if want := []string{"host: example.com", "zebra: 1", "X-Dup: a", "ACCEPT: */*", "X-Dup: b"}; !slices.Equal(sent, want) {
+			t.Errorf("header_order.headers = %q, want %q", sent, want)
+		}
+		if len(got.HeaderOrder.Fingerprint) != 12 {
+			t.Errorf("header_order.fingerprint = %q", got.HeaderOrder.Fingerprint)
+		}
+	}
+}
+
+func TestHandler_JSON(t *testing.T) {
+	h := New()
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+	}{
+		{"json path", "/json", ""},
+		{"format query", "/?format=json", ""},
+		{"accept header", "/", "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req.RemoteAddr = "192.168.1.100:12345"
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("handler returned wrong content type: got %v want %v", contentType, "application/json")
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+			if got["client_ip"] != "192.168.1.100" {
+				t.Errorf("client_ip = %v, want %v", got["client_ip"], "192.168.1.100")
+			}
+		})
+	}
+}
+
+func TestHandler_Negotiation(t *testing.T) {
+	req := httptest.NewRequest("GET", "/json", nil)
+	req.Header.Add("Accept-Language", "fr-CA;q=0.8")
+	req.Header.Add("Accept-Language", "en-US")
+	req.Header.Set("Accept-Encoding", "gzip, deflate;q=0.5, br, zstd")
+	rr := httptest.NewRecorder()
+	New().ServeHTTP(rr, req)
+
+	var got struct {
+		Negotiation struct {
+			Languages []struct {
+				Tag  string `json:"tag"`
+				Name string `json:"name"`
+			} `json:"languages"`
+			MediaTypes []struct {
+				MediaType string `json:"media_type"`
+			} `json:"media_types"`
+			Encodings []struct {
+				Coding string `json:"coding"`
+			} `json:"encodings"`
+		} `json:"negotiation"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+
+	n := got.Negotiation
+	if len(n.Languages) != 2 || n.Languages[0].Tag != "en-US" || n.Languages[1].Name != "French (Canada)" {
+		t.Errorf("languages = %+v, want both headers, en-US first", n.Languages)
+	}
+	if len(n.MediaTypes) != 0 {
+		t.Errorf("media_types = %+v, want none without Accept", n.MediaTypes)
+	}
+	var codings []string
+	for _, c := range n.Encodings {
+		codings = append(codings, c.Coding)
+	}
+	if want := []string{"gzip", "br", "zstd", "deflate"}; !slices.Equal(codings, want) {
+		t.Errorf("encodings = %v, want %v", codings, want)
+	}
+}
+
+func TestHandler_BrowserAcceptGetsHTML(t *testing.T) {
+	h := New()
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.100:12345"
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/json;q=0.9,*/*;q=0.8")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != "text/html; charset=utf-8" {
+		t.Errorf("handler returned wrong content type: got %v want %v", contentType, "text/html; charset=utf-8")
+	}
+}
+
+func TestHandler_CommandLineClientsGetText(t *testing.T) {
+	h := New()
+
+	userAgents := []string{
+		"curl/8.4.0",
+		"Wget/1.21.4",
+		"HTTPie/3.2.2",
+		"Mozilla/5.0 (Windows NT 10.0; Microsoft Windows 10.0.19045; en-US) PowerShell/7.4.0",
+	}
+
+	for _, ua := range userAgents {
+		t.Run(ua, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = "192.168.1.100:12345"
+			req.Header.Set("User-Agent", ua)
+			req.Header.Set("Accept", "*/*")
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if contentType := rr.Header().Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+				t.Errorf("handler returned wrong content type: got %v want %v", contentType, "text/plain; charset=utf-8")
+			}
+			if body := rr.Body.String(); !strings.HasPrefix(body, "IP:") || !strings.Contains(body, "192.168.1.100") {
+				t.Errorf("unexpected plain-text body: %q", body)
+			}
+		})
+	}
+}
+
+func TestHandler_CommandLineClientCanRequestHTML(t *testing.T) {
+	h := New()
+
+	for _, target := range []string{"/?format=html", "/"} {
+		req := httptest.NewRequest("GET", target, nil)
+		req.RemoteAddr = "192.168.1.100:12345"
+		req.Header.Set("User-Agent", "curl/8.4.0")
+		if target == "/" {
+			req.Header.Set("Accept", "text/html")
+		}
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if contentType := rr.Header().Get("Content-Type"); contentType != "text/html; charset=utf-8" {
+			t.Errorf("%s: handler returned wrong content type: got %v want %v", target, contentType, "text/html; charset=utf-8")
+		}
+	}
+}
+
+func TestHandler_PlainTextAccept(t *testing.T) {
+	h := New()
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.100:12345"
+	req.Header.Set("Accept", "text/plain")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+		t.Errorf("handler returned wrong content type: got %v want %v", contentType, "text/plain; charset=utf-8")
+	}
+}
+
+func TestHandler_IPOnly(t *testing.T) {
+	h := New()
+
+	req := httptest.NewRequest("GET", "/ip", nil)
+	req.RemoteAddr = "192.168.1.100:12345"
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+		t.Errorf("handler returned wrong content type: got %v want %v", contentType, "text/plain; charset=utf-8")
+	}
+	if body := rr.Body.String(); body != "192.168.1.100\n" {
+		t.Errorf("body = %q, want %q", body, "192.168.1.100\n")
+	}
+}
+
+func TestHandler_ContentNegotiation(t *testing.T) {
+	h := New()
+
+	tests := []struct {
+		target      string
+		accept      string
+		contentType string
+	}{
+		{"/", "application/yaml", "application/yaml"},
+		{"/", "text/xml;q=0.5, text/csv;q=0.4", "application/xml; charset=utf-8"},
+		{"/", "text/csv", "text/csv; charset=utf-8"},
+		{"/?format=yaml", "text/html", "application/yaml"},
+		{"/?format=csv", "", "text/csv; charset=utf-8"},
+		{"/?format=ip", "", "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req.RemoteAddr = "192.168.1.100:12345"
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			if contentType := rr.Header().Get("Content-Type"); contentType != tt.contentType {
+				t.Errorf("handler returned wrong content type: got %v want %v", contentType, tt.contentType)
+			}
+			if vary := rr.Header().Get("Vary"); !strings.Contains(vary, "Accept") {
+				t.Errorf("Vary = %q, want it to include Accept", vary)
+			}
+		})
+	}
+}
+
+func TestHandler_NotAcceptable(t *testing.T) {
+	h := New()
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.100:12345"
+	req.Header.Set("Accept", "image/png")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNotAcceptable {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotAcceptable)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "application/json") {
+		t.Errorf("406 body should list available media types, got %q", body)
+	}
+}
+
+func TestHandler_UnknownFormat(t *testing.T) {
+	h := New()
+
+	req := httptest.NewRequest("GET", "/?format=pdf", nil)
+	req.RemoteAddr = "192.168.1.100:12345"
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestHandler_XForwardedForFromUntrustedPeer(t *testing.T) {
+	h := New()
+
+	req := httptest.NewRequest("GET", "/ip", nil)
+	req.RemoteAddr = "198.51.100.9:12345"
+	req.Header.Set("X-Forwarded-For", "203.0.113.50")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if body := rr.Body.String(); body != "198.51.100.9\n" {
+		t.Errorf("spoofed X-Forwarded-For should be ignored, got %q", body)
+	}
+}
+
+func TestHandler_WithTrustedProxies(t *testing.T) {
+	trusted, err := parser.ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+	h := New(WithTrustedProxies(trusted))
+
+	req := httptest.NewRequest("GET", "/ip", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	req.Header.Set("X-Forwarded-For", "203.0.113.50, 10.0.0.2")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if body := rr.Body.String(); body != "203.0.113.50\n" {
+		t.Errorf("body = %q, want %q", body, "203.0.113.50\n")
+	}
+}
+
+func TestHandler_WithUserAgentRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regexes.yaml")
+	rules := "user_agent_parsers:\n  - regex: '(Chrome)/(\\d+)'\n    family_replacement: 'Chromium-based'\n"
+	if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.OpenRules(path)
+	if err != nil {
+		t.Fatalf("OpenRules() error = %v", err)
+	}
+	h := New(WithUserAgentRules(f))
+
+	req := httptest.NewRequest("GET", "/json", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	var got struct {
+		UserAgent struct {
+			BrowserName    string `json:"browser_name"`
+			BrowserVersion string `json:"browser_version"`
+			OSName         string `json:"os_name"`
+		} `json:"user_agent"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	// The file has no OS rules, so the OS is unknown
+	if ua := got.UserAgent; ua.BrowserName != "Chromium-based" || ua.BrowserVersion != "120" || ua.OSName != "Unknown" {
+		t.Errorf("user_agent = %+v, want Chromium-based 120 on Unknown", ua)
+	}
+}
+
+func TestHandler_ProxyChainJSON(t *testing.T) {
+	h := New()
+
+	req := httptest.NewRequest("GET", "/json", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	req.Header.Set("X-Forwarded-For", "203.0.113.50")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	var got struct {
+		ClientIP   string `json:"client_ip"`
+		ProxyChain []struct {
+			Address string `json:"address"`
+			Source  string `json:"source"`
+			Trusted bool   `json:"trusted"`
+			Client  bool   `json:"client"`
+		} `json:"proxy_chain"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+
+	if got.ClientIP != "203.0.113.50" {
+		t.Errorf("client_ip = %q, want %q", got.ClientIP, "203.0.113.50")
+	}
+	if len(got.ProxyChain) != 2 {
+		t.Fatalf("proxy_chain has %d hops, want 2: %+v", len(got.ProxyChain), got.ProxyChain)
+	}
+	if hop := got.ProxyChain[0]; hop.Address != "203.0.113.50" || hop.Source != "X-Forwarded-For" || !hop.Client {
+		t.Errorf("unexpected first hop: %+v", hop)
+	}
+	if hop := got.ProxyChain[1]; hop.Source != "socket" || !hop.Trusted || hop.Client {
+		t.Errorf("unexpected socket hop: %+v", hop)
+	}
+}
+
+func TestHandler_ProxyProtocol(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	ln := &proxyproto.Listener{Listener: inner, Allowed: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}}
+	defer ln.Close()
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+	if _, err := io.WriteString(client, "PROXY TCP4 203.0.113.50 198.51.100.1 56324 443\r\n"); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	accepted, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	defer accepted.Close()
+	conn := accepted.(*proxyproto.Conn)
+
+	// net/http reports the address from the PROXY header as RemoteAddr
+	req := httptest.NewRequest("GET", "/json", nil)
+	req.RemoteAddr = conn.RemoteAddr().String()
+	req = req.WithContext(proxyproto.NewContext(req.Context(), conn))
+
+	rr := httptest.NewRecorder()
+	New().ServeHTTP(rr, req)
+
+	var got struct {
+		ClientIP   string `json:"client_ip"`
+		ProxyChain []struct {
+			IP      string `json:"ip"`
+			Source  string `json:"source"`
+			Trusted bool   `json:"trusted"`
+			Client  bool   `json:"client"`
+		} `json:"proxy_chain"`
+		ProxyProtocol *struct {
+			Version int    `json:"version"`
+			Command string `json:"command"`
+			Source  string `json:"source"`
+		} `json:"proxy_protocol"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+
+	if got.ClientIP != "203.0.113.50" {
+		t.Errorf("client_ip = %q, want 203.0.113.50", got.ClientIP)
+	}
+	if len(got.ProxyChain) != 2 {
+		t.Fatalf("proxy_chain has %d hops, want 2: %+v", len(got.ProxyChain), got.ProxyChain)
+	}
+	if hop := got.ProxyChain[0]; hop.IP != "203.0.113.50" || hop.Source != parser.SourceProxyProtocol || !hop.Client {
+		t.Errorf("unexpected client hop: %+v", hop)
+	}
+	if hop := got.ProxyChain[1]; hop.IP != "127.0.0.1" || hop.Source != parser.SourceSocket || !hop.Trusted || hop.Client {
+		t.Errorf("unexpected upstream hop: %+v", hop)
+	}
+	if pp := got.ProxyProtocol; pp == nil || pp.Version != 1 || pp.Command != "PROXY" || pp.Source != "203.0.113.50:56324" {
+		t.Errorf("proxy_protocol = %+v, want the v1 header", pp)
+	}
+}
+
+func TestHandler_WithGeoIP(t *testing.T) {
+	// testdata/city.mmdb maps 192.0.2.0/24 to Berlin in AS64500, as written
+	// by the geoip package's test writer
+	dbs, err := geoip.OpenAll([]string{"testdata/city.mmdb"})
+	if err != nil {
+		t.Fatalf("OpenAll() error = %v", err)
+	}
+	h := New(WithGeoIP(dbs))
+
+	var got struct {
+		Location *struct {
+			CountryCode  string `json:"country_code"`
+			City         string `json:"city"`
+			TimeZone     string `json:"time_zone"`
+			ASN          uint   `json:"asn"`
+			Organization string `json:"organization"`
+		} `json:"location"`
+	}
+	for _, tt := range []struct {
+		remoteAddr string
+		wantCity   string
+	}{
+		{"192.0.2.77:12345", "Berlin"},
+		{"198.51.100.1:12345", ""},
+	} {
+		req := httptest.NewRequest("GET", "/json", nil)
+		req.RemoteAddr = tt.remoteAddr
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		got.Location = nil
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatalf("response is not valid JSON: %v", err)
+		}
+		if tt.wantCity == "" {
+			if got.Location != nil {
+				t.Errorf("%s: location = %+v, want null", tt.remoteAddr, got.Location)
+			}
+			continue
+		}
+		if loc := got.Location; loc == nil || loc.City != tt.wantCity || loc.CountryCode != "DE" || loc.TimeZone != "Europe/Berlin" || loc.ASN != 64500 || loc.Organization != "Example Networks" {
+			t.Errorf("%s: location = %+v, want Berlin, DE in AS64500", tt.remoteAddr, loc)
+		}
+	}
+}
+
+func TestHandler_ClientIPInfo(t *testing.T) {
+	h := New()
+
+	req := httptest.NewRequest("GET", "/json", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	req.Header.Set("X-Forwarded-For", "100.72.14.3")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	var got struct {
+		ClientIPInfo struct {
+			Version int  `json:"version"`
+			CGNAT   bool `json:"cgnat"`
+			Public  bool `json:"public"`
+		} `json:"client_ip_info"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+
+	if info := got.ClientIPInfo; info.Version != 4 || !info.CGNAT || info.Public {
+		t.Errorf("client_ip_info = %+v, want a CGNAT IPv4 address", info)
+	}
+}
+
+func TestHandler_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(New())
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/json")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	var got struct {
+		TLS *struct {
+			Version     string `json:"version"`
+			CipherSuite string `json:"cipher_suite"`
+			ALPN        string `json:"alpn"`
+			Resumed     bool   `json:"resumed"`
+			Curve       string `json:"curve"`
+		} `json:"tls"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+
+	if got.TLS == nil {
+		t.Fatal("tls = null for a request over TLS")
+	}
+	if got.TLS.Version != "TLS 1.3" || got.TLS.CipherSuite == "" || got.TLS.Curve == "" || got.TLS.Resumed {
+		t.Errorf("unexpected tls: %+v", *got.TLS)
+	}
+}
+
+func TestHandler_ClientHints(t *testing.T) {
+	req := httptest.NewRequest("GET", "/json", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Sec-CH-UA", `"Not_A Brand";v="8", "Chromium";v="120", "Microsoft Edge";v="120"`)
+	req.Header.Set("Sec-CH-UA-Platform", `"Windows"`)
+	req.Header.Set("Sec-CH-UA-Platform-Version", `"10.0.0"`)
+	rr := httptest.NewRecorder()
+	New().ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("Accept-CH"); !strings.Contains(got, "Sec-CH-UA-Full-Version-List") || !strings.Contains(got, "Sec-CH-UA-Platform-Version") {
+		t.Errorf("Accept-CH = %q, want it to ask for the high-entropy hints", got)
+	}
+	vary := strings.Split(rr.Header().Get("Vary"), ", ")
+	for _, name := range append([]string{"Accept", "User-Agent"}, parser.ClientHintHeaders...) {
+		if !slices.Contains(vary, name) {
+			t.Errorf("Vary = %q, want it to include %s", rr.Header().Get("Vary"), name)
+		}
+	}
+
+	// Only the page has browsers retry with the hints, and only reports
+	// vary on them
+	tests := []struct {
+		target       string
+		wantCritical bool
+		wantVaryHint bool
+	}{
+		{"/", true, true},
+		{"/json", false, true},
+		{"/ip", false, false},
+		{"/raw", false, false},
+		{"/?format=pdf", false, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.target, nil)
+		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+		rr := httptest.NewRecorder()
+		New().ServeHTTP(rr, req)
+
+		if got := rr.Header().Get("Critical-CH") != ""; got != tt.wantCritical {
+			t.Errorf("%s: Critical-CH = %q, want it sent: %v", tt.target, rr.Header().Get("Critical-CH"), tt.wantCritical)
+		}
+		if got := strings.Contains(rr.Header().Get("Vary"), "Sec-CH-UA"); got != tt.wantVaryHint {
+			t.Errorf("%s: Vary = %q, want the hints in it: %v", tt.target, rr.Header().Get("Vary"), tt.wantVaryHint)
+		}
+	}
+
+	var got struct {
+		UserAgent struct {
+			BrowserName string            `json:"browser_name"`
+			OSName      string            `json:"os_name"`
+			Sources     map[string]string `json:"sources"`
+		} `json:"user_agent"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if ua := got.UserAgent; ua.BrowserName != "Edge" || ua.OSName != "Windows 10" {
+		t.Errorf("browser, OS = %q, %q; want Edge on Windows 10", ua.BrowserName, ua.OSName)
+	}
+	if src := got.UserAgent.Sources; src["browser_name"] != "Sec-CH-UA" || src["os_name"] != "Sec-CH-UA-Platform-Version" {
+		t.Errorf("sources = %v", src)
+	}
+}
+
+// stubResolver answers reverse DNS lookups from a fixed table. Other
+// addresses have no names.
+type stubResolver map[netip.Addr]rdns.Result
+
+func (s stubResolver) Lookup(_ context.Context, addr netip.Addr) rdns.Result {
+	if result, ok := s[addr]; ok {
+		return result
+	}
+	return rdns.Result{Names: []rdns.Name{}}
+}
+
+func TestHandler_WithReverseDNS(t *testing.T) {
+	h := New(WithReverseDNS(stubResolver{
+		netip.MustParseAddr("192.0.2.10"): {Names: []rdns.Name{{Name: "host.example", Confirmed: true}, {Name: "alias.example"}}, ForwardConfirmed: true},
+		netip.MustParseAddr("192.0.2.20"): {Names: []rdns.Name{}, Error: "timeout"},
+	}))
+
+	tests := []struct {
+		remoteAddr    string
+		wantNames     []string
+		wantConfirmed bool
+		wantError     string
+	}{
+		{"192.0.2.10:12345", []string{"host.example", "alias.example"}, true, ""},
+		{"192.0.2.20:12345", []string{}, false, "timeout"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/json", nil)
+		req.RemoteAddr = tt.remoteAddr
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		var got struct {
+			ReverseDNS *struct {
+				Names []struct {
+					Name string `json:"name"`
+				} `json:"names"`
+				ForwardConfirmed bool   `json:"forward_confirmed"`
+				Error            string `json:"error"`
+			} `json:"reverse_dns"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatalf("response is not valid JSON: %v", err)
+		}
+		result := got.ReverseDNS
+		if result == nil {
+			t.Errorf("%s: reverse_dns = null", tt.remoteAddr)
+			continue
+		}
+		names := []string{}
+		for _, n := range result.Names {
+			names = append(names, n.Name)
+		}
+		if !slices.Equal(names, tt.wantNames) || result.ForwardConfirmed != tt.wantConfirmed || result.Error != tt.wantError {
+			t.Errorf("%s: reverse_dns = %+v, want names %v, confirmed %v, error %q", tt.remoteAddr, result, tt.wantNames, tt.wantConfirmed, tt.wantError)
+		}
+	}
+
+	// Without a resolver the report has no reverse DNS
+	rr := httptest.NewRecorder()
+	New().ServeHTTP(rr, httptest.NewRequest("GET", "/json", nil))
+	var got struct {
+		ReverseDNS any `json:"reverse_dns"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || got.ReverseDNS != nil {
+		t.Errorf("reverse_dns = %v (error %v), want null without a resolver", got.ReverseDNS, err)
+	}
+}
+
+// blockingResolver counts its lookups, each of which waits until the
+// request is done.
+type blockingResolver struct {
+	calls atomic.Int32
+}
+
+func (b *blockingResolver) Lookup(ctx context.Context, _ netip.Addr) rdns.Result {
+	b.calls.Add(1)
+	<-ctx.Done()
+	return rdns.Result{Names: []rdns.Name{}, Error: "timeout"}
+}
+
+func TestHandler_SkipsLookupsNotShown(t *testing.T) {
+	resolver := &blockingResolver{}
+	h := New(WithReverseDNS(resolver), WithBotVerification(resolver))
+
+	tests := []struct {
+		target string
+		accept string
+		status int
+	}{
+		{"/ip", "", http.StatusOK},
+		{"/?format=ip", "", http.StatusOK},
+		{"/?format=pdf", "", http.StatusBadRequest},
+		{"/", "image/png", http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		req := httptest.NewRequestWithContext(ctx, "GET", tt.target, nil)
+		req.RemoteAddr = "192.0.2.10:12345"
+		req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)")
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		cancel()
+
+		if rr.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.target, rr.Code, tt.status)
+		}
+		if n := resolver.calls.Load(); n != 0 {
+			t.Errorf("%s %s: resolver called %d times, want none", tt.target, tt.accept, n)
+		}
+	}
+}
+
+func TestHandler_VerifyBot(t *testing.T) {
+	h := New()
+	addr := netip.MustParseAddr("66.249.66.1")
+	tests := []struct {
+		name      string
+		result    rdns.Result
+		wantCheck bool
+		want      bool
+		wantError string
+	}{
+		{
+			name:      "forward-confirmed",
+			result:    rdns.Result{Names: []rdns.Name{{Name: "crawl-66-249-66-1.googlebot.com", Confirmed: true}}, ForwardConfirmed: true},
+			wantCheck: true,
+			want:      true,
+		},
+		{
+			name:      "not forward-confirmed",
+			result:    rdns.Result{Names: []rdns.Name{{Name: "crawl-66-249-66-1.googlebot.com"}}},
+			wantCheck: true,
+		},
+		{
+			name:      "lookup failed",
+			result:    rdns.Result{Error: "timeout"},
+			wantError: "timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := parser.ParseUserAgent("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)").Bot
+			h.verifyBot(context.Background(), bot, addr, &tt.result)
+			if checked := bot.Verified != nil; checked != tt.wantCheck || checked && *bot.Verified != tt.want {
+				t.Errorf("Verified = %v, want checked %v, verified %v", bot.Verified, tt.wantCheck, tt.want)
+			}
+			if bot.VerifyError != tt.wantError {
+				t.Errorf("VerifyError = %q, want %q", bot.VerifyError, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"connectionInfo/internal/render"
+	"connectionInfo/internal/wire"
+)
+
+// http2Info describes how the client opened the HTTP/2 connection the
+// request arrived on, or returns nil for other requests and connections
+// that were not recorded.
+func http2Info(r *http.Request) *render.HTTP2Info {
+	conn := wire.FromContext(r.Context())
+	if r.ProtoMajor != 2 || conn == nil {
+		return nil
+	}
+	rec := conn.HTTP2()
+	if rec == nil {
+		return nil
+	}
+
+	info := &render.HTTP2Info{
+		Akamai:        rec.Akamai(),
+		Settings:      make([]render.HTTP2Setting, 0, len(rec.Settings)),
+		WindowUpdate:  rec.WindowUpdate,
+		Priorities:    make([]string, 0, len(rec.Priorities)),
+		PseudoHeaders: rec.PseudoHeaders,
+		Upgraded:      rec.Upgraded,
+	}
+	for _, s := range rec.Settings {
+		info.Settings = append(info.Settings, render.HTTP2Setting{ID: s.ID, Name: s.Name(), Value: s.Value})
+	}
+	for _, p := range rec.Priorities {
+		info.Priorities = append(info.Priorities, p.String())
+	}
+	if rec.HeadersPriority != nil {
+		info.HeadersPriority = rec.HeadersPriority.String()
+	}
+	return info
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectionInfo/internal/wire"
+)
+
+func TestHandler_HTTP2(t *testing.T) {
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	srv := httptest.NewUnstartedServer(New())
+	srv.Listener = &wire.Listener{Listener: srv.Listener}
+	srv.Config.Protocols = &protocols
+	srv.Config.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		return wire.NewContext(ctx, c.(*wire.Conn))
+	}
+	srv.Start()
+	defer srv.Close()
+
+	type report struct {
+		Protocol string `json:"protocol"`
+		HTTP2    *struct {
+			Akamai   string `json:"akamai"`
+			Settings []struct {
+				Name string `json:"name"`
+			} `json:"settings"`
+			PseudoHeaderOrder []string `json:"pseudo_header_order"`
+		} `json:"http2"`
+	}
+	get := func(client *http.Client) report {
+		t.Helper()
+		resp, err := client.Get(srv.URL + "/json")
+		if err != nil {
+			t.Fatalf("GET error = %v", err)
+		}
+		defer resp.Body.Close()
+		var got report
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("response is not valid JSON: %v", err)
+		}
+		return got
+	}
+
+	var h2c http.Protocols
+	h2c.SetUnencryptedHTTP2(true)
+	got := get(&http.Client{Transport: &http.Transport{Protocols: &h2c}})
+	if got.Protocol != "HTTP/2.0" || got.HTTP2 == nil {
+		t.Fatalf("protocol, http2 = %q, %v; want an HTTP/2 fingerprint", got.Protocol, got.HTTP2)
+	}
+	if strings.Count(got.HTTP2.Akamai, "|") != 3 || len(got.HTTP2.PseudoHeaderOrder) != 4 {
+		t.Errorf("akamai, pseudo_header_order = %q, %v", got.HTTP2.Akamai, got.HTTP2.PseudoHeaderOrder)
+	}
+	if len(got.HTTP2.Settings) == 0 || got.HTTP2.Settings[0].Name == "" {
+		t.Errorf("settings = %+v, want named settings", got.HTTP2.Settings)
+	}
+
+	got = get(srv.Client())
+	if got.Protocol != "HTTP/1.1" || got.HTTP2 != nil {
+		t.Errorf("protocol, http2 = %q, %v; want HTTP/1.1 without a fingerprint", got.Protocol, got.HTTP2)
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"connectionInfo/internal/render"
+	"connectionInfo/internal/wire"
+)
+
+// maxBodyPreview bounds how much of the request body is read and shown.
+const maxBodyPreview = 4096
+
+// rawRequest returns the request as it was received: from its recorded head
+// if there is one, or rebuilt from the parsed request otherwise. It reads
+// the start of the body.
+func rawRequest(r *http.Request, head *wire.Head) *render.RawRequest {
+	raw := &render.RawRequest{}
+	if head != nil {
+		raw.RequestLine = head.RequestLine
+		raw.Headers = make([]render.HeaderPair, 0, len(head.Fields))
+		for _, f := range head.Fields {
+			raw.Headers = append(raw.Headers, render.HeaderPair{Name: f.Name, Value: f.Value})
+		}
+	} else {
+		raw.Reconstructed = true
+		raw.RequestLine = r.Method + " " + r.RequestURI + " " + r.Proto
+		raw.Headers = append([]render.HeaderPair{{Name: "Host", Value: r.Host}}, extractHeaders(r)...)
+	}
+
+	if r.Body != nil {
+		// A body that fails to read part way is shown as far as it got
+		body, _ := io.ReadAll(io.LimitReader(r.Body, maxBodyPreview+1))
+		if len(body) > maxBodyPreview {
+			body, raw.Truncated = body[:maxBodyPreview], true
+		}
+		raw.Body = strings.ToValidUTF8(string(body), "\uFFFD")
+	}
+	return raw
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHandler_RawRequest(t *testing.T) {
+	conn := dialRecording(t, New())
+
+	body := strings.Repeat("x", maxBodyPreview) + "tail"
+	io.WriteString(conn, "POST /raw?a=1 HTTP/1.1\r\nhost: example.com\r\nX-Dup: a\r\nX-Dup: b\r\n"+
+		"Content-Length: "+strconv.Itoa(len(body))+"\r\n\r\n"+body)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	got, _ := io.ReadAll(resp.Body)
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	want := "POST /raw?a=1 HTTP/1.1\nhost: example.com\nX-Dup: a\nX-Dup: b\nContent-Length: 4100\n\n" +
+		strings.Repeat("x", maxBodyPreview) + "\n[body truncated]\n"
+	if string(got) != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestHandler_RawRequestReconstructed(t *testing.T) {
+	h := New()
+
+	req := httptest.NewRequest("GET", "/?format=raw", nil)
+	req.Header.Set("User-Agent", "curl/8.4.0")
+	req.Header.Add("X-Dup", "a")
+	req.Header.Add("X-Dup", "b")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	want := "GET /?format=raw HTTP/1.1\nHost: example.com\nUser-Agent: curl/8.4.0\nX-Dup: a, b\n\n"
+	if body := rr.Body.String(); body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
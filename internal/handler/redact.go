@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/url"
+	"strings"
+
+	"connectionInfo/internal/render"
+)
+
+// redact masks the header values, query parameters and body text that the
+// redaction policy covers, wherever info shows them, and reports whether it
+// masked any.
+func (h *Handler) redact(info *render.ConnectionInfo) bool {
+	masked := false
+	headers := func(list []render.HeaderPair) {
+		for i := range list {
+			var ok bool
+			if list[i].Value, ok = h.redaction.Header(list[i].Name, list[i].Value); ok {
+				masked = true
+			}
+		}
+	}
+
+	headers(info.Headers)
+	if info.HeaderOrder != nil {
+		headers(info.HeaderOrder.Headers)
+	}
+	if raw := info.RawRequest; raw != nil {
+		headers(raw.Headers)
+		var ok bool
+		if raw.Body, ok = h.redaction.Text(raw.Body); ok {
+			masked = true
+		}
+		if raw.RequestLine, ok = h.redactRequestLine(raw.RequestLine); ok {
+			masked = true
+		}
+	}
+
+	for name, values := range info.QueryParams {
+		for i := range values {
+			var ok bool
+			if values[i], ok = h.redaction.Param(name, values[i]); ok {
+				masked = true
+			}
+		}
+	}
+
+	// Cookies are masked one by one, so their names and sizes stay visible.
+	// What was decoded from a masked value would give it away.
+	for i := range info.Cookies.Cookies {
+		c := &info.Cookies.Cookies[i]
+		var ok bool
+		if c.Value, ok = h.redaction.Header("Cookie", c.Value); ok {
+			c.Decoded, c.JWTHeader, c.JWTClaims = "", "", ""
+			masked = true
+		}
+	}
+	return masked
+}
+
+// redactRequestLine masks the query parameters the policy covers in a
+// request line, leaving the rest of it as sent.
+func (h *Handler) redactRequestLine(line string) (string, bool) {
+	method, rest, ok1 := strings.Cut(line, " ")
+	target, proto, ok2 := strings.Cut(rest, " ")
+	path, query, ok3 := strings.Cut(target, "?")
+	if !ok1 || !ok2 || !ok3 {
+		return line, false
+	}
+
+	masked := false
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		// Names and values are compared as decoded, but kept as sent
+		decodedName, err1 := url.QueryUnescape(name)
+		decodedValue, err2 := url.QueryUnescape(value)
+		if err1 != nil || err2 != nil {
+			decodedName, decodedValue = name, value
+		}
+		if v, ok := h.redaction.Param(decodedName, decodedValue); ok {
+			params[i], masked = name+"="+v, true
+		}
+	}
+	if !masked {
+		return line, false
+	}
+	return method + " " + path + "?" + strings.Join(params, "&") + " " + proto, true
+}
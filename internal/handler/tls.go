@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"connectionInfo/internal/clienthello"
+	"connectionInfo/internal/render"
+)
+
+// tlsInfo describes the TLS connection the request arrived on, or returns
+// nil for requests that did not arrive over TLS.
+func (h *Handler) tlsInfo(r *http.Request) *render.TLSInfo {
+	cs := r.TLS
+	if cs == nil {
+		return nil
+	}
+
+	info := &render.TLSInfo{
+		Version:     tls.VersionName(cs.Version),
+		CipherSuite: tls.CipherSuiteName(cs.CipherSuite),
+		ServerName:  cs.ServerName,
+		ALPN:        cs.NegotiatedProtocol,
+		Resumed:     cs.DidResume,
+		ClientCerts: make([]render.CertInfo, 0, len(cs.PeerCertificates)),
+	}
+	if cs.CurveID != 0 {
+		info.Curve = cs.CurveID.String()
+	}
+
+	for _, cert := range cs.PeerCertificates {
+		info.ClientCerts = append(info.ClientCerts, certInfo(cert))
+	}
+	if len(cs.PeerCertificates) > 0 {
+		info.ClientVerified, info.ClientVerifyError = h.verifyClient(cs)
+	}
+	if conn := clienthello.FromContext(r.Context()); conn != nil {
+		if hello, _ := conn.Hello(); hello != nil {
+			info.ClientHello = clientHelloInfo(hello)
+		}
+	}
+	return info
+}
+
+// verifyClient reports whether the client's chain is valid. A chain the
+// handshake already verified is accepted as is; otherwise, when the server
+// only requested a certificate, it is verified here so that the report can
+// show why it would have been rejected.
+func (h *Handler) verifyClient(cs *tls.ConnectionState) (bool, string) {
+	if len(cs.VerifiedChains) > 0 {
+		return true, ""
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         h.clientCAs,
+		Intermediates: intermediates,
+		CurrentTime:   time.Now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return false, err.Error()
+	}
+	return true, ""
+}
+
+// certInfo summarizes a certificate for display.
+func certInfo(cert *x509.Certificate) render.CertInfo {
+	info := render.CertInfo{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SANs:               []string{},
+		Serial:             colonHex(cert.SerialNumber.Bytes()),
+		NotBefore:          cert.NotBefore.UTC(),
+		NotAfter:           cert.NotAfter.UTC(),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		IsCA:               cert.IsCA,
+	}
+
+	for _, name := range cert.DNSNames {
+		info.SANs = append(info.SANs, "DNS:"+name)
+	}
+	for _, ip := range cert.IPAddresses {
+		info.SANs = append(info.SANs, "IP:"+ip.String())
+	}
+	for _, email := range cert.EmailAddresses {
+		info.SANs = append(info.SANs, "email:"+email)
+	}
+	for _, uri := range cert.URIs {
+		info.SANs = append(info.SANs, "URI:"+uri.String())
+	}
+
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		info.KeyType, info.KeyBits = "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		info.KeyType, info.KeyBits = "ECDSA", key.Curve.Params().BitSize
+		info.KeyDetail = key.Curve.Params().Name
+	case ed25519.PublicKey:
+		info.KeyType, info.KeyBits = "Ed25519", 256
+	default:
+		info.KeyType = "unknown"
+	}
+
+	sha256Sum := sha256.Sum256(cert.Raw)
+	sha1Sum := sha1.Sum(cert.Raw)
+	info.SHA256 = colonHex(sha256Sum[:])
+	info.SHA1 = colonHex(sha1Sum[:])
+	return info
+}
+
+// clientHelloInfo decodes a ClientHello for display and fingerprints it.
+func clientHelloInfo(hello *clienthello.Hello) *render.ClientHelloInfo {
+	info := &render.ClientHelloInfo{
+		Version:             codePoint(hello.Version, clienthello.VersionName),
+		SupportedVersions:   codePoints(hello.SupportedVersions, clienthello.VersionName),
+		CipherSuites:        codePoints(hello.CipherSuites, clienthello.CipherSuiteName),
+		Extensions:          codePoints(hello.Extensions, clienthello.ExtensionName),
+		SupportedGroups:     codePoints(hello.SupportedGroups, clienthello.GroupName),
+		SignatureAlgorithms: codePoints(hello.SignatureAlgorithms, clienthello.SignatureSchemeName),
+		ECPointFormats:      make([]render.CodePoint, 0, len(hello.ECPointFormats)),
+		ALPN:                append([]string{}, hello.ALPN...),
+		GREASE:              []string{},
+	}
+	info.JA3, info.JA3Hash = hello.JA3()
+	info.JA4, info.JA4Raw = hello.JA4()
+
+	for _, f := range hello.ECPointFormats {
+		info.ECPointFormats = append(info.ECPointFormats, render.CodePoint{Value: uint16(f), Name: clienthello.PointFormatName(f)})
+	}
+
+	seen := make(map[uint16]bool)
+	for _, list := range [][]uint16{hello.CipherSuites, hello.Extensions, hello.SupportedGroups, hello.SupportedVersions, hello.SignatureAlgorithms} {
+		for _, v := range list {
+			if clienthello.IsGREASE(v) && !seen[v] {
+				seen[v] = true
+				info.GREASE = append(info.GREASE, render.CodePoint{Value: v}.Hex())
+			}
+		}
+	}
+	return info
+}
+
+func codePoint(v uint16, name func(uint16) string) render.CodePoint {
+	return render.CodePoint{Value: v, Name: name(v)}
+}
+
+func codePoints(values []uint16, name func(uint16) string) []render.CodePoint {
+	points := make([]render.CodePoint, 0, len(values))
+	for _, v := range values {
+		points = append(points, codePoint(v, name))
+	}
+	return points
+}
+
+// colonHex formats bytes as upper-case hex pairs separated by colons, the
+// way certificate tools print serials and fingerprints.
+func colonHex(b []byte) string {
+	if len(b) == 0 {
+		return "00"
+	}
+	pairs := make([]string, len(b))
+	for i := range b {
+		pairs[i] = strings.ToUpper(hex.EncodeToString(b[i : i+1]))
+	}
+	return strings.Join(pairs, ":")
+}
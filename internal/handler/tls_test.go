@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"connectionInfo/internal/clienthello"
+)
+
+// issue creates a certificate for commonName signed by parent, or
+// self-signed if parent is nil.
+func issue(t *testing.T, commonName string, isCA bool, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(0x1234),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		template.EmailAddresses = []string{"client@example.com"}
+		template.URIs = []*url.URL{{Scheme: "spiffe", Host: "example.com", Path: "/client"}}
+	}
+
+	signer, signerKey := template, any(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+type clientCertReport struct {
+	TLS *struct {
+		ClientCertificates []struct {
+			Subject string   `json:"subject"`
+			Issuer  string   `json:"issuer"`
+			SANs    []string `json:"sans"`
+			Serial  string   `json:"serial"`
+			KeyType string   `json:"key_type"`
+			KeyBits int      `json:"key_bits"`
+			SHA256  string   `json:"sha256"`
+		} `json:"client_certificates"`
+		ClientVerified    bool   `json:"client_verified"`
+		ClientVerifyError string `json:"client_verify_error"`
+	} `json:"tls"`
+}
+
+// getWithCert requests /json from a TLS server that asks for, but does not
+// verify, client certificates, presenting certs.
+func getWithCert(t *testing.T, h *Handler, certs ...tls.Certificate) clientCertReport {
+	t.Helper()
+
+	srv := httptest.NewUnstartedServer(h)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := srv.Client()
+	client.Transport.(*http.Transport).TLSClientConfig.Certificates = certs
+	resp, err := client.Get(srv.URL + "/json")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	var got clientCertReport
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	return got
+}
+
+func TestHandler_ClientCertificate(t *testing.T) {
+	ca := issue(t, "Test CA", true, nil)
+	trusted := issue(t, "trusted client", false, &ca)
+	untrusted := issue(t, "untrusted client", false, nil)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	h := New(WithClientCAs(pool))
+
+	got := getWithCert(t, h, trusted)
+	if got.TLS == nil || len(got.TLS.ClientCertificates) != 1 {
+		t.Fatalf("tls = %+v, want one client certificate", got.TLS)
+	}
+	cert := got.TLS.ClientCertificates[0]
+	if cert.Subject != "CN=trusted client" || cert.Issuer != "CN=Test CA" || cert.Serial != "12:34" {
+		t.Errorf("unexpected certificate: %+v", cert)
+	}
+	if cert.KeyType != "ECDSA" || cert.KeyBits != 256 || len(cert.SHA256) != 95 {
+		t.Errorf("unexpected key or fingerprint: %+v", cert)
+	}
+	wantSANs := "email:client@example.com URI:spiffe://example.com/client"
+	if strings.Join(cert.SANs, " ") != wantSANs {
+		t.Errorf("sans = %v, want %s", cert.SANs, wantSANs)
+	}
+	if !got.TLS.ClientVerified || got.TLS.ClientVerifyError != "" {
+		t.Errorf("client_verified, client_verify_error = %v, %q; want true", got.TLS.ClientVerified, got.TLS.ClientVerifyError)
+	}
+
+	got = getWithCert(t, h, untrusted)
+	if got.TLS == nil || got.TLS.ClientVerified || !strings.Contains(got.TLS.ClientVerifyError, "unknown authority") {
+		t.Errorf("tls = %+v, want an unknown authority error", got.TLS)
+	}
+
+	got = getWithCert(t, h)
+	if got.TLS == nil || len(got.TLS.ClientCertificates) != 0 || got.TLS.ClientVerified || got.TLS.ClientVerifyError != "" {
+		t.Errorf("tls = %+v, want no client certificate", got.TLS)
+	}
+}
+
+func TestHandler_ClientHello(t *testing.T) {
+	srv := httptest.NewUnstartedServer(New())
+	srv.Listener = &clienthello.Listener{Listener: srv.Listener}
+	srv.Config.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		return clienthello.NewContext(ctx, c.(*tls.Conn).NetConn().(*clienthello.Conn))
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/json")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	var got struct {
+		TLS struct {
+			ClientHello *struct {
+				JA3Hash      string `json:"ja3_hash"`
+				JA4          string `json:"ja4"`
+				CipherSuites []struct {
+					Value string `json:"value"`
+					Name  string `json:"name"`
+				} `json:"cipher_suites"`
+			} `json:"client_hello"`
+		} `json:"tls"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+
+	hello := got.TLS.ClientHello
+	if hello == nil {
+		t.Fatal("client_hello = null for a request over a recording listener")
+	}
+	if len(hello.JA3Hash) != 32 || !strings.HasPrefix(hello.JA4, "t13") {
+		t.Errorf("ja3_hash, ja4 = %q, %q", hello.JA3Hash, hello.JA4)
+	}
+	if len(hello.CipherSuites) == 0 || hello.CipherSuites[0].Name == "" {
+		t.Errorf("cipher_suites = %+v, want named suites", hello.CipherSuites)
+	}
+}
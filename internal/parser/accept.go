@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MediaRange is a single entry of an Accept header, e.g. "text/html;q=0.9".
+type MediaRange struct {
+	Type    string            // e.g., "text" or "*"
+	Subtype string            // e.g., "html" or "*"
+	Params  map[string]string // Parameters other than q, lower-cased names
+	Q       float64           // Quality value between 0 and 1 (default 1)
+}
+
+// MediaType returns the range as "type/subtype".
+func (m MediaRange) MediaType() string {
+	return m.Type + "/" + m.Subtype
+}
+
+// Specificity ranks how precisely the range names a media type:
+// 0 for "*/*", 1 for "type/*" and 2 for "type/subtype".
+func (m MediaRange) Specificity() int {
+	switch {
+	case m.Type == "*":
+		return 0
+	case m.Subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Matches reports whether the range covers the given "type/subtype" media type.
+func (m MediaRange) Matches(mediaType string) bool {
+	typ, subtype, ok := strings.Cut(strings.ToLower(mediaType), "/")
+	if !ok {
+		return false
+	}
+	if m.Type == "*" {
+		return true
+	}
+	return m.Type == typ && (m.Subtype == "*" || m.Subtype == subtype)
+}
+
+// ParseAccept parses an Accept header into media ranges in the order the
+// client sent them. Parameter values may be quoted strings, which can hold
+// commas and semicolons. Malformed entries are skipped; an empty header
+// yields nil, which callers should treat as "*/*".
+func ParseAccept(header string) []MediaRange {
+	var ranges []MediaRange
+
+	for _, entry := range splitQuoted(header, ',') {
+		parts := splitQuoted(entry, ';')
+
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(parts[0])), "/")
+		typ, subtype = strings.TrimSpace(typ), strings.TrimSpace(subtype)
+		if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+			continue
+		}
+
+		mr := MediaRange{Type: typ, Subtype: subtype, Q: 1}
+		valid := true
+		for _, param := range parts[1:] {
+			name, value, _ := strings.Cut(param, "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if value, valid = unquote(strings.TrimSpace(value)); !valid {
+				break
+			}
+			if name == "q" {
+				if mr.Q, valid = parseQ(value); !valid {
+					break
+				}
+				continue
+			}
+			if mr.Params == nil {
+				mr.Params = make(map[string]string)
+			}
+			mr.Params[name] = value
+		}
+
+		if valid {
+			ranges = append(ranges, mr)
+		}
+	}
+
+	return ranges
+}
+
+// PreferredMediaRanges returns a copy of ranges ordered by preference:
+// highest quality first, ties in the order the client sent them.
+func PreferredMediaRanges(ranges []MediaRange) []MediaRange {
+	return byPreference(ranges, func(m MediaRange) float64 { return m.Q })
+}
+
+// Coding is a single entry of an Accept-Encoding header, e.g. "br;q=0.8".
+type Coding struct {
+	Name string  // Content coding, lower-cased, e.g. "gzip", "identity" or "*"
+	Q    float64 // Quality value between 0 and 1 (default 1)
+}
+
+// ParseAcceptEncoding parses an Accept-Encoding header into content codings
+// ordered by preference: highest quality first, ties in the order sent.
+// Malformed entries are skipped.
+func ParseAcceptEncoding(header string) []Coding {
+	var codings []Coding
+	for _, entry := range strings.Split(header, ",") {
+		name, q, ok := parseWeighted(entry)
+		if !ok || strings.ContainsAny(name, " \t/") {
+			continue
+		}
+		codings = append(codings, Coding{Name: strings.ToLower(name), Q: q})
+	}
+	return byPreference(codings, func(c Coding) float64 { return c.Q })
+}
+
+// parseWeighted splits a list entry such as "gzip;q=0.8" into its value and
+// quality. Parameters other than q are ignored.
+func parseWeighted(entry string) (string, float64, bool) {
+	parts := strings.Split(entry, ";")
+	value := strings.TrimSpace(parts[0])
+	if value == "" {
+		return "", 0, false
+	}
+	q := 1.0
+	for _, param := range parts[1:] {
+		name, v, _ := strings.Cut(param, "=")
+		if strings.EqualFold(strings.TrimSpace(name), "q") {
+			var ok bool
+			if q, ok = parseQ(strings.TrimSpace(v)); !ok {
+				return "", 0, false
+			}
+		}
+	}
+	return value, q, true
+}
+
+// parseQ parses a quality value, which must be between 0 and 1.
+func parseQ(value string) (float64, bool) {
+	q, err := strconv.ParseFloat(value, 64)
+	if err != nil || q < 0 || q > 1 {
+		return 0, false
+	}
+	return q, true
+}
+
+// byPreference returns a copy of list sorted by descending quality, keeping
+// the order of entries with equal quality.
+func byPreference[T any](list []T, q func(T) float64) []T {
+	sorted := slices.Clone(list)
+	slices.SortStableFunc(sorted, func(a, b T) int {
+		switch qa, qb := q(a), q(b); {
+		case qa > qb:
+			return -1
+		case qa < qb:
+			return 1
+		}
+		return 0
+	})
+	return sorted
+}
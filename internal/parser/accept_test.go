@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	ranges := ParseAccept(`text/html, application/xhtml+xml, application/xml;q=0.9, text/plain; format=flowed, */*;q=0.8`)
+
+	expected := []struct {
+		mediaType   string
+		q           float64
+		specificity int
+	}{
+		{"text/html", 1, 2},
+		{"application/xhtml+xml", 1, 2},
+		{"application/xml", 0.9, 2},
+		{"text/plain", 1, 2},
+		{"*/*", 0.8, 0},
+	}
+
+	if len(ranges) != len(expected) {
+		t.Fatalf("ParseAccept() returned %d ranges, want %d: %+v", len(ranges), len(expected), ranges)
+	}
+	for i, want := range expected {
+		got := ranges[i]
+		if got.MediaType() != want.mediaType || got.Q != want.q || got.Specificity() != want.specificity {
+			t.Errorf("range %d = %s q=%v specificity=%d, want %s q=%v specificity=%d",
+				i, got.MediaType(), got.Q, got.Specificity(), want.mediaType, want.q, want.specificity)
+		}
+	}
+
+	if ranges[3].Params["format"] != "flowed" {
+		t.Errorf("Params = %v, want format=flowed", ranges[3].Params)
+	}
+}
+
+func TestParseAccept_QuotedParams(t *testing.T) {
+	ranges := ParseAccept(`text/html;foo="a,b;c=\"d\"";q=0.5, application/json`)
+
+	if len(ranges) != 2 {
+		t.Fatalf("ParseAccept() returned %d ranges, want 2: %+v", len(ranges), ranges)
+	}
+	if got := ranges[0]; got.MediaType() != "text/html" || got.Q != 0.5 || got.Params["foo"] != `a,b;c="d"` {
+		t.Errorf("range 0 = %s q=%v params=%v, want text/html q=0.5 with foo=a,b;c=\"d\"", got.MediaType(), got.Q, got.Params)
+	}
+	if got := ranges[1]; got.MediaType() != "application/json" || got.Q != 1 {
+		t.Errorf("range 1 = %s q=%v, want application/json q=1", got.MediaType(), got.Q)
+	}
+}
+
+func TestParseAccept_Malformed(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"", 0},
+		{"text", 0},
+		{"*/html", 0},
+		{"text/html;q=2", 0},
+		{"text/html;q=abc, application/json", 1},
+		{"TEXT/HTML", 1},
+		{`text/html;foo="a, application/json`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := ParseAccept(tt.header); len(got) != tt.want {
+				t.Errorf("ParseAccept(%q) returned %d ranges, want %d: %+v", tt.header, len(got), tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMediaRange_Matches(t *testing.T) {
+	tests := []struct {
+		accept    string
+		mediaType string
+		want      bool
+	}{
+		{"*/*", "application/json", true},
+		{"text/*", "text/csv", true},
+		{"text/*", "application/json", false},
+		{"application/json", "application/json", true},
+		{"application/json", "application/yaml", false},
+		{"text/html", "TEXT/HTML", true},
+	}
+
+	for _, tt := range tests {
+		mr := ParseAccept(tt.accept)[0]
+		if got := mr.Matches(tt.mediaType); got != tt.want {
+			t.Errorf("%s.Matches(%q) = %v, want %v", tt.accept, tt.mediaType, got, tt.want)
+		}
+	}
+}
+
+func TestParseAcceptEncoding(t *testing.T) {
+	codings := ParseAcceptEncoding("gzip;q=0.8, deflate;q=0.8, BR, zstd, identity;q=0, bad/coding, x;q=9")
+
+	expected := []Coding{
+		{"br", 1},
+		{"zstd", 1},
+		{"gzip", 0.8},
+		{"deflate", 0.8},
+		{"identity", 0},
+	}
+
+	if len(codings) != len(expected) {
+		t.Fatalf("ParseAcceptEncoding() returned %d codings, want %d: %+v", len(codings), len(expected), codings)
+	}
+	for i, want := range expected {
+		if codings[i] != want {
+			t.Errorf("coding %d = %+v, want %+v", i, codings[i], want)
+		}
+	}
+}
+
+func TestPreferredMediaRanges(t *testing.T) {
+	ranges := ParseAccept("*/*;q=0.8, application/json;q=0.9, text/html")
+
+	got := PreferredMediaRanges(ranges)
+	var types []string
+	for _, r := range got {
+		types = append(types, r.MediaType())
+	}
+	if want := "text/html application/json */*"; strings.Join(types, " ") != want {
+		t.Errorf("PreferredMediaRanges() = %v, want %s", types, want)
+	}
+	if ranges[0].MediaType() != "*/*" {
+		t.Error("PreferredMediaRanges() should not reorder its argument")
+	}
+}
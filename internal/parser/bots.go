@@ -0,0 +1,180 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Client categories, as reported in UserAgentInfo.Category.
+const (
+	CategoryBrowser    = "browser"
+	CategoryBot        = "bot"         // Crawlers, link previewers and AI agents
+	CategoryCLI        = "cli"         // Command-line tools such as curl
+	CategoryLibrary    = "library"     // HTTP libraries and SDKs used by programs
+	CategoryFeedReader = "feed-reader" // RSS and Atom readers
+	CategoryMonitor    = "monitor"     // Uptime monitors and health checks
+	CategoryAutomation = "automation"  // Headless and remote-controlled browsers
+	CategoryUnknown    = "unknown"
+)
+
+// Bot describes a well-known automated client named by the User-Agent.
+type Bot struct {
+	Name     string   // Product, e.g. "Googlebot"
+	Operator string   // Who runs it, e.g. "Google"
+	Domains  []string // Domains its addresses reverse-resolve into; empty if the operator publishes none
+
+	// Set by verification against the client's address, which only
+	// applies to bots with Domains
+	Verified     *bool  // Whether the address has a forward-confirmed PTR name in Domains; nil if not checked
+	VerifiedName string // The PTR name that verified it
+	VerifyError  string // Why the check could not be made, e.g. "timeout"
+}
+
+// Verify checks the forward-confirmed PTR names of the client's address
+// against the bot's domains. Names that are not forward-confirmed must be
+// left out, since anyone can point the PTR record of their own address at
+// any name.
+func (b *Bot) Verify(confirmedNames []string) {
+	verified := false
+	for _, name := range confirmedNames {
+		if slices.ContainsFunc(b.Domains, func(domain string) bool {
+			return name == domain || strings.HasSuffix(name, "."+domain)
+		}) {
+			verified, b.VerifiedName = true, name
+			break
+		}
+	}
+	b.Verified = &verified
+}
+
+// agent is a pattern for a client that is not a browser or command-line
+// tool. The pattern's first group, if any, is the version.
+type agent struct {
+	name     string
+	category string
+	pattern  *regexp.Regexp
+	operator string   // Set for well-known bots, which are reported as a Bot
+	domains  []string // For bots whose operator documents reverse DNS verification
+}
+
+// Google's crawlers all share these domains.
+var googleDomains = []string{"googlebot.com", "google.com", "googleusercontent.com"}
+
+// Patterns for bots, monitors, feed readers, libraries and automation,
+// checked before the browser patterns, as many of these clients also claim
+// to be a browser.
+var agentPatterns = []agent{
+	// Search engines, which document how to verify their crawlers
+	{"Googlebot", CategoryBot, regexp.MustCompile(`Googlebot(?:-Image|-Video|-News)?/(\d+(?:\.\d+)*)`), "Google", googleDomains},
+	{"AdsBot-Google", CategoryBot, regexp.MustCompile(`AdsBot-Google(?:-Mobile)?`), "Google", googleDomains},
+	{"Google-InspectionTool", CategoryBot, regexp.MustCompile(`Google-InspectionTool/(\d+(?:\.\d+)*)`), "Google", googleDomains},
+	{"GoogleOther", CategoryBot, regexp.MustCompile(`GoogleOther`), "Google", googleDomains},
+	{"Storebot-Google", CategoryBot, regexp.MustCompile(`Storebot-Google/(\d+(?:\.\d+)*)`), "Google", googleDomains},
+	{"Bingbot", CategoryBot, regexp.MustCompile(`(?i)bingbot/(\d+(?:\.\d+)*)`), "Microsoft", []string{"search.msn.com"}},
+	{"Applebot", CategoryBot, regexp.MustCompile(`Applebot/(\d+(?:\.\d+)*)`), "Apple", []string{"applebot.apple.com"}},
+	{"YandexBot", CategoryBot, regexp.MustCompile(`YandexBot/(\d+(?:\.\d+)*)`), "Yandex", []string{"yandex.ru", "yandex.net", "yandex.com"}},
+	{"Baiduspider", CategoryBot, regexp.MustCompile(`Baiduspider(?:-render)?/(\d+(?:\.\d+)*)`), "Baidu", []string{"baidu.com", "baidu.jp"}},
+	{"Amazonbot", CategoryBot, regexp.MustCompile(`Amazonbot/(\d+(?:\.\d+)*)`), "Amazon", []string{"crawl.amazonbot.amazon"}},
+	{"DuckDuckBot", CategoryBot, regexp.MustCompile(`DuckDuckBot(?:-Https)?/(\d+(?:\.\d+)*)`), "DuckDuckGo", nil},
+
+	// AI crawlers and agents
+	{"GPTBot", CategoryBot, regexp.MustCompile(`GPTBot/(\d+(?:\.\d+)*)`), "OpenAI", nil},
+	{"ChatGPT-User", CategoryBot, regexp.MustCompile(`ChatGPT-User/(\d+(?:\.\d+)*)`), "OpenAI", nil},
+	{"OAI-SearchBot", CategoryBot, regexp.MustCompile(`OAI-SearchBot/(\d+(?:\.\d+)*)`), "OpenAI", nil},
+	{"ClaudeBot", CategoryBot, regexp.MustCompile(`ClaudeBot/(\d+(?:\.\d+)*)`), "Anthropic", nil},
+	{"PerplexityBot", CategoryBot, regexp.MustCompile(`PerplexityBot/(\d+(?:\.\d+)*)`), "Perplexity", nil},
+	{"CCBot", CategoryBot, regexp.MustCompile(`CCBot/(\d+(?:\.\d+)*)`), "Common Crawl", nil},
+	{"Bytespider", CategoryBot, regexp.MustCompile(`Bytespider`), "ByteDance", nil},
+
+	// Link previews and social networks
+	{"facebookexternalhit", CategoryBot, regexp.MustCompile(`facebookexternalhit/(\d+(?:\.\d+)*)`), "Meta", nil},
+	{"meta-externalagent", CategoryBot, regexp.MustCompile(`meta-externalagent/(\d+(?:\.\d+)*)`), "Meta", nil},
+	{"Twitterbot", CategoryBot, regexp.MustCompile(`Twitterbot/(\d+(?:\.\d+)*)`), "X", nil},
+	{"LinkedInBot", CategoryBot, regexp.MustCompile(`LinkedInBot/(\d+(?:\.\d+)*)`), "LinkedIn", nil},
+	{"Slackbot", CategoryBot, regexp.MustCompile(`Slackbot(?:-LinkExpanding)? (\d+(?:\.\d+)*)`), "Slack", nil},
+	{"Discordbot", CategoryBot, regexp.MustCompile(`Discordbot/(\d+(?:\.\d+)*)`), "Discord", nil},
+
+	// SEO tools
+	{"AhrefsBot", CategoryBot, regexp.MustCompile(`AhrefsBot/(\d+(?:\.\d+)*)`), "Ahrefs", nil},
+	{"SemrushBot", CategoryBot, regexp.MustCompile(`SemrushBot(?:-\w+)?/(\d+(?:\.\d+)*)`), "Semrush", nil},
+
+	// Monitors and health checks
+	{"UptimeRobot", CategoryMonitor, regexp.MustCompile(`UptimeRobot/(\d+(?:\.\d+)*)`), "UptimeRobot", nil},
+	{"Pingdom", CategoryMonitor, regexp.MustCompile(`Pingdom\.com_bot_version_(\d+(?:\.\d+)*)`), "SolarWinds", nil},
+	{"StatusCake", CategoryMonitor, regexp.MustCompile(`StatusCake`), "StatusCake", nil},
+	{"Site24x7", CategoryMonitor, regexp.MustCompile(`Site24x7`), "Zoho", nil},
+	{"Better Stack", CategoryMonitor, regexp.MustCompile(`Better (?:Uptime|Stack) Bot`), "Better Stack", nil},
+	{"Datadog Synthetics", CategoryMonitor, regexp.MustCompile(`Datadog/Synthetics`), "Datadog", nil},
+	{"kube-probe", CategoryMonitor, regexp.MustCompile(`^kube-probe/(\d+(?:\.\d+)*)`), "", nil},
+	{"ELB-HealthChecker", CategoryMonitor, regexp.MustCompile(`^ELB-HealthChecker/(\d+(?:\.\d+)*)`), "Amazon", nil},
+	{"GoogleHC", CategoryMonitor, regexp.MustCompile(`^GoogleHC/(\d+(?:\.\d+)*)`), "Google", nil},
+	{"Blackbox Exporter", CategoryMonitor, regexp.MustCompile(`^Blackbox Exporter/(\d+(?:\.\d+)*)`), "", nil},
+	{"check_http", CategoryMonitor, regexp.MustCompile(`^check_http/v?(\d+(?:\.\d+)*)`), "", nil},
+
+	// Feed readers
+	{"Feedly", CategoryFeedReader, regexp.MustCompile(`Feedly(?:Bot)?/(\d+(?:\.\d+)*)`), "", nil},
+	{"Inoreader", CategoryFeedReader, regexp.MustCompile(`Inoreader/(\d+(?:\.\d+)*)`), "", nil},
+	{"NewsBlur", CategoryFeedReader, regexp.MustCompile(`NewsBlur`), "", nil},
+	{"Feedbin", CategoryFeedReader, regexp.MustCompile(`Feedbin`), "", nil},
+	{"FreshRSS", CategoryFeedReader, regexp.MustCompile(`FreshRSS/(\d+(?:\.\d+)*)`), "", nil},
+	{"Tiny Tiny RSS", CategoryFeedReader, regexp.MustCompile(`Tiny Tiny RSS/(\d+(?:\.\d+)*)`), "", nil},
+	{"Miniflux", CategoryFeedReader, regexp.MustCompile(`Miniflux/(\d+(?:\.\d+)*)`), "", nil},
+	{"NetNewsWire", CategoryFeedReader, regexp.MustCompile(`NetNewsWire(?: \(RSS Reader[^)]*\))?(?:/| Version )?(\d+(?:\.\d+)*)?`), "", nil},
+
+	// Headless and remote-controlled browsers
+	{"Chrome", CategoryAutomation, regexp.MustCompile(`HeadlessChrome/(\d+(?:\.\d+)*)`), "", nil},
+	{"PhantomJS", CategoryAutomation, regexp.MustCompile(`PhantomJS/(\d+(?:\.\d+)*)`), "", nil},
+	{"Lighthouse", CategoryAutomation, regexp.MustCompile(`Chrome-Lighthouse`), "", nil},
+	{"Cypress", CategoryAutomation, regexp.MustCompile(`Cypress/(\d+(?:\.\d+)*)`), "", nil},
+
+	// HTTP libraries and SDKs
+	{"python-requests", CategoryLibrary, regexp.MustCompile(`^python-requests/(\d+(?:\.\d+)*)`), "", nil},
+	{"python-httpx", CategoryLibrary, regexp.MustCompile(`^python-httpx/(\d+(?:\.\d+)*)`), "", nil},
+	{"Python-urllib", CategoryLibrary, regexp.MustCompile(`^Python-urllib/(\d+(?:\.\d+)*)`), "", nil},
+	{"aiohttp", CategoryLibrary, regexp.MustCompile(`aiohttp/(\d+(?:\.\d+)*)`), "", nil},
+	{"Go-http-client", CategoryLibrary, regexp.MustCompile(`^Go-http-client/(\d+(?:\.\d+)*)`), "", nil},
+	{"okhttp", CategoryLibrary, regexp.MustCompile(`^okhttp/(\d+(?:\.\d+)*)`), "", nil},
+	{"axios", CategoryLibrary, regexp.MustCompile(`^axios/(\d+(?:\.\d+)*)`), "", nil},
+	{"node-fetch", CategoryLibrary, regexp.MustCompile(`^node-fetch(?:/(\d+(?:\.\d+)*))?`), "", nil},
+	{"Apache-HttpClient", CategoryLibrary, regexp.MustCompile(`^Apache-HttpClient/(\d+(?:\.\d+)*)`), "", nil},
+	{"Java", CategoryLibrary, regexp.MustCompile(`^Java/(\d+(?:\.\d+)*)`), "", nil},
+	{"libwww-perl", CategoryLibrary, regexp.MustCompile(`^libwww-perl/(\d+(?:\.\d+)*)`), "", nil},
+	{"Guzzle", CategoryLibrary, regexp.MustCompile(`^GuzzleHttp/(\d+(?:\.\d+)*)`), "", nil},
+	{"Faraday", CategoryLibrary, regexp.MustCompile(`^Faraday v(\d+(?:\.\d+)*)`), "", nil},
+	{"Dart", CategoryLibrary, regexp.MustCompile(`^Dart/(\d+(?:\.\d+)*)`), "", nil},
+	{"reqwest", CategoryLibrary, regexp.MustCompile(`^reqwest/(\d+(?:\.\d+)*)`), "", nil},
+	{"PostmanRuntime", CategoryLibrary, regexp.MustCompile(`^PostmanRuntime/(\d+(?:\.\d+)*)`), "", nil},
+}
+
+// genericBotPattern matches a product token that names itself a bot,
+// crawler or spider, such as "ExampleBot/1.0". A version is required, so
+// that device names such as "CUBOT" in a browser's User-Agent do not match.
+var genericBotPattern = regexp.MustCompile(`(?i)\b([a-z][\w.-]*(?:bot|crawler|spider))/(\d+(?:\.\d+)*)`)
+
+// matchAgent looks up the clients of agentPatterns and then the generic bot
+// pattern in ua, filling in info if one matches.
+func matchAgent(ua string, info *UserAgentInfo) bool {
+	for _, a := range agentPatterns {
+		matches := a.pattern.FindStringSubmatch(ua)
+		if matches == nil {
+			continue
+		}
+		info.BrowserName, info.Category = a.name, a.category
+		info.Sources.Browser = SourceUserAgent
+		if len(matches) > 1 && matches[1] != "" {
+			info.BrowserVersion, info.Sources.BrowserVersion = matches[1], SourceUserAgent
+		}
+		if a.operator != "" {
+			info.Bot = &Bot{Name: a.name, Operator: a.operator, Domains: a.domains}
+		}
+		return true
+	}
+
+	if matches := genericBotPattern.FindStringSubmatch(ua); matches != nil {
+		info.BrowserName, info.BrowserVersion, info.Category = matches[1], matches[2], CategoryBot
+		info.Sources.Browser, info.Sources.BrowserVersion = SourceUserAgent, SourceUserAgent
+		return true
+	}
+	return false
+}
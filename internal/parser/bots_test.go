@@ -0,0 +1,195 @@
+package parser
+
+import "testing"
+
+func TestParseUserAgent_Category(t *testing.T) {
+	tests := []struct {
+		name         string
+		ua           string
+		wantBrowser  string
+		wantVersion  string
+		wantCategory string
+		wantOperator string // Empty if no Bot is expected
+		wantDomains  bool
+	}{
+		{
+			name:         "Googlebot",
+			ua:           "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			wantBrowser:  "Googlebot",
+			wantVersion:  "2.1",
+			wantCategory: CategoryBot,
+			wantOperator: "Google",
+			wantDomains:  true,
+		},
+		{
+			name:         "Googlebot smartphone",
+			ua:           "Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.216 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			wantBrowser:  "Googlebot",
+			wantVersion:  "2.1",
+			wantCategory: CategoryBot,
+			wantOperator: "Google",
+			wantDomains:  true,
+		},
+		{
+			name:         "Bingbot",
+			ua:           "Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm) Chrome/116.0.1938.76 Safari/537.36",
+			wantBrowser:  "Bingbot",
+			wantVersion:  "2.0",
+			wantCategory: CategoryBot,
+			wantOperator: "Microsoft",
+			wantDomains:  true,
+		},
+		{
+			name:         "GPTBot",
+			ua:           "Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko); compatible; GPTBot/1.2; +https://openai.com/gptbot",
+			wantBrowser:  "GPTBot",
+			wantVersion:  "1.2",
+			wantCategory: CategoryBot,
+			wantOperator: "OpenAI",
+		},
+		{
+			name:         "facebookexternalhit",
+			ua:           "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+			wantBrowser:  "facebookexternalhit",
+			wantVersion:  "1.1",
+			wantCategory: CategoryBot,
+			wantOperator: "Meta",
+		},
+		{
+			name:         "UptimeRobot",
+			ua:           "Mozilla/5.0+(compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)",
+			wantBrowser:  "UptimeRobot",
+			wantVersion:  "2.0",
+			wantCategory: CategoryMonitor,
+			wantOperator: "UptimeRobot",
+		},
+		{
+			name:         "Headless Chrome",
+			ua:           "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.6099.71 Safari/537.36",
+			wantBrowser:  "Chrome",
+			wantVersion:  "120.0.6099.71",
+			wantCategory: CategoryAutomation,
+		},
+		{
+			name:         "Feedly",
+			ua:           "Feedly/1.0 (+http://www.feedly.com/fetcher.html; 5 subscribers; like FeedFetcher-Google)",
+			wantBrowser:  "Feedly",
+			wantVersion:  "1.0",
+			wantCategory: CategoryFeedReader,
+		},
+		{
+			name:         "python-requests",
+			ua:           "python-requests/2.31.0",
+			wantBrowser:  "python-requests",
+			wantVersion:  "2.31.0",
+			wantCategory: CategoryLibrary,
+		},
+		{
+			name:         "Go",
+			ua:           "Go-http-client/2.0",
+			wantBrowser:  "Go-http-client",
+			wantVersion:  "2.0",
+			wantCategory: CategoryLibrary,
+		},
+		{
+			name:         "curl",
+			ua:           "curl/8.4.0",
+			wantBrowser:  "curl",
+			wantVersion:  "8.4.0",
+			wantCategory: CategoryCLI,
+		},
+		{
+			name:         "Browser",
+			ua:           "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			wantBrowser:  "Firefox",
+			wantVersion:  "121.0",
+			wantCategory: CategoryBrowser,
+		},
+		{
+			name:         "Device name ending in bot",
+			ua:           "Mozilla/5.0 (Linux; Android 9; CUBOT X19) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			wantBrowser:  "Chrome",
+			wantVersion:  "120.0.0.0",
+			wantCategory: CategoryBrowser,
+		},
+		{
+			name:         "Empty",
+			ua:           "",
+			wantBrowser:  "Unknown",
+			wantCategory: CategoryUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseUserAgent(tt.ua)
+
+			if result.BrowserName != tt.wantBrowser || result.BrowserVersion != tt.wantVersion {
+				t.Errorf("browser = %q %q, want %q %q", result.BrowserName, result.BrowserVersion, tt.wantBrowser, tt.wantVersion)
+			}
+			if result.Category != tt.wantCategory {
+				t.Errorf("Category = %q, want %q", result.Category, tt.wantCategory)
+			}
+			if result.CLI != (tt.wantCategory == CategoryCLI) {
+				t.Errorf("CLI = %v for category %q", result.CLI, tt.wantCategory)
+			}
+			if tt.wantOperator == "" {
+				if result.Bot != nil {
+					t.Errorf("Bot = %+v, want nil", result.Bot)
+				}
+				return
+			}
+			if result.Bot == nil {
+				t.Fatal("Bot = nil")
+			}
+			if result.Bot.Name != tt.wantBrowser || result.Bot.Operator != tt.wantOperator {
+				t.Errorf("Bot = %q by %q, want %q by %q", result.Bot.Name, result.Bot.Operator, tt.wantBrowser, tt.wantOperator)
+			}
+			if got := len(result.Bot.Domains) > 0; got != tt.wantDomains {
+				t.Errorf("has Domains = %v, want %v", got, tt.wantDomains)
+			}
+		})
+	}
+}
+
+func TestBotVerify(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		want     bool
+		wantName string
+	}{
+		{"subdomain", []string{"crawl-66-249-66-1.googlebot.com"}, true, "crawl-66-249-66-1.googlebot.com"},
+		{"other domain", []string{"host.example.com"}, false, ""},
+		{"lookalike domain", []string{"crawl.evilgooglebot.com"}, false, ""},
+		{"second name", []string{"host.example.com", "rate-limited-proxy-66-249-90-1.google.com"}, true, "rate-limited-proxy-66-249-90-1.google.com"},
+		{"no names", nil, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := ParseUserAgent("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)").Bot
+			bot.Verify(tt.names)
+			if bot.Verified == nil || *bot.Verified != tt.want {
+				t.Errorf("Verified = %v, want %v", bot.Verified, tt.want)
+			}
+			if bot.VerifiedName != tt.wantName {
+				t.Errorf("VerifiedName = %q, want %q", bot.VerifiedName, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestMergeClientHints_Bot(t *testing.T) {
+	info := ParseUserAgent("Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm) Chrome/116.0.1938.76 Safari/537.36")
+	info.MergeClientHints(&ClientHintsInfo{Brands: []Brand{{Name: "Chromium", Version: "116"}}})
+	if info.BrowserName != "Bingbot" || info.Category != CategoryBot {
+		t.Errorf("browser = %q (%s), want Bingbot (bot)", info.BrowserName, info.Category)
+	}
+
+	info = ParseUserAgent("Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	info.MergeClientHints(&ClientHintsInfo{Brands: []Brand{{Name: "HeadlessChrome", Version: "120"}, {Name: "Chromium", Version: "120"}}})
+	if info.BrowserName != "Chrome" || info.Category != CategoryAutomation {
+		t.Errorf("browser = %q (%s), want Chrome (automation)", info.BrowserName, info.Category)
+	}
+}
@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Hop sources, as reported in Hop.Source.
+const (
+	SourceForwarded     = "Forwarded"
+	SourceXForwardedFor = "X-Forwarded-For"
+	SourceXRealIP       = "X-Real-IP"
+	SourceVia           = "Via"
+	SourceSocket        = "socket"
+	SourceProxyProtocol = "PROXY protocol"
+)
+
+// Hop is one address on the path between the client and this server, as
+// reported by a forwarding header or observed on the socket.
+type Hop struct {
+	Address string // Address as it appeared in its source
+	IP      string // Normalized IP address, empty if Address is not one
+	Source  string // One of the Source* constants
+	Trusted bool   // Whether IP belongs to a trusted proxy network
+	Valid   bool   // Whether Address parsed as an IP address
+	Client  bool   // Whether this hop was chosen as the client IP
+}
+
+// Chain lists every hop the request reports, grouped by source in the order
+// Forwarded, X-Forwarded-For, X-Real-IP, Via, with the socket peer last.
+// Within each source hops run from the client towards this server.
+//
+// Exactly one hop is marked as the client. Forwarding headers are only
+// honored when the socket peer is a trusted proxy; otherwise anyone could
+// spoof their address by sending the headers themselves. The standard
+// Forwarded header is preferred, then X-Forwarded-For; the chosen chain is
+// walked right to left, skipping trusted proxies, and the first untrusted
+// hop is the client. An invalid hop (a garbled entry, or an "unknown" or
+// obfuscated Forwarded node) ends the walk, and the last valid hop to its
+// right is the best answer available. Without either header X-Real-IP is
+// used, and failing all of that the socket peer is the client.
+func (t TrustedProxies) Chain(r *http.Request) []Hop {
+	var forwarded, xff, realIP, via []Hop
+
+	for _, fe := range ParseForwarded(r.Header.Values("Forwarded")) {
+		forwarded = append(forwarded, t.newHop(fe.For.Raw, SourceForwarded))
+	}
+
+	// X-Forwarded-For can contain multiple IPs: "client, proxy1, proxy2",
+	// and may be split across several header lines
+	for _, line := range r.Header.Values("X-Forwarded-For") {
+		for _, entry := range strings.Split(line, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				xff = append(xff, t.newHop(entry, SourceXForwardedFor))
+			}
+		}
+	}
+
+	// X-Real-IP is set by nginx to the address it saw
+	if v := strings.TrimSpace(r.Header.Get("X-Real-IP")); v != "" {
+		realIP = append(realIP, t.newHop(v, SourceXRealIP))
+	}
+
+	// Via entries are "protocol received-by [comment]"; received-by is a
+	// host, pseudonym or address of each proxy, first proxy first
+	for _, line := range r.Header.Values("Via") {
+		for _, entry := range splitQuoted(line, ',') {
+			fields := strings.Fields(entry)
+			if len(fields) >= 2 {
+				via = append(via, t.newHop(fields[1], SourceVia))
+			}
+		}
+	}
+
+	socket := t.newHop(r.RemoteAddr, SourceSocket)
+	if !socket.Valid {
+		// Keep RemoteAddr visible even if it is not an IP (e.g. a Unix socket)
+		socket.IP = extractIP(r.RemoteAddr)
+	}
+
+	chain := make([]Hop, 0, len(forwarded)+len(xff)+len(realIP)+len(via)+1)
+	chain = append(chain, forwarded...)
+	chain = append(chain, xff...)
+	chain = append(chain, realIP...)
+	chain = append(chain, via...)
+	chain = append(chain, socket)
+
+	// Only the first non-empty forwarding source is used, and it starts the
+	// chain, so indexes into it are also indexes into chain
+	client := len(chain) - 1
+	if socket.Trusted {
+		switch {
+		case len(forwarded) > 0:
+			client = walkChain(forwarded, client)
+		case len(xff) > 0:
+			client = walkChain(xff, client)
+		case len(realIP) > 0 && realIP[0].Valid:
+			client = 0
+		}
+	}
+	chain[client].Client = true
+
+	return chain
+}
+
+// WithProxyPeer adjusts a chain built for a connection that arrived through
+// a PROXY protocol upstream. Chain saw the address from the PROXY header as
+// RemoteAddr, so that hop is relabelled, and the upstream itself (trusted,
+// since it was allowed to send the header) is appended as the socket peer.
+func WithProxyPeer(chain []Hop, peer string) []Hop {
+	if len(chain) == 0 {
+		return chain
+	}
+	chain[len(chain)-1].Source = SourceProxyProtocol
+
+	hop := Hop{Address: peer, Source: SourceSocket, Trusted: true}
+	if addr, ok := parseHopAddr(peer); ok {
+		hop.IP = addr.String()
+		hop.Valid = true
+	}
+	return append(chain, hop)
+}
+
+// ClientOf returns the IP of the hop in chain marked as the client.
+func ClientOf(chain []Hop) string {
+	for _, hop := range chain {
+		if hop.Client {
+			return hop.IP
+		}
+	}
+	return ""
+}
+
+// walkChain walks hops from right to left and returns the index of the
+// first untrusted valid hop, or of the last valid hop seen before an invalid
+// one. It returns fallback if the rightmost hop is invalid.
+func walkChain(hops []Hop, fallback int) int {
+	client := fallback
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !hops[i].Valid {
+			break
+		}
+		client = i
+		if !hops[i].Trusted {
+			break
+		}
+	}
+	return client
+}
+
+// newHop builds a hop from a raw address, which may be an IP, "IP:port" or
+// "[IPv6]:port".
+func (t TrustedProxies) newHop(address, source string) Hop {
+	hop := Hop{Address: address, Source: source}
+
+	if addr, ok := parseHopAddr(address); ok {
+		hop.IP = addr.String()
+		hop.Valid = true
+		hop.Trusted = t.Contains(addr)
+	}
+
+	return hop
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "127.0.0.1:51234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.50, 198.51.100.7")
+	req.Header.Set("X-Real-IP", "198.51.100.7")
+	req.Header.Set("Via", "1.1 cdn.example (Edge), 1.1 [2001:db8::2]:8080")
+
+	got := DefaultTrustedProxies.Chain(req)
+	want := []Hop{
+		{Address: "203.0.113.50", IP: "203.0.113.50", Source: SourceXForwardedFor, Valid: true},
+		{Address: "198.51.100.7", IP: "198.51.100.7", Source: SourceXForwardedFor, Valid: true, Client: true},
+		{Address: "198.51.100.7", IP: "198.51.100.7", Source: SourceXRealIP, Valid: true},
+		{Address: "cdn.example", Source: SourceVia},
+		{Address: "[2001:db8::2]:8080", IP: "2001:db8::2", Source: SourceVia, Valid: true},
+		{Address: "127.0.0.1:51234", IP: "127.0.0.1", Source: SourceSocket, Trusted: true, Valid: true},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Chain() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestChain_Forwarded(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "[::1]:51234"
+	req.Header.Set("Forwarded", `for="[2001:db8::1]:4711", for=_hidden, for=127.0.0.2`)
+
+	got := DefaultTrustedProxies.Chain(req)
+	want := []Hop{
+		{Address: "[2001:db8::1]:4711", IP: "2001:db8::1", Source: SourceForwarded, Valid: true},
+		{Address: "_hidden", Source: SourceForwarded},
+		{Address: "127.0.0.2", IP: "127.0.0.2", Source: SourceForwarded, Trusted: true, Valid: true, Client: true},
+		{Address: "[::1]:51234", IP: "::1", Source: SourceSocket, Trusted: true, Valid: true},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Chain() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestChain_UntrustedPeer(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.10:51234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.50")
+
+	chain := DefaultTrustedProxies.Chain(req)
+	if len(chain) != 2 {
+		t.Fatalf("Chain() returned %d hops, want 2: %+v", len(chain), chain)
+	}
+	if chain[0].Client || !chain[1].Client {
+		t.Errorf("the untrusted socket peer should be the client: %+v", chain)
+	}
+	if got := ClientOf(chain); got != "192.0.2.10" {
+		t.Errorf("ClientOf() = %q, want %q", got, "192.0.2.10")
+	}
+}
+
+func TestChain_NonIPRemoteAddr(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "@"
+
+	chain := DefaultTrustedProxies.Chain(req)
+	want := []Hop{{Address: "@", IP: "@", Source: SourceSocket, Client: true}}
+	if !reflect.DeepEqual(chain, want) {
+		t.Errorf("Chain() = %+v, want %+v", chain, want)
+	}
+}
+
+func TestWithProxyPeer(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.50:4711"
+
+	chain := WithProxyPeer(DefaultTrustedProxies.Chain(req), "127.0.0.1:51234")
+	want := []Hop{
+		{Address: "203.0.113.50:4711", IP: "203.0.113.50", Source: SourceProxyProtocol, Valid: true, Client: true},
+		{Address: "127.0.0.1:51234", IP: "127.0.0.1", Source: SourceSocket, Trusted: true, Valid: true},
+	}
+	if !reflect.DeepEqual(chain, want) {
+		t.Errorf("WithProxyPeer() =\n%+v\nwant\n%+v", chain, want)
+	}
+}
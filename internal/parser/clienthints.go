@@ -0,0 +1,263 @@
+package parser
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// User-Agent Client Hints request headers (https://wicg.github.io/ua-client-hints/).
+const (
+	HintUA              = "Sec-CH-UA"
+	HintMobile          = "Sec-CH-UA-Mobile"
+	HintPlatform        = "Sec-CH-UA-Platform"
+	HintPlatformVersion = "Sec-CH-UA-Platform-Version"
+	HintFullVersionList = "Sec-CH-UA-Full-Version-List"
+	HintArch            = "Sec-CH-UA-Arch"
+	HintModel           = "Sec-CH-UA-Model"
+	HintBitness         = "Sec-CH-UA-Bitness"
+)
+
+// ClientHintHeaders lists every hint header, low-entropy ones first.
+var ClientHintHeaders = []string{HintUA, HintMobile, HintPlatform, HintPlatformVersion, HintFullVersionList, HintArch, HintModel, HintBitness}
+
+// HighEntropyHints lists the hints a browser only sends once the server
+// asks for them with Accept-CH.
+var HighEntropyHints = []string{HintPlatformVersion, HintFullVersionList, HintArch, HintModel, HintBitness}
+
+// ClientHintsInfo holds the User-Agent Client Hints of a request as sent.
+// Hints that were not sent, or could not be parsed, are empty.
+type ClientHintsInfo struct {
+	Brands          []Brand // Sec-CH-UA, significant version only
+	FullVersionList []Brand // Sec-CH-UA-Full-Version-List
+	Mobile          *bool   // Sec-CH-UA-Mobile; nil if not sent
+	Platform        string  // Sec-CH-UA-Platform, e.g. "Windows"
+	PlatformVersion string  // Sec-CH-UA-Platform-Version, e.g. "15.0.0"
+	Arch            string  // Sec-CH-UA-Arch, e.g. "x86" or "arm"
+	Model           string  // Sec-CH-UA-Model, e.g. "Pixel 7"; empty on desktops
+	Bitness         string  // Sec-CH-UA-Bitness, e.g. "64"
+}
+
+// Brand is an entry of Sec-CH-UA or Sec-CH-UA-Full-Version-List.
+type Brand struct {
+	Name    string // e.g. "Google Chrome"
+	Version string // e.g. "120" or "120.0.6099.130"
+	GREASE  bool   // A made-up brand that keeps servers from relying on the list's format
+}
+
+// ParseClientHints parses the User-Agent Client Hints headers of a request.
+// It returns nil if none were sent.
+func ParseClientHints(h http.Header) *ClientHintsInfo {
+	sent := false
+	for _, name := range ClientHintHeaders {
+		if len(h.Values(name)) > 0 {
+			sent = true
+			break
+		}
+	}
+	if !sent {
+		return nil
+	}
+
+	value := func(name string) string {
+		return strings.Join(h.Values(name), ", ")
+	}
+	str := func(name string) string {
+		item, ok := parseSFItem(value(name))
+		if !ok {
+			return ""
+		}
+		s, _ := item.Value.(string)
+		return s
+	}
+
+	info := &ClientHintsInfo{
+		Brands:          parseBrands(value(HintUA)),
+		FullVersionList: parseBrands(value(HintFullVersionList)),
+		Platform:        str(HintPlatform),
+		PlatformVersion: str(HintPlatformVersion),
+		Arch:            str(HintArch),
+		Model:           str(HintModel),
+		Bitness:         str(HintBitness),
+	}
+	if item, ok := parseSFItem(value(HintMobile)); ok {
+		if mobile, ok := item.Value.(bool); ok {
+			info.Mobile = &mobile
+		}
+	}
+	return info
+}
+
+// parseBrands parses a brand list such as
+// `"Chromium";v="120", "Not_A Brand";v="8"`.
+func parseBrands(header string) []Brand {
+	list, ok := parseSFList(header)
+	if !ok {
+		return nil
+	}
+	var brands []Brand
+	for _, item := range list {
+		name, ok := item.Value.(string)
+		if !ok {
+			continue
+		}
+		version, _ := item.Param("v")
+		brands = append(brands, Brand{Name: name, Version: sfString(version), GREASE: isGREASEBrand(name)})
+	}
+	return brands
+}
+
+// isGREASEBrand reports whether name is one of the made-up brands Chromium
+// adds to its brand lists, such as "Not_A Brand" or "Not)A;Brand".
+func isGREASEBrand(name string) bool {
+	return strings.HasPrefix(name, "Not") && strings.HasSuffix(name, "Brand")
+}
+
+// Browser names used for brands in Client Hints, where they differ.
+var brandNames = map[string]string{
+	"Google Chrome":  "Chrome",
+	"HeadlessChrome": "Chrome",
+	"Microsoft Edge": "Edge",
+	"Opera GX":       "Opera",
+}
+
+// browser returns the browser the hints name and its version, and the
+// header they came from. Chromium is only chosen if no other brand is
+// listed, since every Chromium-based browser lists it.
+func (c *ClientHintsInfo) browser() (name, version, source string) {
+	brands, source := c.FullVersionList, HintFullVersionList
+	if len(brands) == 0 {
+		brands, source = c.Brands, HintUA
+	}
+	for _, b := range brands {
+		if b.GREASE {
+			continue
+		}
+		if name == "" || name == "Chromium" {
+			name, version = b.Name, b.Version
+		}
+	}
+	if name == "" {
+		return "", "", ""
+	}
+	if n, ok := brandNames[name]; ok {
+		name = n
+	}
+	return name, version, source
+}
+
+// headless reports whether the hints list headless Chrome's brand.
+func (c *ClientHintsInfo) headless() bool {
+	for _, brands := range [][]Brand{c.Brands, c.FullVersionList} {
+		for _, b := range brands {
+			if b.Name == "HeadlessChrome" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// windowsName returns the Windows release for a Sec-CH-UA-Platform-Version:
+// 13 and up is Windows 11, 1 to 10 is Windows 10, and 0 is an earlier
+// release. On Windows the platform version is the Windows.Foundation API
+// contract version rather than the version of Windows.
+func windowsName(platformVersion string) string {
+	major, _, _ := strings.Cut(platformVersion, ".")
+	n, err := strconv.Atoi(major)
+	switch {
+	case err != nil:
+		return "Windows"
+	case n >= 13:
+		return "Windows 11"
+	case n >= 1:
+		return "Windows 10"
+	}
+	return "Windows"
+}
+
+// Operating system names used for platforms in Client Hints, where they
+// differ.
+var platformNames = map[string]string{
+	"Chrome OS":   "ChromeOS",
+	"Chromium OS": "ChromeOS",
+	"Mac OS X":    "macOS",
+}
+
+// MergeClientHints fills in or replaces what the User-Agent string said with
+// what the hints say, recording the header each field now comes from. Hints
+// take precedence because Chromium freezes the User-Agent string: its
+// platform and version no longer change with the system. A nil h leaves u
+// unchanged.
+func (u *UserAgentInfo) MergeClientHints(h *ClientHintsInfo) {
+	if h == nil {
+		return
+	}
+	u.Hints = h
+
+	// Hints only name the browser of clients that claim to be one, so that
+	// a bot running Chromium is still reported as the bot
+	name, version, source := h.browser()
+	switch u.Category {
+	case CategoryBrowser, CategoryAutomation, CategoryUnknown:
+	default:
+		name = ""
+	}
+	if name != "" {
+		// A full version beats the User-Agent's; a major version only
+		// replaces it if the browser differs
+		if source == HintFullVersionList || name != u.BrowserName || u.BrowserVersion == "" {
+			u.BrowserVersion, u.Sources.BrowserVersion = version, source
+		}
+		u.BrowserName, u.Sources.Browser = name, source
+		u.Category, u.CLI, u.Parsed = CategoryBrowser, false, true
+		if h.headless() {
+			u.Category = CategoryAutomation
+		}
+	}
+
+	u.mergeEngine(h)
+
+	// The platform only replaces the User-Agent's OS if it is a different
+	// one, as the User-Agent may name the release, such as "Windows 8.1",
+	// or the Linux distribution
+	if platform := h.Platform; platform != "" && platform != "Unknown" {
+		if name, ok := platformNames[platform]; ok {
+			platform = name
+		}
+		if !strings.HasPrefix(u.OSName, platform) && !(platform == "Linux" && slices.Contains(linuxDistributions, u.OSName)) {
+			u.OSName, u.Sources.OS = platform, HintPlatform
+		}
+		u.Parsed = true
+	}
+	if h.PlatformVersion != "" {
+		// The hint names the Windows release, so a version from the
+		// User-Agent, such as uap-core's "10" for Windows NT 10.0, is stale
+		if strings.HasPrefix(u.OSName, "Windows") {
+			u.OSName, u.Sources.OS = windowsName(h.PlatformVersion), HintPlatformVersion
+			u.OSVersion, u.Sources.OSVersion = "", ""
+		} else {
+			u.OSVersion, u.Sources.OSVersion = h.PlatformVersion, HintPlatformVersion
+		}
+	}
+
+	if h.Mobile != nil {
+		u.Mobile, u.Sources.Mobile = *h.Mobile, HintMobile
+		if *h.Mobile && (u.Device == "" || u.Device == DeviceDesktop) {
+			u.Device, u.Sources.Device = DeviceMobile, HintMobile
+		}
+	}
+	if h.Arch != "" {
+		u.Architecture, u.Sources.Architecture = h.Arch, HintArch
+	}
+	if h.Bitness != "" {
+		u.Bitness, u.Sources.Bitness = h.Bitness, HintBitness
+	}
+	if h.Model != "" {
+		u.Model, u.Sources.Model = h.Model, HintModel
+		if vendor := androidVendor(h.Model); vendor != "" {
+			u.Vendor, u.Sources.Vendor = vendor, HintModel
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// chromeHints returns the hints Chrome 120 on Windows 11 sends once asked
+// for the high-entropy ones.
+func chromeHints() http.Header {
+	h := http.Header{}
+	h.Set("Sec-CH-UA", `"Not_A Brand";v="8", "Chromium";v="120", "Google Chrome";v="120"`)
+	h.Set("Sec-CH-UA-Mobile", "?0")
+	h.Set("Sec-CH-UA-Platform", `"Windows"`)
+	h.Set("Sec-CH-UA-Platform-Version", `"15.0.0"`)
+	h.Set("Sec-CH-UA-Full-Version-List", `"Not_A Brand";v="8.0.0.0", "Chromium";v="120.0.6099.130", "Google Chrome";v="120.0.6099.130"`)
+	h.Set("Sec-CH-UA-Arch", `"x86"`)
+	h.Set("Sec-CH-UA-Bitness", `"64"`)
+	h.Set("Sec-CH-UA-Model", `""`)
+	return h
+}
+
+func TestParseClientHints(t *testing.T) {
+	hints := ParseClientHints(chromeHints())
+	if hints == nil {
+		t.Fatal("ParseClientHints() = nil")
+	}
+
+	wantBrands := []Brand{{"Not_A Brand", "8", true}, {"Chromium", "120", false}, {"Google Chrome", "120", false}}
+	if !slices.Equal(hints.Brands, wantBrands) {
+		t.Errorf("Brands = %v, want %v", hints.Brands, wantBrands)
+	}
+	if len(hints.FullVersionList) != 3 || hints.FullVersionList[2].Version != "120.0.6099.130" {
+		t.Errorf("FullVersionList = %v", hints.FullVersionList)
+	}
+	if hints.Mobile == nil || *hints.Mobile {
+		t.Errorf("Mobile = %v, want false", hints.Mobile)
+	}
+	if hints.Platform != "Windows" || hints.PlatformVersion != "15.0.0" || hints.Arch != "x86" || hints.Bitness != "64" || hints.Model != "" {
+		t.Errorf("hints = %+v", hints)
+	}
+
+	if hints := ParseClientHints(http.Header{"Accept": {"*/*"}}); hints != nil {
+		t.Errorf("ParseClientHints() = %+v without hints, want nil", hints)
+	}
+
+	// Malformed hints are left empty
+	h := http.Header{}
+	h.Set("Sec-CH-UA", `"Chromium";v="120",`)
+	h.Set("Sec-CH-UA-Mobile", `"yes"`)
+	h.Set("Sec-CH-UA-Platform", "Windows")
+	if hints := ParseClientHints(h); hints == nil || hints.Brands != nil || hints.Mobile != nil || hints.Platform != "" {
+		t.Errorf("ParseClientHints() = %+v, want empty hints", hints)
+	}
+}
+
+func TestMergeClientHints(t *testing.T) {
+	ua := ParseUserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	ua.MergeClientHints(ParseClientHints(chromeHints()))
+
+	if ua.BrowserName != "Chrome" || ua.BrowserVersion != "120.0.6099.130" || ua.OSName != "Windows 11" {
+		t.Errorf("browser, version, OS = %q, %q, %q", ua.BrowserName, ua.BrowserVersion, ua.OSName)
+	}
+	if ua.Architecture != "x86" || ua.Bitness != "64" || ua.Mobile || ua.Model != "" || ua.OSVersion != "" {
+		t.Errorf("ua = %+v", ua)
+	}
+	want := UserAgentSources{
+		Browser:        HintFullVersionList,
+		BrowserVersion: HintFullVersionList,
+		Engine:         HintFullVersionList,
+		OS:             HintPlatformVersion,
+		Mobile:         HintMobile,
+		Device:         SourceUserAgent,
+		Architecture:   HintArch,
+		Bitness:        HintBitness,
+	}
+	if ua.Sources != want {
+		t.Errorf("Sources = %+v, want %+v", ua.Sources, want)
+	}
+	if ua.Hints == nil {
+		t.Error("Hints = nil, want the hints as sent")
+	}
+}
+
+func TestMergeClientHints_LowEntropy(t *testing.T) {
+	h := http.Header{}
+	h.Set("Sec-CH-UA", `"Microsoft Edge";v="121", "Chromium";v="121", "Not A(Brand";v="99"`)
+	h.Set("Sec-CH-UA-Mobile", "?1")
+	h.Set("Sec-CH-UA-Platform", `"Android"`)
+
+	ua := ParseUserAgent("Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Mobile Safari/537.36")
+	ua.MergeClientHints(ParseClientHints(h))
+
+	// Edge sends a User-Agent that names only Chrome
+	if ua.BrowserName != "Edge" || ua.BrowserVersion != "121" || ua.Sources.BrowserVersion != HintUA {
+		t.Errorf("browser, version = %q, %q (from %s); want Edge 121 from Sec-CH-UA", ua.BrowserName, ua.BrowserVersion, ua.Sources.BrowserVersion)
+	}
+	if ua.OSName != "Android" || ua.Sources.OS != SourceUserAgent {
+		t.Errorf("OS = %q from %s, want Android from the User-Agent", ua.OSName, ua.Sources.OS)
+	}
+	if !ua.Mobile {
+		t.Error("Mobile = false, want true")
+	}
+
+	// Chrome's own version from the User-Agent is kept when the hints
+	// only give the major version
+	h.Set("Sec-CH-UA", `"Google Chrome";v="121", "Chromium";v="121"`)
+	ua = ParseUserAgent("Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Mobile Safari/537.36")
+	ua.MergeClientHints(ParseClientHints(h))
+	if ua.BrowserVersion != "121.0.0.0" || ua.Sources.BrowserVersion != SourceUserAgent {
+		t.Errorf("version = %q from %s, want 121.0.0.0 from the User-Agent", ua.BrowserVersion, ua.Sources.BrowserVersion)
+	}
+}
+
+func TestMergeClientHints_LinuxDistribution(t *testing.T) {
+	ua := ParseUserAgent("Mozilla/5.0 (X11; Ubuntu; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	ua.MergeClientHints(&ClientHintsInfo{Platform: "Linux", Arch: "x86", Bitness: "64"})
+
+	if ua.OSName != "Ubuntu" || ua.Sources.OS != SourceUserAgent {
+		t.Errorf("OS = %q from %q, want Ubuntu from the User-Agent", ua.OSName, ua.Sources.OS)
+	}
+	if ua.Architecture != "x86" || ua.Sources.Architecture != HintArch {
+		t.Errorf("Architecture = %q from %q, want x86 from %s", ua.Architecture, ua.Sources.Architecture, HintArch)
+	}
+}
+
+func TestMergeClientHints_WindowsRelease(t *testing.T) {
+	// Rules such as uap-core's report Windows NT 10.0 as Windows 10
+	ua := UserAgentInfo{OSName: "Windows", OSVersion: "10"}
+	ua.Sources.OS, ua.Sources.OSVersion = SourceUserAgent, SourceUserAgent
+	ua.MergeClientHints(&ClientHintsInfo{Platform: "Windows", PlatformVersion: "15.0.0"})
+
+	if ua.OSName != "Windows 11" || ua.Sources.OS != HintPlatformVersion {
+		t.Errorf("OS = %q from %q, want Windows 11 from %s", ua.OSName, ua.Sources.OS, HintPlatformVersion)
+	}
+	if ua.OSVersion != "" || ua.Sources.OSVersion != "" {
+		t.Errorf("OSVersion = %q from %q, want none", ua.OSVersion, ua.Sources.OSVersion)
+	}
+}
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits that cookies are checked against. Browsers store cookies of up to
+// 4096 bytes and at least 50 per domain (RFC 6265, section 6.1); many
+// servers reject header lines longer than 8190 bytes.
+const (
+	MaxCookieSize   = 4096
+	MaxCookieCount  = 50
+	MaxCookieHeader = 8190
+)
+
+// Cookie is one name=value pair from a Cookie header.
+type Cookie struct {
+	Name      string
+	Value     string // As sent, including any quotes
+	Size      int    // Bytes in the name and value, which browsers limit
+	Duplicate bool   // Another cookie in the request has the same name
+	Encoding  string // "jwt", "url" or "base64" if the value decodes as one; empty otherwise
+	Decoded   string // URL- or base64-decoded value
+	JWTHeader string // Decoded JSON header of a JWT
+	JWTClaims string // Decoded JSON claims of a JWT; the signature is not checked
+}
+
+// CookieInfo holds the cookies of a request.
+type CookieInfo struct {
+	Cookies  []Cookie // In the order sent
+	Size     int      // Bytes in the Cookie header values
+	Warnings []string // Limits exceeded and duplicate names
+}
+
+// ParseCookies splits the values of the Cookie headers into cookies and
+// decodes their values. Pairs without "=" are cookies with an empty name,
+// as browsers treat them.
+func ParseCookies(values []string) CookieInfo {
+	info := CookieInfo{Cookies: []Cookie{}, Warnings: []string{}}
+	counts := map[string]int{}
+
+	for _, value := range values {
+		info.Size += len(value)
+		for _, pair := range strings.Split(value, ";") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			name, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				name, val = "", pair
+			}
+			c := Cookie{Name: strings.TrimSpace(name), Value: strings.TrimSpace(val)}
+			c.Size = len(c.Name) + len(c.Value)
+			decodeCookie(&c)
+			info.Cookies = append(info.Cookies, c)
+			counts[c.Name]++
+		}
+	}
+
+	warned := map[string]bool{}
+	for i, c := range info.Cookies {
+		info.Cookies[i].Duplicate = counts[c.Name] > 1
+		if c.Size > MaxCookieSize {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("%q is %d bytes; browsers drop cookies over %d bytes", c.Name, c.Size, MaxCookieSize))
+		}
+		if n := counts[c.Name]; n > 1 && !warned[c.Name] {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("%q is sent %d times, usually for different paths or domains", c.Name, n))
+			warned[c.Name] = true
+		}
+	}
+	if n := len(info.Cookies); n > MaxCookieCount {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("%d cookies; browsers need only keep %d per domain", n, MaxCookieCount))
+	}
+	if info.Size > MaxCookieHeader {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("cookies total %d bytes; many servers reject headers over %d bytes", info.Size, MaxCookieHeader))
+	}
+	return info
+}
+
+// decodeCookie recognizes a JWT, a URL-encoded value or base64 text.
+func decodeCookie(c *Cookie) {
+	v := strings.Trim(c.Value, `"`)
+
+	if header, claims, ok := decodeJWT(v); ok {
+		c.Encoding, c.JWTHeader, c.JWTClaims = "jwt", header, claims
+		return
+	}
+	if strings.Contains(v, "%") {
+		if decoded, err := url.PathUnescape(v); err == nil && decoded != v && utf8.ValidString(decoded) {
+			c.Encoding, c.Decoded = "url", decoded
+			return
+		}
+	}
+	if decoded, ok := decodeBase64Text(v); ok {
+		c.Encoding, c.Decoded = "base64", decoded
+	}
+}
+
+// decodeJWT decodes the header and claims of a compact JWS whose header is a
+// JSON object with an "alg" member.
+func decodeJWT(v string) (string, string, bool) {
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return "", "", false
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", "", false
+	}
+	var h map[string]any
+	if json.Unmarshal(header, &h) != nil || h["alg"] == nil {
+		return "", "", false
+	}
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !json.Valid(claims) {
+		return "", "", false
+	}
+	return string(header), string(claims), true
+}
+
+// decodeBase64Text decodes v if it is base64 in any common alphabet and
+// padding, and the result is printable text. Short values are left alone,
+// as ordinary words are often valid base64.
+func decodeBase64Text(v string) (string, bool) {
+	if len(v) < 8 {
+		return "", false
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		b, err := enc.DecodeString(v)
+		if err != nil {
+			continue
+		}
+		s := string(b)
+		if !utf8.ValidString(s) || strings.IndexFunc(s, func(r rune) bool { return !unicode.IsPrint(r) && !unicode.IsSpace(r) }) >= 0 {
+			return "", false
+		}
+		return s, true
+	}
+	return "", false
+}
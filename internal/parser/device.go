@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Device classes, as reported in UserAgentInfo.Device.
+const (
+	DeviceDesktop  = "desktop"
+	DeviceMobile   = "mobile"
+	DeviceTablet   = "tablet"
+	DeviceTV       = "tv" // Smart TVs and streaming devices
+	DeviceConsole  = "console"
+	DeviceWearable = "wearable"
+	DeviceEReader  = "e-reader"
+	DeviceCar      = "car"
+)
+
+// device is a pattern for a device the User-Agent names. The model is the
+// pattern's first group if it has one, or model otherwise; if neither is
+// set, an Android device's model is taken from its User-Agent.
+type device struct {
+	class   string
+	vendor  string
+	model   string
+	pattern *regexp.Regexp
+}
+
+// Device patterns - order matters, check more specific patterns first
+var devicePatterns = []device{
+	// Consoles
+	{DeviceConsole, "Sony", "", regexp.MustCompile(`(PlayStation (?:5|4|3|Vita|Portable))`)},
+	{DeviceConsole, "Microsoft", "", regexp.MustCompile(`.*\b(Xbox(?: One| Series [XS])?)`)}, // The last token names the model
+	{DeviceConsole, "Nintendo", "", regexp.MustCompile(`Nintendo (Switch|WiiU|Wii|3DS)`)},
+
+	// TVs and streaming devices
+	{DeviceTV, "Amazon", "Fire TV", regexp.MustCompile(`\bAFT[A-Z0-9]+\b`)},
+	{DeviceTV, "Apple", "Apple TV", regexp.MustCompile(`AppleTV`)},
+	{DeviceTV, "Google", "Chromecast", regexp.MustCompile(`CrKey`)},
+	{DeviceTV, "Roku", "", regexp.MustCompile(`Roku`)},
+	{DeviceTV, "Samsung", "", regexp.MustCompile(`SMART-TV.*Tizen|Tizen.*TV`)},
+	{DeviceTV, "LG", "", regexp.MustCompile(`Web0S|webOS\.TV`)},
+	{DeviceTV, "Sony", "BRAVIA", regexp.MustCompile(`BRAVIA`)},
+	{DeviceTV, "", "", regexp.MustCompile(`SmartTV|SMART-TV|HbbTV|GoogleTV|Android TV`)},
+
+	// E-readers; Kindle Fire tablets name their model code instead
+	{DeviceTablet, "Amazon", "", regexp.MustCompile(`\b(KF[A-Z]{2,4})\b`)},
+	{DeviceEReader, "Amazon", "Kindle", regexp.MustCompile(`Kindle`)},
+	{DeviceEReader, "Kobo", "", regexp.MustCompile(`Kobo`)},
+	{DeviceEReader, "PocketBook", "", regexp.MustCompile(`PocketBook`)},
+	{DeviceEReader, "Tolino", "", regexp.MustCompile(`tolino`)},
+
+	// Wearables
+	{DeviceWearable, "Apple", "Apple Watch", regexp.MustCompile(`Watch OS|watchOS|AppleWatch`)},
+	{DeviceWearable, "Samsung", "", regexp.MustCompile(`\b(SM-R\d+\w*)`)},
+	{DeviceWearable, "", "", regexp.MustCompile(`Wear ?OS|Android Wear`)},
+
+	// Cars
+	{DeviceCar, "Tesla", "", regexp.MustCompile(`Tesla|QtCarBrowser`)},
+
+	// Apple devices; iPads on iPadOS 13 and later claim to be a Mac
+	{DeviceTablet, "Apple", "iPad", regexp.MustCompile(`iPad`)},
+	{DeviceMobile, "Apple", "iPhone", regexp.MustCompile(`iPhone`)},
+	{DeviceMobile, "Apple", "iPod touch", regexp.MustCompile(`iPod`)},
+
+	{DeviceMobile, "Microsoft", "", regexp.MustCompile(`Windows Phone|IEMobile`)},
+}
+
+// androidModelPattern matches the model in an Android User-Agent, such as
+// "Pixel 8" in "(Linux; Android 14; Pixel 8)" or "LG-L160L" in
+// "(Linux; U; Android 4.0.3; ko-kr; LG-L160L Build/IML74K)".
+var androidModelPattern = regexp.MustCompile(`Android[^;)]*;(?: [a-z]{2,3}[-_][A-Za-z]{2,4};)? ([^;)]+?)(?: Build/[^;)]*)?[;)]`)
+
+// Android device vendors, by the prefix of their model names.
+var androidVendors = []struct {
+	prefix string
+	vendor string
+}{
+	{"SM-", "Samsung"},
+	{"GT-", "Samsung"},
+	{"Galaxy", "Samsung"},
+	{"Pixel", "Google"},
+	{"Nexus", "Google"},
+	{"Redmi", "Xiaomi"},
+	{"POCO", "Xiaomi"},
+	{"Mi ", "Xiaomi"},
+	{"Xiaomi", "Xiaomi"},
+	{"moto", "Motorola"},
+	{"Moto", "Motorola"},
+	{"ONEPLUS", "OnePlus"},
+	{"OnePlus", "OnePlus"},
+	{"CPH", "OPPO"},
+	{"OPPO", "OPPO"},
+	{"RMX", "realme"},
+	{"vivo", "vivo"},
+	{"HUAWEI", "Huawei"},
+	{"LG-", "LG"},
+	{"LM-", "LG"},
+	{"Nokia", "Nokia"},
+	{"SHIELD", "NVIDIA"},
+}
+
+// androidVendor returns the vendor of an Android device model, or an empty
+// string if it is unknown.
+func androidVendor(model string) string {
+	for _, v := range androidVendors {
+		if strings.HasPrefix(model, v.prefix) {
+			return v.vendor
+		}
+	}
+	return ""
+}
+
+// androidModel returns the model an Android User-Agent names, or an empty
+// string. Chrome's reduced User-Agent names every model "K".
+func androidModel(ua string) string {
+	matches := androidModelPattern.FindStringSubmatch(ua)
+	if matches == nil || matches[1] == "K" {
+		return ""
+	}
+	model := strings.TrimPrefix(matches[1], "SAMSUNG ")
+	if strings.HasPrefix(model, "Android ") || strings.Contains(model, "Linux") {
+		return ""
+	}
+	return model
+}
+
+// detectDevice fills in the device class, vendor and model the User-Agent
+// names. Automated clients are skipped, as their User-Agents often imitate
+// a phone or a desktop browser. The vendor and model of a matching device
+// rule replace the built-in ones.
+func detectDevice(ua string, info *UserAgentInfo, rules []rule) {
+	switch info.Category {
+	case CategoryBrowser, CategoryAutomation, CategoryUnknown:
+	default:
+		return
+	}
+
+	class, vendor, model := "", "", ""
+	for _, d := range devicePatterns {
+		matches := d.pattern.FindStringSubmatch(ua)
+		if matches == nil {
+			continue
+		}
+		class, vendor, model = d.class, d.vendor, d.model
+		if len(matches) > 1 {
+			model = matches[1]
+		}
+		break
+	}
+
+	android := strings.Contains(ua, "Android")
+	if android && model == "" {
+		model = androidModel(ua)
+	}
+	if android && vendor == "" {
+		vendor = androidVendor(model)
+	}
+
+	switch {
+	case class != "":
+	case android:
+		// Android phones say "Mobile"; tablets do not
+		class = DeviceTablet
+		if strings.Contains(ua, "Mobile") && !strings.HasPrefix(model, "SM-T") && !strings.HasPrefix(model, "SM-X") {
+			class = DeviceMobile
+		}
+	case strings.Contains(ua, "Macintosh"):
+		class, vendor, model = DeviceDesktop, "Apple", "Mac"
+	case strings.Contains(ua, "Windows") || strings.Contains(ua, "X11") || strings.Contains(ua, "CrOS"):
+		class = DeviceDesktop
+	case strings.Contains(ua, "Mobile"):
+		class = DeviceMobile
+	}
+
+	// uap-core's rules name devices they cannot identify "Generic"
+	if fields := match(rules, ua); fields != nil && fields[1] != "Generic" {
+		if fields[1] != "" {
+			vendor = fields[1]
+		}
+		if fields[2] != "" {
+			model = fields[2]
+		}
+	}
+	if class == "" && vendor == "" && model == "" {
+		return
+	}
+
+	if class != "" {
+		info.Device, info.Sources.Device = class, SourceUserAgent
+		info.Mobile, info.Sources.Mobile = class == DeviceMobile, SourceUserAgent
+	}
+	if vendor != "" {
+		info.Vendor, info.Sources.Vendor = vendor, SourceUserAgent
+	}
+	if model != "" {
+		info.Model, info.Sources.Model = model, SourceUserAgent
+	}
+	info.Parsed = true
+}
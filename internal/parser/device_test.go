@@ -0,0 +1,167 @@
+package parser
+
+import "testing"
+
+func TestParseUserAgent_Device(t *testing.T) {
+	tests := []struct {
+		name       string
+		ua         string
+		wantDevice string
+		wantVendor string
+		wantModel  string
+	}{
+		{
+			name:       "Samsung Galaxy",
+			ua:         "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			wantDevice: DeviceMobile,
+			wantVendor: "Samsung",
+			wantModel:  "SM-S918B",
+		},
+		{
+			name:       "Samsung Internet",
+			ua:         "Mozilla/5.0 (Linux; Android 13; SAMSUNG SM-A536B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			wantDevice: DeviceMobile,
+			wantVendor: "Samsung",
+			wantModel:  "SM-A536B",
+		},
+		{
+			name:       "Samsung tablet",
+			ua:         "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			wantDevice: DeviceTablet,
+			wantVendor: "Samsung",
+			wantModel:  "SM-X700",
+		},
+		{
+			name:       "Pixel",
+			ua:         "Mozilla/5.0 (Linux; Android 14; Pixel 8 Pro) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.43 Mobile Safari/537.36",
+			wantDevice: DeviceMobile,
+			wantVendor: "Google",
+			wantModel:  "Pixel 8 Pro",
+		},
+		{
+			name:       "Old Android with build",
+			ua:         "Mozilla/5.0 (Linux; U; Android 4.0.3; ko-kr; LG-L160L Build/IML74K) AppleWebKit/534.30 (KHTML, like Gecko) Version/4.0 Mobile Safari/534.30",
+			wantDevice: DeviceMobile,
+			wantVendor: "LG",
+			wantModel:  "LG-L160L",
+		},
+		{
+			name:       "Reduced Android",
+			ua:         "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			wantDevice: DeviceMobile,
+		},
+		{
+			name:       "iPhone",
+			ua:         "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			wantDevice: DeviceMobile,
+			wantVendor: "Apple",
+			wantModel:  "iPhone",
+		},
+		{
+			name:       "iPad",
+			ua:         "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			wantDevice: DeviceTablet,
+			wantVendor: "Apple",
+			wantModel:  "iPad",
+		},
+		{
+			name:       "Mac",
+			ua:         "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			wantDevice: DeviceDesktop,
+			wantVendor: "Apple",
+			wantModel:  "Mac",
+		},
+		{
+			name:       "Windows",
+			ua:         "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			wantDevice: DeviceDesktop,
+		},
+		{
+			name:       "Kindle",
+			ua:         "Mozilla/5.0 (X11; U; Linux armv7l like Android; en-us) AppleWebKit/531.2+ (KHTML, like Gecko) Version/5.0 Safari/531.2+ Kindle/3.0+",
+			wantDevice: DeviceEReader,
+			wantVendor: "Amazon",
+			wantModel:  "Kindle",
+		},
+		{
+			name:       "Fire tablet",
+			ua:         "Mozilla/5.0 (Linux; Android 9; KFTRWI) AppleWebKit/537.36 (KHTML, like Gecko) Silk/120.3.1 like Chrome/120.0.6099.116 Safari/537.36",
+			wantDevice: DeviceTablet,
+			wantVendor: "Amazon",
+			wantModel:  "KFTRWI",
+		},
+		{
+			name:       "PlayStation",
+			ua:         "Mozilla/5.0 (PlayStation; PlayStation 5/2.26) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.0 Safari/605.1.15",
+			wantDevice: DeviceConsole,
+			wantVendor: "Sony",
+			wantModel:  "PlayStation 5",
+		},
+		{
+			name:       "Xbox",
+			ua:         "Mozilla/5.0 (Windows NT 10.0; Win64; x64; Xbox; Xbox Series X) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edge/20.02",
+			wantDevice: DeviceConsole,
+			wantVendor: "Microsoft",
+			wantModel:  "Xbox Series X",
+		},
+		{
+			name:       "Samsung TV",
+			ua:         "Mozilla/5.0 (SMART-TV; LINUX; Tizen 6.0) AppleWebKit/537.36 (KHTML, like Gecko) 76.0.3809.146/6.0 TV Safari/537.36",
+			wantDevice: DeviceTV,
+			wantVendor: "Samsung",
+		},
+		{
+			name:       "Fire TV",
+			ua:         "Mozilla/5.0 (Linux; Android 9; AFTMM Build/PS7233) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			wantDevice: DeviceTV,
+			wantVendor: "Amazon",
+			wantModel:  "Fire TV",
+		},
+		{
+			name:       "Galaxy Watch",
+			ua:         "Mozilla/5.0 (Linux; Android 11; SM-R890) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/1.0 Chrome/99.0.4844.88 Mobile Safari/537.36",
+			wantDevice: DeviceWearable,
+			wantVendor: "Samsung",
+			wantModel:  "SM-R890",
+		},
+		{
+			name:       "Tesla",
+			ua:         "Mozilla/5.0 (X11; GNU/Linux) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.5414.119 Safari/537.36 Tesla/2023.44.30",
+			wantDevice: DeviceCar,
+			wantVendor: "Tesla",
+		},
+		{
+			name: "Bot imitating a phone",
+			ua:   "Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.216 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		},
+		{
+			name: "curl",
+			ua:   "curl/8.4.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseUserAgent(tt.ua)
+			if result.Device != tt.wantDevice || result.Vendor != tt.wantVendor || result.Model != tt.wantModel {
+				t.Errorf("device = %q, %q, %q; want %q, %q, %q", result.Device, result.Vendor, result.Model, tt.wantDevice, tt.wantVendor, tt.wantModel)
+			}
+			if result.Mobile != (tt.wantDevice == DeviceMobile) {
+				t.Errorf("Mobile = %v for device %q", result.Mobile, tt.wantDevice)
+			}
+		})
+	}
+}
+
+func TestMergeClientHints_Device(t *testing.T) {
+	mobile := true
+	ua := ParseUserAgent("Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36")
+	ua.MergeClientHints(&ClientHintsInfo{Mobile: &mobile, Platform: "Android", Model: "Pixel 7"})
+
+	if ua.Device != DeviceMobile || ua.Vendor != "Google" || ua.Model != "Pixel 7" {
+		t.Errorf("device = %q, %q, %q; want mobile, Google, Pixel 7", ua.Device, ua.Vendor, ua.Model)
+	}
+	if ua.Sources.Device != SourceUserAgent || ua.Sources.Vendor != HintModel || ua.Sources.Model != HintModel {
+		t.Errorf("Sources = %+v", ua.Sources)
+	}
+}
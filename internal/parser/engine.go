@@ -0,0 +1,59 @@
+package parser
+
+import "regexp"
+
+// Engine patterns - order matters: browsers built on an engine still name
+// the ones they descend from, as Blink-based browsers send AppleWebKit and
+// "like Gecko". The pattern's first group is the engine version.
+var enginePatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"Trident", regexp.MustCompile(`Trident/(\d+(?:\.\d+)*)`)},
+	{"Presto", regexp.MustCompile(`Presto/(\d+(?:\.\d+)*)`)},
+	{"EdgeHTML", regexp.MustCompile(`AppleWebKit/.*Chrome/.*\bEdge/(\d+(?:\.\d+)*)`)},
+
+	// Blink's version is that of the Chromium it ships in
+	{"Blink", regexp.MustCompile(`AppleWebKit/.*Chrome/(\d+(?:\.\d+)*)`)},
+	{"WebKit", regexp.MustCompile(`AppleWebKit/(\d+(?:\.\d+)*)`)},
+	{"Gecko", regexp.MustCompile(`rv:(\d+(?:\.\d+)*)\) Gecko/\d`)},
+}
+
+// detectEngine fills in the rendering engine the User-Agent names.
+func detectEngine(ua string, info *UserAgentInfo) {
+	for _, ep := range enginePatterns {
+		if matches := ep.pattern.FindStringSubmatch(ua); matches != nil {
+			info.Engine, info.EngineVersion = ep.name, matches[1]
+			info.Sources.Engine = SourceUserAgent
+			return
+		}
+	}
+}
+
+// engine returns Blink and the Chromium version if the hints list
+// Chromium, which every Blink-based browser does.
+func (c *ClientHintsInfo) engine() (name, version, source string) {
+	for _, list := range []struct {
+		brands []Brand
+		source string
+	}{{c.FullVersionList, HintFullVersionList}, {c.Brands, HintUA}} {
+		for _, b := range list.brands {
+			if b.Name == "Chromium" {
+				return "Blink", b.Version, list.source
+			}
+		}
+	}
+	return "", "", ""
+}
+
+// mergeEngine sets the engine from the hints. As for the browser, a major
+// version only replaces the User-Agent's if the engine differs.
+func (u *UserAgentInfo) mergeEngine(h *ClientHintsInfo) {
+	name, version, source := h.engine()
+	if name == "" {
+		return
+	}
+	if source == HintFullVersionList || name != u.Engine || u.EngineVersion == "" {
+		u.Engine, u.EngineVersion, u.Sources.Engine = name, version, source
+	}
+}
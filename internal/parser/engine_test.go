@@ -0,0 +1,192 @@
+package parser
+
+import "testing"
+
+func TestParseUserAgent_BrowserAndEngine(t *testing.T) {
+	tests := []struct {
+		name              string
+		ua                string
+		wantBrowser       string
+		wantVersion       string
+		wantEngine        string
+		wantEngineVersion string
+	}{
+		{
+			name:              "Chrome",
+			ua:                "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.130 Safari/537.36",
+			wantBrowser:       "Chrome",
+			wantVersion:       "120.0.6099.130",
+			wantEngine:        "Blink",
+			wantEngineVersion: "120.0.6099.130",
+		},
+		{
+			name:              "Firefox",
+			ua:                "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			wantBrowser:       "Firefox",
+			wantVersion:       "121.0",
+			wantEngine:        "Gecko",
+			wantEngineVersion: "121.0",
+		},
+		{
+			name:              "Safari",
+			ua:                "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2.1 Safari/605.1.15",
+			wantBrowser:       "Safari",
+			wantVersion:       "17.2.1",
+			wantEngine:        "WebKit",
+			wantEngineVersion: "605.1.15",
+		},
+		{
+			name:              "Internet Explorer 11",
+			ua:                "Mozilla/5.0 (Windows NT 10.0; WOW64; Trident/7.0; rv:11.0) like Gecko",
+			wantBrowser:       "Internet Explorer",
+			wantVersion:       "11.0",
+			wantEngine:        "Trident",
+			wantEngineVersion: "7.0",
+		},
+		{
+			name:              "Presto Opera",
+			ua:                "Opera/9.80 (Windows NT 6.1; WOW64) Presto/2.12.388 Version/12.16",
+			wantBrowser:       "Opera",
+			wantVersion:       "12.16",
+			wantEngine:        "Presto",
+			wantEngineVersion: "2.12.388",
+		},
+		{
+			name:              "Legacy Edge",
+			ua:                "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/70.0.3538.102 Safari/537.36 Edge/18.19582",
+			wantBrowser:       "Edge",
+			wantVersion:       "18.19582",
+			wantEngine:        "EdgeHTML",
+			wantEngineVersion: "18.19582",
+		},
+		{
+			name:              "Samsung Internet",
+			ua:                "Mozilla/5.0 (Linux; Android 13; SAMSUNG SM-A536B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			wantBrowser:       "Samsung Internet",
+			wantVersion:       "23.0",
+			wantEngine:        "Blink",
+			wantEngineVersion: "115.0.0.0",
+		},
+		{
+			name:              "Vivaldi",
+			ua:                "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Vivaldi/6.5.3206.48",
+			wantBrowser:       "Vivaldi",
+			wantVersion:       "6.5.3206.48",
+			wantEngine:        "Blink",
+			wantEngineVersion: "120.0.0.0",
+		},
+		{
+			name:              "Yandex",
+			ua:                "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 YaBrowser/23.11.0.0 Safari/537.36",
+			wantBrowser:       "Yandex",
+			wantVersion:       "23.11.0.0",
+			wantEngine:        "Blink",
+			wantEngineVersion: "118.0.0.0",
+		},
+		{
+			name:              "UC Browser",
+			ua:                "Mozilla/5.0 (Linux; U; Android 10; en-US; RMX1911 Build/QKQ1.200209.002) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/78.0.3904.108 UCBrowser/13.4.0.1306 Mobile Safari/537.36",
+			wantBrowser:       "UC Browser",
+			wantVersion:       "13.4.0.1306",
+			wantEngine:        "Blink",
+			wantEngineVersion: "78.0.3904.108",
+		},
+		{
+			name:              "DuckDuckGo",
+			ua:                "Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/120.0.6099.144 Mobile DuckDuckGo/5 Safari/537.36",
+			wantBrowser:       "DuckDuckGo",
+			wantVersion:       "5",
+			wantEngine:        "Blink",
+			wantEngineVersion: "120.0.6099.144",
+		},
+		{
+			name:              "Chrome on iOS",
+			ua:                "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			wantBrowser:       "Chrome",
+			wantVersion:       "120.0.6099.119",
+			wantEngine:        "WebKit",
+			wantEngineVersion: "605.1.15",
+		},
+		{
+			name:              "Firefox on iOS",
+			ua:                "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) FxiOS/121.0 Mobile/15E148 Safari/605.1.15",
+			wantBrowser:       "Firefox",
+			wantVersion:       "121.0",
+			wantEngine:        "WebKit",
+			wantEngineVersion: "605.1.15",
+		},
+		{
+			name:              "Electron",
+			ua:                "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Slack/4.36.140 Chrome/120.0.6099.56 Electron/28.0.0 Safari/537.36",
+			wantBrowser:       "Electron",
+			wantVersion:       "28.0.0",
+			wantEngine:        "Blink",
+			wantEngineVersion: "120.0.6099.56",
+		},
+		{
+			name:              "Instagram",
+			ua:                "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Instagram 312.1.3.26.100 (iPhone15,3; iOS 17_2; en_US; en; scale=3.00; 1290x2796; 551128245)",
+			wantBrowser:       "Instagram",
+			wantVersion:       "312.1.3.26.100",
+			wantEngine:        "WebKit",
+			wantEngineVersion: "605.1.15",
+		},
+		{
+			name:              "Facebook",
+			ua:                "Mozilla/5.0 (Linux; Android 14; Pixel 8 Build/UD1A.231105.004; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/120.0.6099.144 Mobile Safari/537.36 [FB_IAB/FB4A;FBAV/444.0.0.34.116;]",
+			wantBrowser:       "Facebook",
+			wantVersion:       "444.0.0.34.116",
+			wantEngine:        "Blink",
+			wantEngineVersion: "120.0.6099.144",
+		},
+		{
+			name:              "LinkedIn",
+			ua:                "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 [LinkedInApp]/9.29.1234",
+			wantBrowser:       "LinkedIn",
+			wantEngine:        "WebKit",
+			wantEngineVersion: "605.1.15",
+		},
+		{
+			name:              "Android WebView",
+			ua:                "Mozilla/5.0 (Linux; Android 14; Pixel 8 Build/UD1A.231105.004; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/120.0.6099.144 Mobile Safari/537.36",
+			wantBrowser:       "Android WebView",
+			wantVersion:       "120.0.6099.144",
+			wantEngine:        "Blink",
+			wantEngineVersion: "120.0.6099.144",
+		},
+		{
+			name:        "curl",
+			ua:          "curl/8.4.0",
+			wantBrowser: "curl",
+			wantVersion: "8.4.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseUserAgent(tt.ua)
+			if result.BrowserName != tt.wantBrowser || result.BrowserVersion != tt.wantVersion {
+				t.Errorf("browser = %q %q, want %q %q", result.BrowserName, result.BrowserVersion, tt.wantBrowser, tt.wantVersion)
+			}
+			if result.Engine != tt.wantEngine || result.EngineVersion != tt.wantEngineVersion {
+				t.Errorf("engine = %q %q, want %q %q", result.Engine, result.EngineVersion, tt.wantEngine, tt.wantEngineVersion)
+			}
+		})
+	}
+}
+
+func TestMergeClientHints_Brave(t *testing.T) {
+	// Brave sends the same User-Agent as Chrome
+	ua := ParseUserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	ua.MergeClientHints(&ClientHintsInfo{
+		Brands:          []Brand{{Name: "Not_A Brand", Version: "8", GREASE: true}, {Name: "Chromium", Version: "120"}, {Name: "Brave", Version: "120"}},
+		FullVersionList: []Brand{{Name: "Chromium", Version: "120.0.6099.144"}, {Name: "Brave", Version: "120.1.61.116"}},
+	})
+
+	if ua.BrowserName != "Brave" || ua.BrowserVersion != "120.1.61.116" {
+		t.Errorf("browser = %q %q, want Brave 120.1.61.116", ua.BrowserName, ua.BrowserVersion)
+	}
+	if ua.Engine != "Blink" || ua.EngineVersion != "120.0.6099.144" || ua.Sources.Engine != HintFullVersionList {
+		t.Errorf("engine = %q %q from %s, want Blink 120.0.6099.144 from %s", ua.Engine, ua.EngineVersion, ua.Sources.Engine, HintFullVersionList)
+	}
+}
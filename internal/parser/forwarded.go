@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// ForwardedElement is one element of an RFC 7239 Forwarded header: the
+// parameters a single proxy recorded about the request it received.
+type ForwardedElement struct {
+	For   ForwardedNode // Client or previous proxy that sent the request (for=)
+	By    ForwardedNode // Interface of the proxy that received it (by=)
+	Proto string        // Protocol used to make the request (proto=), e.g. "https"
+	Host  string        // Host header as received by the proxy (host=)
+}
+
+// ForwardedNode is a node identifier from a for= or by= parameter.
+type ForwardedNode struct {
+	Raw        string // Identifier as sent, without quotes; empty if absent
+	IP         string // IP address, if the node name is one
+	Port       string // Port or obfuscated port (e.g., "_abc"), if present
+	Obfuscated bool   // Node name is an obfuscated identifier such as "_hidden"
+	Unknown    bool   // Node name is the literal "unknown"
+}
+
+// ParseForwarded parses the values of one or more Forwarded header lines
+// into elements, ordered from the client towards the server. Parameters with
+// malformed syntax are skipped; unknown parameters are ignored.
+func ParseForwarded(values []string) []ForwardedElement {
+	var elements []ForwardedElement
+
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			if strings.TrimSpace(element) == "" {
+				continue
+			}
+
+			var fe ForwardedElement
+			for _, pair := range splitQuoted(element, ';') {
+				name, val, ok := strings.Cut(pair, "=")
+				if !ok {
+					continue
+				}
+				val, ok = unquote(strings.TrimSpace(val))
+				if !ok {
+					continue
+				}
+				switch strings.ToLower(strings.TrimSpace(name)) {
+				case "for":
+					fe.For = parseForwardedNode(val)
+				case "by":
+					fe.By = parseForwardedNode(val)
+				case "proto":
+					fe.Proto = strings.ToLower(val)
+				case "host":
+					fe.Host = val
+				}
+			}
+			elements = append(elements, fe)
+		}
+	}
+
+	return elements
+}
+
+// parseForwardedNode parses a node identifier: an IPv4 address, a bracketed
+// IPv6 address, "unknown" or an obfuscated "_name", each optionally followed
+// by ":port".
+func parseForwardedNode(s string) ForwardedNode {
+	node := ForwardedNode{Raw: s}
+
+	// Some proxies send IPv6 without the brackets RFC 7239 requires
+	if addr, err := netip.ParseAddr(s); err == nil {
+		node.IP = addr.String()
+		return node
+	}
+
+	name := s
+	if strings.HasPrefix(s, "[") {
+		if end := strings.Index(s, "]"); end > 0 {
+			name = s[1:end]
+			node.Port = strings.TrimPrefix(s[end+1:], ":")
+		}
+	} else if host, port, ok := strings.Cut(s, ":"); ok {
+		name, node.Port = host, port
+	}
+
+	switch {
+	case strings.EqualFold(name, "unknown"):
+		node.Unknown = true
+	case strings.HasPrefix(name, "_"):
+		node.Obfuscated = true
+	default:
+		if addr, err := netip.ParseAddr(name); err == nil {
+			node.IP = addr.String()
+		}
+	}
+
+	return node
+}
+
+// splitQuoted splits s on sep, ignoring separators inside quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	inQuotes, escaped, start := false, false, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case escaped:
+			escaped = false
+		case c == '\\' && inQuotes:
+			escaped = true
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote returns a token as-is, or the contents of a quoted-string with
+// backslash escapes resolved. It reports false for an unterminated quote.
+func unquote(s string) (string, bool) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, !strings.Contains(s, `"`)
+	}
+	if len(s) < 2 || !strings.HasSuffix(s, `"`) {
+		return "", false
+	}
+
+	var b strings.Builder
+	inner := s[1 : len(s)-1]
+	for i := 0; i < len(inner); i++ {
+		if inner[i] == '\\' && i+1 < len(inner) {
+			i++
+		}
+		b.WriteByte(inner[i])
+	}
+	return b.String(), true
+}
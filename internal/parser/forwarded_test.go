@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseForwarded(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []ForwardedElement
+	}{
+		{
+			name:   "single element",
+			values: []string{"for=192.0.2.60;proto=http;by=203.0.113.43"},
+			want: []ForwardedElement{{
+				For:   ForwardedNode{Raw: "192.0.2.60", IP: "192.0.2.60"},
+				By:    ForwardedNode{Raw: "203.0.113.43", IP: "203.0.113.43"},
+				Proto: "http",
+			}},
+		},
+		{
+			name:   "quoted IPv6 with port",
+			values: []string{`For="[2001:db8:cafe::17]:4711"`},
+			want: []ForwardedElement{{
+				For: ForwardedNode{Raw: "[2001:db8:cafe::17]:4711", IP: "2001:db8:cafe::17", Port: "4711"},
+			}},
+		},
+		{
+			name:   "multiple elements and header lines",
+			values: []string{"for=192.0.2.43, for=198.51.100.17;by=_proxy1", `for=unknown;host="example.com";proto=HTTPS`},
+			want: []ForwardedElement{
+				{For: ForwardedNode{Raw: "192.0.2.43", IP: "192.0.2.43"}},
+				{For: ForwardedNode{Raw: "198.51.100.17", IP: "198.51.100.17"}, By: ForwardedNode{Raw: "_proxy1", Obfuscated: true}},
+				{For: ForwardedNode{Raw: "unknown", Unknown: true}, Host: "example.com", Proto: "https"},
+			},
+		},
+		{
+			name:   "obfuscated identifier with obfuscated port",
+			values: []string{`for="_hidden:_port1"`},
+			want: []ForwardedElement{{
+				For: ForwardedNode{Raw: "_hidden:_port1", Port: "_port1", Obfuscated: true},
+			}},
+		},
+		{
+			name:   "IPv4 with port",
+			values: []string{`for="192.0.2.60:8080"`},
+			want: []ForwardedElement{{
+				For: ForwardedNode{Raw: "192.0.2.60:8080", IP: "192.0.2.60", Port: "8080"},
+			}},
+		},
+		{
+			name:   "comma inside quoted string",
+			values: []string{`for=192.0.2.1;host="a,b", for=192.0.2.2`},
+			want: []ForwardedElement{
+				{For: ForwardedNode{Raw: "192.0.2.1", IP: "192.0.2.1"}, Host: "a,b"},
+				{For: ForwardedNode{Raw: "192.0.2.2", IP: "192.0.2.2"}},
+			},
+		},
+		{
+			name:   "malformed pairs are skipped",
+			values: []string{`for="192.0.2.1;proto;by=198.51.100.1`},
+			want:   []ForwardedElement{{}},
+		},
+		{
+			name:   "empty",
+			values: nil,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseForwarded(tt.values)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseForwarded(%q) =\n%+v\nwant\n%+v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
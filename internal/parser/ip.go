@@ -1,37 +1,88 @@
 package parser
 
 import (
+	"fmt"
 	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 )
 
-// GetClientIP extracts the client IP address from the request.
-// It first checks the X-Forwarded-For header (taking the leftmost IP),
-// then falls back to the RemoteAddr from the request.
-func GetClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header first
-	xff := r.Header.Get("X-Forwarded-For")
-	if xff != "" {
-		// X-Forwarded-For can contain multiple IPs: "client, proxy1, proxy2"
-		// The leftmost IP is the original client
-		ips := strings.Split(xff, ",")
-		if len(ips) > 0 {
-			clientIP := strings.TrimSpace(ips[0])
-			if clientIP != "" {
-				return clientIP
+// TrustedProxies is the set of networks whose forwarding headers are believed.
+type TrustedProxies []netip.Prefix
+
+// DefaultTrustedProxies trusts loopback only, which is where the built-in
+// nginx reverse proxy connects from.
+var DefaultTrustedProxies = TrustedProxies{
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("::1/128"),
+}
+
+// ParseTrustedProxies parses a comma- or space-separated list of CIDR
+// prefixes or bare IP addresses. The literal "none" yields an empty set,
+// so that no forwarding header is ever trusted.
+func ParseTrustedProxies(s string) (TrustedProxies, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+
+	trusted := TrustedProxies{}
+	for _, field := range fields {
+		if field == "none" {
+			continue
+		}
+		if strings.Contains(field, "/") {
+			prefix, err := netip.ParsePrefix(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", field, err)
 			}
+			trusted = append(trusted, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", field, err)
 		}
+		trusted = append(trusted, netip.PrefixFrom(addr, addr.BitLen()))
 	}
+	return trusted, nil
+}
 
-	// Check X-Real-IP header (commonly used by nginx)
-	xri := r.Header.Get("X-Real-IP")
-	if xri != "" {
-		return strings.TrimSpace(xri)
+// Contains reports whether addr falls inside one of the trusted networks.
+// IPv4-mapped IPv6 addresses are matched as IPv4.
+func (t TrustedProxies) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
 	}
+	return false
+}
+
+// GetClientIP extracts the client IP address from the request, trusting
+// forwarding headers only from DefaultTrustedProxies.
+func GetClientIP(r *http.Request) string {
+	return DefaultTrustedProxies.ClientIP(r)
+}
 
-	// Fall back to RemoteAddr
-	return extractIP(r.RemoteAddr)
+// ClientIP extracts the client IP address from the request: the hop that
+// Chain marks as the client.
+func (t TrustedProxies) ClientIP(r *http.Request) string {
+	return ClientOf(t.Chain(r))
+}
+
+// parseHopAddr parses a forwarding header entry, which may be a bare IP or
+// an "IP:port" / "[IPv6]:port" pair.
+func parseHopAddr(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr, true
+	}
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr(), true
+	}
+	return netip.Addr{}, false
 }
 
 // extractIP extracts the IP address from a host:port string.
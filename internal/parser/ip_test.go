@@ -2,6 +2,7 @@ package parser
 
 import (
 	"net/http"
+	"net/netip"
 	"testing"
 )
 
@@ -26,31 +27,73 @@ func TestGetClientIP(t *testing.T) {
 		},
 		{
 			name:       "X-Forwarded-For single IP",
-			remoteAddr: "10.0.0.1:12345",
+			remoteAddr: "127.0.0.1:12345",
 			headers:    map[string]string{"X-Forwarded-For": "203.0.113.50"},
 			expected:   "203.0.113.50",
 		},
 		{
-			name:       "X-Forwarded-For multiple IPs",
-			remoteAddr: "10.0.0.1:12345",
+			name:       "X-Forwarded-For multiple IPs takes first untrusted from the right",
+			remoteAddr: "127.0.0.1:12345",
 			headers:    map[string]string{"X-Forwarded-For": "203.0.113.50, 70.41.3.18, 150.172.238.178"},
+			expected:   "150.172.238.178",
+		},
+		{
+			name:       "X-Forwarded-For skips trusted hops",
+			remoteAddr: "127.0.0.1:12345",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.50, 127.0.0.2, ::1"},
 			expected:   "203.0.113.50",
 		},
+		{
+			name:       "X-Forwarded-For all trusted returns leftmost",
+			remoteAddr: "127.0.0.1:12345",
+			headers:    map[string]string{"X-Forwarded-For": "127.0.0.3, 127.0.0.2"},
+			expected:   "127.0.0.3",
+		},
 		{
 			name:       "X-Forwarded-For with spaces",
-			remoteAddr: "10.0.0.1:12345",
+			remoteAddr: "127.0.0.1:12345",
 			headers:    map[string]string{"X-Forwarded-For": "  203.0.113.50  "},
 			expected:   "203.0.113.50",
 		},
 		{
-			name:       "X-Real-IP header",
+			name:       "X-Forwarded-For with ports",
+			remoteAddr: "[::1]:12345",
+			headers:    map[string]string{"X-Forwarded-For": "[2001:db8::1]:4711"},
+			expected:   "2001:db8::1",
+		},
+		{
+			name:       "X-Forwarded-For garbled entry stops the walk",
+			remoteAddr: "127.0.0.1:12345",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.50, garbage, 127.0.0.2"},
+			expected:   "127.0.0.2",
+		},
+		{
+			name:       "X-Forwarded-For ignored from untrusted peer",
 			remoteAddr: "10.0.0.1:12345",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.50"},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "X-Real-IP header",
+			remoteAddr: "127.0.0.1:12345",
 			headers:    map[string]string{"X-Real-IP": "198.51.100.178"},
 			expected:   "198.51.100.178",
 		},
 		{
-			name:       "X-Forwarded-For takes precedence over X-Real-IP",
+			name:       "X-Real-IP ignored from untrusted peer",
 			remoteAddr: "10.0.0.1:12345",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.178"},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "invalid X-Real-IP falls back to peer",
+			remoteAddr: "127.0.0.1:12345",
+			headers:    map[string]string{"X-Real-IP": "not-an-ip"},
+			expected:   "127.0.0.1",
+		},
+		{
+			name:       "X-Forwarded-For takes precedence over X-Real-IP",
+			remoteAddr: "127.0.0.1:12345",
 			headers: map[string]string{
 				"X-Forwarded-For": "203.0.113.50",
 				"X-Real-IP":       "198.51.100.178",
@@ -59,13 +102,58 @@ func TestGetClientIP(t *testing.T) {
 		},
 		{
 			name:       "empty X-Forwarded-For falls back to X-Real-IP",
-			remoteAddr: "10.0.0.1:12345",
+			remoteAddr: "127.0.0.1:12345",
 			headers: map[string]string{
 				"X-Forwarded-For": "",
 				"X-Real-IP":       "198.51.100.178",
 			},
 			expected: "198.51.100.178",
 		},
+		{
+			name:       "Forwarded single element",
+			remoteAddr: "127.0.0.1:12345",
+			headers:    map[string]string{"Forwarded": "for=192.0.2.60;proto=https"},
+			expected:   "192.0.2.60",
+		},
+		{
+			name:       "Forwarded quoted IPv6",
+			remoteAddr: "127.0.0.1:12345",
+			headers:    map[string]string{"Forwarded": `for="[2001:db8:cafe::17]:4711"`},
+			expected:   "2001:db8:cafe::17",
+		},
+		{
+			name:       "Forwarded walks right to left",
+			remoteAddr: "127.0.0.1:12345",
+			headers:    map[string]string{"Forwarded": "for=192.0.2.43, for=198.51.100.17, for=127.0.0.2"},
+			expected:   "198.51.100.17",
+		},
+		{
+			name:       "Forwarded obfuscated client stops at the trusted proxy",
+			remoteAddr: "127.0.0.1:12345",
+			headers:    map[string]string{"Forwarded": "for=_hidden, for=127.0.0.2"},
+			expected:   "127.0.0.2",
+		},
+		{
+			name:       "Forwarded unknown client falls back to peer",
+			remoteAddr: "127.0.0.1:12345",
+			headers:    map[string]string{"Forwarded": "for=unknown"},
+			expected:   "127.0.0.1",
+		},
+		{
+			name:       "Forwarded takes precedence over X-Forwarded-For",
+			remoteAddr: "127.0.0.1:12345",
+			headers: map[string]string{
+				"Forwarded":       "for=192.0.2.60",
+				"X-Forwarded-For": "203.0.113.50",
+			},
+			expected: "192.0.2.60",
+		},
+		{
+			name:       "Forwarded ignored from untrusted peer",
+			remoteAddr: "10.0.0.1:12345",
+			headers:    map[string]string{"Forwarded": "for=192.0.2.60"},
+			expected:   "10.0.0.1",
+		},
 		{
 			name:       "RemoteAddr without port",
 			remoteAddr: "192.168.1.100",
@@ -90,6 +178,71 @@ func TestGetClientIP(t *testing.T) {
 	}
 }
 
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8, 70.41.3.18")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.1.2.3:12345"
+	req.Header.Add("X-Forwarded-For", "203.0.113.50, 198.51.100.7")
+	req.Header.Add("X-Forwarded-For", "70.41.3.18, 10.9.9.9")
+
+	if got := trusted.ClientIP(req); got != "198.51.100.7" {
+		t.Errorf("ClientIP() = %q, want %q", got, "198.51.100.7")
+	}
+
+	// Loopback is not trusted by this set
+	req.RemoteAddr = "127.0.0.1:12345"
+	if got := trusted.ClientIP(req); got != "127.0.0.1" {
+		t.Errorf("ClientIP() = %q, want %q", got, "127.0.0.1")
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"127.0.0.0/8,::1/128", []string{"127.0.0.0/8", "::1/128"}, false},
+		{"10.1.2.3/8 192.0.2.1", []string{"10.0.0.0/8", "192.0.2.1/32"}, false},
+		{"2001:db8::1", []string{"2001:db8::1/128"}, false},
+		{"none", []string{}, false},
+		{"", []string{}, false},
+		{"10.0.0.0/33", nil, true},
+		{"example.com", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTrustedProxies(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTrustedProxies(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseTrustedProxies(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+			for i := range got {
+				if got[i].String() != tt.want[i] {
+					t.Errorf("ParseTrustedProxies(%q)[%d] = %v, want %v", tt.input, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTrustedProxies_ContainsMappedIPv4(t *testing.T) {
+	addr, _ := netip.ParseAddr("::ffff:127.0.0.1")
+	if !DefaultTrustedProxies.Contains(addr) {
+		t.Errorf("Contains(%v) = false, want true", addr)
+	}
+}
+
 func TestExtractIP(t *testing.T) {
 	tests := []struct {
 		input    string
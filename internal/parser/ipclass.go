@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"net/netip"
+)
+
+// IPInfo describes what kind of address an IP is. Flags describe the
+// address after unmapping, so an IPv4-mapped IPv6 address such as
+// ::ffff:10.0.0.1 is reported as IPv4Mapped and Private.
+type IPInfo struct {
+	Valid         bool   // Address parsed as an IP address
+	Version       int    // 4 or 6, after unmapping; 0 if not valid
+	IPv4Mapped    bool   // IPv4-mapped IPv6 address (::ffff:0:0/96)
+	Unspecified   bool   // 0.0.0.0 or ::
+	Loopback      bool   // 127.0.0.0/8 or ::1
+	Private       bool   // RFC 1918: 10/8, 172.16/12, 192.168/16
+	CGNAT         bool   // Carrier-grade NAT shared space, RFC 6598: 100.64/10
+	LinkLocal     bool   // 169.254/16 or fe80::/10
+	UniqueLocal   bool   // IPv6 unique local, RFC 4193: fc00::/7
+	Documentation bool   // RFC 5737, RFC 3849 and RFC 9637 example ranges
+	Multicast     bool   // 224.0.0.0/4 or ff00::/8
+	Teredo        bool   // Teredo tunnel, RFC 4380: 2001::/32
+	SixToFour     bool   // 6to4 tunnel, RFC 3056: 2002::/16
+	EmbeddedIPv4  string // Client IPv4 address carried in a Teredo or 6to4 address
+	TeredoServer  string // Teredo server IPv4 address
+	Public        bool   // Globally routable unicast address
+}
+
+var (
+	cgnatPrefix     = netip.MustParsePrefix("100.64.0.0/10")
+	teredoPrefix    = netip.MustParsePrefix("2001::/32")
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+
+	documentationPrefixes = []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+		netip.MustParsePrefix("203.0.113.0/24"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("3fff::/20"),
+	}
+)
+
+// ClassifyIP classifies an IP address, given either bare or as "IP:port".
+// A string that is not an IP address yields an IPInfo with Valid false.
+func ClassifyIP(s string) IPInfo {
+	addr, ok := parseHopAddr(s)
+	if !ok {
+		return IPInfo{}
+	}
+
+	info := IPInfo{Valid: true, IPv4Mapped: addr.Is4In6()}
+	addr = addr.Unmap().WithZone("")
+	if addr.Is4() {
+		info.Version = 4
+	} else {
+		info.Version = 6
+	}
+
+	info.Unspecified = addr.IsUnspecified()
+	info.Loopback = addr.IsLoopback()
+	info.Private = addr.Is4() && addr.IsPrivate()
+	info.UniqueLocal = addr.Is6() && addr.IsPrivate()
+	info.CGNAT = cgnatPrefix.Contains(addr)
+	info.LinkLocal = addr.IsLinkLocalUnicast()
+	info.Multicast = addr.IsMulticast()
+	for _, prefix := range documentationPrefixes {
+		if prefix.Contains(addr) {
+			info.Documentation = true
+		}
+	}
+
+	// Teredo stores the server address in bits 32-63 and the client address,
+	// with every bit inverted, in the last 32 bits.
+	b := addr.As16()
+	switch {
+	case teredoPrefix.Contains(addr):
+		info.Teredo = true
+		info.TeredoServer = netip.AddrFrom4([4]byte{b[4], b[5], b[6], b[7]}).String()
+		info.EmbeddedIPv4 = netip.AddrFrom4([4]byte{^b[12], ^b[13], ^b[14], ^b[15]}).String()
+	case sixToFourPrefix.Contains(addr):
+		info.SixToFour = true
+		info.EmbeddedIPv4 = netip.AddrFrom4([4]byte{b[2], b[3], b[4], b[5]}).String()
+	}
+
+	info.Public = addr.IsGlobalUnicast() && !info.Private && !info.UniqueLocal &&
+		!info.CGNAT && !info.Documentation
+	return info
+}
+
+// Labels returns short human-readable names for the set flags, in a stable
+// order, for display as badges.
+func (i IPInfo) Labels() []string {
+	if !i.Valid {
+		return nil
+	}
+
+	labels := []string{"IPv4"}
+	if i.Version == 6 {
+		labels[0] = "IPv6"
+	}
+	for _, flag := range []struct {
+		set   bool
+		label string
+	}{
+		{i.IPv4Mapped, "IPv4-mapped"},
+		{i.Public, "Public"},
+		{i.Unspecified, "Unspecified"},
+		{i.Loopback, "Loopback"},
+		{i.Private, "Private"},
+		{i.CGNAT, "CGNAT"},
+		{i.LinkLocal, "Link-local"},
+		{i.UniqueLocal, "Unique local"},
+		{i.Documentation, "Documentation"},
+		{i.Multicast, "Multicast"},
+		{i.Teredo, "Teredo"},
+		{i.SixToFour, "6to4"},
+	} {
+		if flag.set {
+			labels = append(labels, flag.label)
+		}
+	}
+	return labels
+}
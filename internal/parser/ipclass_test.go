@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyIP(t *testing.T) {
+	tests := []struct {
+		input string
+		want  IPInfo
+	}{
+		{"8.8.8.8", IPInfo{Valid: true, Version: 4, Public: true}},
+		{"2606:4700::1111", IPInfo{Valid: true, Version: 6, Public: true}},
+		{"203.0.113.50:443", IPInfo{Valid: true, Version: 4, Documentation: true}},
+		{"2001:db8::1", IPInfo{Valid: true, Version: 6, Documentation: true}},
+		{"127.0.0.1", IPInfo{Valid: true, Version: 4, Loopback: true}},
+		{"::1", IPInfo{Valid: true, Version: 6, Loopback: true}},
+		{"0.0.0.0", IPInfo{Valid: true, Version: 4, Unspecified: true}},
+		{"10.1.2.3", IPInfo{Valid: true, Version: 4, Private: true}},
+		{"172.16.0.1", IPInfo{Valid: true, Version: 4, Private: true}},
+		{"172.32.0.1", IPInfo{Valid: true, Version: 4, Public: true}},
+		{"192.168.1.100", IPInfo{Valid: true, Version: 4, Private: true}},
+		{"100.64.0.1", IPInfo{Valid: true, Version: 4, CGNAT: true}},
+		{"100.127.255.254", IPInfo{Valid: true, Version: 4, CGNAT: true}},
+		{"100.128.0.1", IPInfo{Valid: true, Version: 4, Public: true}},
+		{"169.254.169.254", IPInfo{Valid: true, Version: 4, LinkLocal: true}},
+		{"fe80::1%eth0", IPInfo{Valid: true, Version: 6, LinkLocal: true}},
+		{"fd12:3456:789a::1", IPInfo{Valid: true, Version: 6, UniqueLocal: true}},
+		{"239.1.2.3", IPInfo{Valid: true, Version: 4, Multicast: true}},
+		{"ff02::1", IPInfo{Valid: true, Version: 6, Multicast: true}},
+		{"::ffff:10.0.0.1", IPInfo{Valid: true, Version: 4, IPv4Mapped: true, Private: true}},
+		{"[::ffff:100.64.1.1]:8080", IPInfo{Valid: true, Version: 4, IPv4Mapped: true, CGNAT: true}},
+		{
+			// RFC 4380 example: server 65.54.227.120, client 192.0.2.45
+			"2001:0:4136:e378:8000:63bf:3fff:fdd2",
+			IPInfo{Valid: true, Version: 6, Teredo: true, TeredoServer: "65.54.227.120", EmbeddedIPv4: "192.0.2.45", Public: true},
+		},
+		{"2002:c000:0204::1", IPInfo{Valid: true, Version: 6, SixToFour: true, EmbeddedIPv4: "192.0.2.4", Public: true}},
+		{"unknown", IPInfo{}},
+		{"", IPInfo{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := ClassifyIP(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClassifyIP(%q) =\n%+v\nwant\n%+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPInfo_Labels(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"100.64.0.1", []string{"IPv4", "CGNAT"}},
+		{"::ffff:192.168.0.1", []string{"IPv4", "IPv4-mapped", "Private"}},
+		{"2002:c000:0204::1", []string{"IPv6", "Public", "6to4"}},
+		{"not-an-ip", nil},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyIP(tt.input).Labels(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ClassifyIP(%q).Labels() = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package parser
+
+import "strings"
+
+// LanguageRange is a single entry of an Accept-Language header, e.g.
+// "en-US;q=0.9".
+type LanguageRange struct {
+	Tag  string  // Language tag as sent, e.g. "en-US", or "*"
+	Name string  // English name, e.g. "English (United States)"; empty if unknown
+	Q    float64 // Quality value between 0 and 1 (default 1)
+}
+
+// ParseAcceptLanguage parses an Accept-Language header into language ranges
+// ordered by preference: highest quality first, ties in the order sent.
+// Malformed entries are skipped.
+func ParseAcceptLanguage(header string) []LanguageRange {
+	var ranges []LanguageRange
+	for _, entry := range strings.Split(header, ",") {
+		tag, q, ok := parseWeighted(entry)
+		if !ok || !validLanguageTag(tag) {
+			continue
+		}
+		ranges = append(ranges, LanguageRange{Tag: tag, Name: LanguageName(tag), Q: q})
+	}
+	return byPreference(ranges, func(l LanguageRange) float64 { return l.Q })
+}
+
+// validLanguageTag reports whether tag is "*" or made of subtags of one to
+// eight letters and digits separated by hyphens, as language ranges are
+// (RFC 4647, section 2.1).
+func validLanguageTag(tag string) bool {
+	if tag == "*" {
+		return true
+	}
+	for i, sub := range strings.Split(tag, "-") {
+		if len(sub) == 0 || len(sub) > 8 {
+			return false
+		}
+		for _, c := range sub {
+			isLetter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+			isDigit := c >= '0' && c <= '9'
+			if !isLetter && !(isDigit && i > 0) && !(sub == "*" && i > 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// LanguageName returns the English name of a language tag, with its script
+// and region in parentheses, e.g. "Chinese (Traditional, Taiwan)" for
+// "zh-Hant-TW". It returns an empty string if the language is unknown.
+// Regions without a name are shown by their code, and other subtags, such
+// as variants, are left out.
+func LanguageName(tag string) string {
+	subtags := strings.Split(tag, "-")
+	name := languageNames[strings.ToLower(subtags[0])]
+	if name == "" {
+		return ""
+	}
+
+	var details []string
+	for _, sub := range subtags[1:] {
+		switch {
+		case len(sub) == 1:
+			// An extension or private use subtag; the rest is not named
+			return joinDetails(name, details)
+		case len(sub) == 4 && isAlpha(sub):
+			if script := scriptNames[strings.ToLower(sub)]; script != "" {
+				details = append(details, script)
+			}
+		case len(sub) == 2 && isAlpha(sub):
+			region := strings.ToUpper(sub)
+			if regionName := regionNames[region]; regionName != "" {
+				region = regionName
+			}
+			details = append(details, region)
+		case sub == "419":
+			details = append(details, "Latin America")
+		}
+	}
+	return joinDetails(name, details)
+}
+
+func joinDetails(name string, details []string) string {
+	if len(details) == 0 {
+		return name
+	}
+	return name + " (" + strings.Join(details, ", ") + ")"
+}
+
+func isAlpha(s string) bool {
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// English names of the languages browsers commonly offer, by ISO 639 code.
+var languageNames = map[string]string{
+	"af":  "Afrikaans",
+	"am":  "Amharic",
+	"ar":  "Arabic",
+	"as":  "Assamese",
+	"az":  "Azerbaijani",
+	"be":  "Belarusian",
+	"bg":  "Bulgarian",
+	"bn":  "Bangla",
+	"bs":  "Bosnian",
+	"ca":  "Catalan",
+	"cs":  "Czech",
+	"cy":  "Welsh",
+	"da":  "Danish",
+	"de":  "German",
+	"el":  "Greek",
+	"en":  "English",
+	"eo":  "Esperanto",
+	"es":  "Spanish",
+	"et":  "Estonian",
+	"eu":  "Basque",
+	"fa":  "Persian",
+	"fi":  "Finnish",
+	"fil": "Filipino",
+	"fo":  "Faroese",
+	"fr":  "French",
+	"fy":  "Western Frisian",
+	"ga":  "Irish",
+	"gd":  "Scottish Gaelic",
+	"gl":  "Galician",
+	"gu":  "Gujarati",
+	"ha":  "Hausa",
+	"he":  "Hebrew",
+	"hi":  "Hindi",
+	"hr":  "Croatian",
+	"hu":  "Hungarian",
+	"hy":  "Armenian",
+	"id":  "Indonesian",
+	"ig":  "Igbo",
+	"is":  "Icelandic",
+	"it":  "Italian",
+	"ja":  "Japanese",
+	"jv":  "Javanese",
+	"ka":  "Georgian",
+	"kk":  "Kazakh",
+	"km":  "Khmer",
+	"kn":  "Kannada",
+	"ko":  "Korean",
+	"ku":  "Kurdish",
+	"ky":  "Kyrgyz",
+	"la":  "Latin",
+	"lb":  "Luxembourgish",
+	"lo":  "Lao",
+	"lt":  "Lithuanian",
+	"lv":  "Latvian",
+	"mi":  "Māori",
+	"mk":  "Macedonian",
+	"ml":  "Malayalam",
+	"mn":  "Mongolian",
+	"mr":  "Marathi",
+	"ms":  "Malay",
+	"mt":  "Maltese",
+	"my":  "Burmese",
+	"nb":  "Norwegian Bokmål",
+	"ne":  "Nepali",
+	"nl":  "Dutch",
+	"nn":  "Norwegian Nynorsk",
+	"no":  "Norwegian",
+	"or":  "Odia",
+	"pa":  "Punjabi",
+	"pl":  "Polish",
+	"ps":  "Pashto",
+	"pt":  "Portuguese",
+	"rm":  "Romansh",
+	"ro":  "Romanian",
+	"ru":  "Russian",
+	"rw":  "Kinyarwanda",
+	"sa":  "Sanskrit",
+	"sd":  "Sindhi",
+	"si":  "Sinhala",
+	"sk":  "Slovak",
+	"sl":  "Slovenian",
+	"so":  "Somali",
+	"sq":  "Albanian",
+	"sr":  "Serbian",
+	"sv":  "Swedish",
+	"sw":  "Swahili",
+	"ta":  "Tamil",
+	"te":  "Telugu",
+	"tg":  "Tajik",
+	"th":  "Thai",
+	"ti":  "Tigrinya",
+	"tk":  "Turkmen",
+	"tr":  "Turkish",
+	"tt":  "Tatar",
+	"ug":  "Uyghur",
+	"uk":  "Ukrainian",
+	"ur":  "Urdu",
+	"uz":  "Uzbek",
+	"vi":  "Vietnamese",
+	"xh":  "Xhosa",
+	"yi":  "Yiddish",
+	"yo":  "Yoruba",
+	"yue": "Cantonese",
+	"zh":  "Chinese",
+	"zu":  "Zulu",
+}
+
+// English names of common scripts, by lower-cased ISO 15924 code.
+var scriptNames = map[string]string{
+	"arab": "Arabic",
+	"cyrl": "Cyrillic",
+	"hans": "Simplified",
+	"hant": "Traditional",
+	"latn": "Latin",
+}
+
+// English names of regions, by ISO 3166-1 code.
+var regionNames = map[string]string{
+	"AE": "United Arab Emirates",
+	"AR": "Argentina",
+	"AT": "Austria",
+	"AU": "Australia",
+	"BA": "Bosnia & Herzegovina",
+	"BD": "Bangladesh",
+	"BE": "Belgium",
+	"BG": "Bulgaria",
+	"BO": "Bolivia",
+	"BR": "Brazil",
+	"BY": "Belarus",
+	"CA": "Canada",
+	"CH": "Switzerland",
+	"CL": "Chile",
+	"CN": "China",
+	"CO": "Colombia",
+	"CR": "Costa Rica",
+	"CZ": "Czechia",
+	"DE": "Germany",
+	"DK": "Denmark",
+	"DO": "Dominican Republic",
+	"DZ": "Algeria",
+	"EC": "Ecuador",
+	"EE": "Estonia",
+	"EG": "Egypt",
+	"ES": "Spain",
+	"FI": "Finland",
+	"FR": "France",
+	"GB": "United Kingdom",
+	"GR": "Greece",
+	"GT": "Guatemala",
+	"HK": "Hong Kong",
+	"HR": "Croatia",
+	"HU": "Hungary",
+	"ID": "Indonesia",
+	"IE": "Ireland",
+	"IL": "Israel",
+	"IN": "India",
+	"IQ": "Iraq",
+	"IR": "Iran",
+	"IS": "Iceland",
+	"IT": "Italy",
+	"JP": "Japan",
+	"KE": "Kenya",
+	"KR": "South Korea",
+	"KZ": "Kazakhstan",
+	"LT": "Lithuania",
+	"LU": "Luxembourg",
+	"LV": "Latvia",
+	"MA": "Morocco",
+	"MO": "Macao",
+	"MX": "Mexico",
+	"MY": "Malaysia",
+	"NG": "Nigeria",
+	"NL": "Netherlands",
+	"NO": "Norway",
+	"NZ": "New Zealand",
+	"PE": "Peru",
+	"PH": "Philippines",
+	"PK": "Pakistan",
+	"PL": "Poland",
+	"PR": "Puerto Rico",
+	"PT": "Portugal",
+	"PY": "Paraguay",
+	"RO": "Romania",
+	"RS": "Serbia",
+	"RU": "Russia",
+	"SA": "Saudi Arabia",
+	"SE": "Sweden",
+	"SG": "Singapore",
+	"SI": "Slovenia",
+	"SK": "Slovakia",
+	"TH": "Thailand",
+	"TN": "Tunisia",
+	"TR": "Türkiye",
+	"TW": "Taiwan",
+	"UA": "Ukraine",
+	"US": "United States",
+	"UY": "Uruguay",
+	"VE": "Venezuela",
+	"VN": "Vietnam",
+	"ZA": "South Africa",
+}
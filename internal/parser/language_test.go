@@ -0,0 +1,70 @@
+package parser
+
+import "testing"
+
+func TestParseAcceptLanguage(t *testing.T) {
+	ranges := ParseAcceptLanguage("de-CH, fr;q=0.5, en-US;q=0.9, zh-Hant-TW;q=0.5, x-klingon;q=0.1, *;q=0.1")
+
+	expected := []LanguageRange{
+		{"de-CH", "German (Switzerland)", 1},
+		{"en-US", "English (United States)", 0.9},
+		{"fr", "French", 0.5},
+		{"zh-Hant-TW", "Chinese (Traditional, Taiwan)", 0.5},
+		{"x-klingon", "", 0.1},
+		{"*", "", 0.1},
+	}
+
+	if len(ranges) != len(expected) {
+		t.Fatalf("ParseAcceptLanguage() returned %d ranges, want %d: %+v", len(ranges), len(expected), ranges)
+	}
+	for i, want := range expected {
+		if ranges[i] != want {
+			t.Errorf("range %d = %+v, want %+v", i, ranges[i], want)
+		}
+	}
+}
+
+func TestParseAcceptLanguage_Malformed(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"", 0},
+		{"en;q=2", 0},
+		{"en_US", 0},
+		{"toolonglanguage", 0},
+		{"1en", 0},
+		{"en;q=abc, de", 1},
+		{"es-419", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := ParseAcceptLanguage(tt.header); len(got) != tt.want {
+				t.Errorf("ParseAcceptLanguage(%q) returned %d ranges, want %d: %+v", tt.header, len(got), tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLanguageName(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"en", "English"},
+		{"EN-gb", "English (United Kingdom)"},
+		{"es-419", "Spanish (Latin America)"},
+		{"sr-Latn", "Serbian (Latin)"},
+		{"pt-XX", "Portuguese (XX)"},
+		{"de-DE-1996", "German (Germany)"},
+		{"en-US-u-ca-gregory", "English (United States)"},
+		{"tlh", ""},
+	}
+
+	for _, tt := range tests {
+		if got := LanguageName(tt.tag); got != tt.want {
+			t.Errorf("LanguageName(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
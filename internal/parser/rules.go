@@ -0,0 +1,298 @@
+package parser
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRulesReloadInterval is how often RulesFile.Watch checks the rules
+// file for changes.
+const DefaultRulesReloadInterval = time.Minute
+
+//go:embed regexes.yaml
+var defaultRules []byte
+
+// DefaultRules are the built-in user-agent rules, used by ParseUserAgent.
+var DefaultRules = mustParseRules(defaultRules)
+
+// Sections of a rules file, with the keys of each rule's fields and the
+// template a field takes when a rule does not set it. Fields after the
+// first are the version parts, or the device's brand and model.
+var ruleSections = map[string]struct {
+	keys     []string
+	defaults []string
+}{
+	"user_agent_parsers": {
+		[]string{"family_replacement", "v1_replacement", "v2_replacement", "v3_replacement", "v4_replacement"},
+		[]string{"$1", "$2", "$3", "$4", "$5"},
+	},
+	"os_parsers": {
+		[]string{"os_replacement", "os_v1_replacement", "os_v2_replacement", "os_v3_replacement", "os_v4_replacement"},
+		[]string{"$1", "$2", "$3", "$4", "$5"},
+	},
+	"device_parsers": {
+		[]string{"device_replacement", "brand_replacement", "model_replacement"},
+		[]string{"$1", "", "$1"},
+	},
+}
+
+// rule is a compiled rule of a rules file.
+type rule struct {
+	pattern *regexp.Regexp
+	fields  []string // Templates of the section's fields, in order
+}
+
+// Rules are user-agent rules in the format of ua-parser's regexes.yaml:
+// user_agent_parsers name the browser, os_parsers the operating system and
+// device_parsers the device's vendor and model. Within each section the
+// first matching rule wins. Rules are safe for concurrent use.
+type Rules struct {
+	userAgent []rule
+	os        []rule
+	device    []rule
+}
+
+// ParseRules parses and validates rules in the regexes.yaml format. Every
+// regex must compile and every $n in a replacement must name a group of
+// its regex.
+func ParseRules(data []byte) (*Rules, error) {
+	sections, err := decodeRules(data)
+	if err != nil {
+		return nil, err
+	}
+	for name := range sections {
+		if _, ok := ruleSections[name]; !ok {
+			return nil, fmt.Errorf("unknown section %q", name)
+		}
+	}
+	if len(sections["user_agent_parsers"]) == 0 {
+		return nil, errors.New("no user_agent_parsers")
+	}
+
+	r := &Rules{}
+	for _, s := range []struct {
+		name  string
+		rules *[]rule
+	}{{"user_agent_parsers", &r.userAgent}, {"os_parsers", &r.os}, {"device_parsers", &r.device}} {
+		if *s.rules, err = compileRules(s.name, sections[s.name]); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// mustParseRules is like ParseRules but panics if the rules are invalid.
+func mustParseRules(data []byte) *Rules {
+	r, err := ParseRules(data)
+	if err != nil {
+		panic("parser: built-in rules: " + err.Error())
+	}
+	return r
+}
+
+// Len returns the number of rules.
+func (r *Rules) Len() int {
+	return len(r.userAgent) + len(r.os) + len(r.device)
+}
+
+// compileRules compiles the entries of a section.
+func compileRules(section string, entries []ruleEntry) ([]rule, error) {
+	keys, defaults := ruleSections[section].keys, ruleSections[section].defaults
+	rules := make([]rule, 0, len(entries))
+	for _, e := range entries {
+		expr := e.fields["regex"]
+		if expr == "" {
+			return nil, fmt.Errorf("line %d: rule has no regex", e.line)
+		}
+		switch flag := e.fields["regex_flag"]; flag {
+		case "":
+		case "i":
+			expr = "(?i)" + expr
+		default:
+			return nil, fmt.Errorf("line %d: unknown regex_flag %q", e.line, flag)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", e.line, err)
+		}
+
+		r := rule{pattern: re, fields: slices.Clone(defaults)}
+		for key, value := range e.fields {
+			if key == "regex" || key == "regex_flag" {
+				continue
+			}
+			i := slices.Index(keys, key)
+			if i < 0 {
+				return nil, fmt.Errorf("line %d: unknown key %q in %s", e.line, key, section)
+			}
+			if n := maxGroup(value); n > re.NumSubexp() {
+				return nil, fmt.Errorf("line %d: %s refers to $%d, but the regex has %d groups", e.line, key, n, re.NumSubexp())
+			}
+			r.fields[i] = value
+		}
+		// Devices are named by their brand and model instead
+		if _, named := e.fields[keys[0]]; !named && re.NumSubexp() == 0 && section != "device_parsers" {
+			return nil, fmt.Errorf("line %d: regex has no group and no %s", e.line, keys[0])
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// maxGroup returns the highest group a template refers to as $1 to $9.
+func maxGroup(tmpl string) int {
+	n := 0
+	for i := 0; i+1 < len(tmpl); i++ {
+		if tmpl[i] == '$' && tmpl[i+1] >= '1' && tmpl[i+1] <= '9' {
+			n = max(n, int(tmpl[i+1]-'0'))
+		}
+	}
+	return n
+}
+
+// match returns the fields of the first rule matching ua, or nil if none
+// does.
+func match(rules []rule, ua string) []string {
+	for _, r := range rules {
+		matches := r.pattern.FindStringSubmatch(ua)
+		if matches == nil {
+			continue
+		}
+		fields := make([]string, len(r.fields))
+		for i, tmpl := range r.fields {
+			fields[i] = expand(tmpl, matches)
+		}
+		return fields
+	}
+	return nil
+}
+
+// expand replaces $1 to $9 in a template with the groups of a match, and
+// trims the result. Groups that did not take part in the match, or that
+// the regex does not have, are empty.
+func expand(tmpl string, matches []string) string {
+	if !strings.Contains(tmpl, "$") {
+		return tmpl
+	}
+	var b strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		if tmpl[i] == '$' && i+1 < len(tmpl) && tmpl[i+1] >= '1' && tmpl[i+1] <= '9' {
+			if n := int(tmpl[i+1] - '0'); n < len(matches) {
+				b.WriteString(matches[n])
+			}
+			i++
+			continue
+		}
+		b.WriteByte(tmpl[i])
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// joinVersion joins version parts with dots, up to the first missing one.
+func joinVersion(parts []string) string {
+	for i, p := range parts {
+		if p == "" {
+			parts = parts[:i]
+			break
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// RulesFile is a rules file loaded into memory. Reload replaces its rules
+// when the file changes on disk; requests being parsed at the time keep
+// using the old ones. It is safe for concurrent use.
+type RulesFile struct {
+	Path string
+
+	rules atomic.Pointer[Rules]
+
+	mu      sync.Mutex // Serializes reloads
+	modTime time.Time
+	size    int64
+}
+
+// OpenRules loads and validates the rules file at path.
+func OpenRules(path string) (*RulesFile, error) {
+	f := &RulesFile{Path: path}
+	if _, err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Reload loads the file again if its modification time or size changed
+// since it was last loaded, and reports whether it did. If the new file
+// cannot be read or is invalid, the previous rules stay in use; an invalid
+// file is reported once and not parsed again until it changes.
+func (f *RulesFile) Reload() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fi, err := os.Stat(f.Path)
+	if err != nil {
+		return false, err
+	}
+	if f.rules.Load() != nil && fi.ModTime().Equal(f.modTime) && fi.Size() == f.size {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return false, err
+	}
+	r, err := ParseRules(data)
+	if err != nil {
+		// An invalid file is only tried again once it changes
+		f.modTime, f.size = fi.ModTime(), fi.Size()
+		return false, fmt.Errorf("%s: %w", f.Path, err)
+	}
+
+	f.rules.Store(r)
+	f.modTime, f.size = fi.ModTime(), fi.Size()
+	return true, nil
+}
+
+// Rules returns the loaded rules.
+func (f *RulesFile) Rules() *Rules {
+	return f.rules.Load()
+}
+
+// Watch reloads the file when it changes, checking every interval until
+// stop is closed. Failures are logged; an invalid file is retried once it
+// changes.
+func (f *RulesFile) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		f.Check()
+	}
+}
+
+// Check reloads the file if it changed, logging the outcome.
+func (f *RulesFile) Check() {
+	reloaded, err := f.Reload()
+	switch {
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		log.Printf("User-agent rules reload failed: %v", err)
+	case err != nil:
+		log.Printf("User-agent rules %s are missing; keeping the loaded rules", f.Path)
+	case reloaded:
+		log.Printf("Reloaded user-agent rules %s (%d rules)", f.Path, f.Rules().Len())
+	}
+}
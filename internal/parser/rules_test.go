@@ -0,0 +1,274 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRules = `# Rules in the layout of uap-core's regexes.yaml
+user_agent_parsers:
+  - regex: '(Lynx)/(\d+)\.(\d+)\.(\d+)(?:rel\.(\d+))?'
+
+  # Replacements name groups with $n
+  - regex: 'Example(Browser)/(\d+)\.(\d+)'
+    family_replacement: "Example $1"
+    v2_replacement: '$3 beta'
+  - regex: 'It''s'
+    family_replacement: 'Quoted' # a comment
+
+os_parsers:
+  - regex: '(ExampleOS) (\d+)_(\d+)'
+  - regex: 'example-tv'
+    os_replacement: 'TVOS'
+    os_v1_replacement: '7'
+
+device_parsers:
+  - regex: 'GENERIC'
+    device_replacement: 'Generic Smartphone'
+    brand_replacement: 'Generic'
+    model_replacement: 'Smartphone'
+  - regex: '; (pixel \d+)'
+    regex_flag: 'i'
+    brand_replacement: 'Google'
+`
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	if rules.Len() != 7 {
+		t.Errorf("Len() = %d, want 7", rules.Len())
+	}
+
+	tests := []struct {
+		ua          string
+		wantBrowser string
+		wantVersion string
+		wantOS      string
+		wantOSVer   string
+	}{
+		{"Lynx/2.8.9rel.1 libwww-FM/2.14", "Lynx", "2.8.9.1", "Unknown", ""},
+		{"ExampleBrowser/3.1 (ExampleOS 5_2)", "Example Browser", "3.1 beta", "ExampleOS", "5.2"},
+		{"It's example-tv", "Quoted", "", "TVOS", "7"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0.0.0", "Unknown", "", "Unknown", ""},
+	}
+	for _, tt := range tests {
+		got := rules.ParseUserAgent(tt.ua)
+		if got.BrowserName != tt.wantBrowser || got.BrowserVersion != tt.wantVersion {
+			t.Errorf("ParseUserAgent(%q) browser = %q %q, want %q %q", tt.ua, got.BrowserName, got.BrowserVersion, tt.wantBrowser, tt.wantVersion)
+		}
+		if got.OSName != tt.wantOS || got.OSVersion != tt.wantOSVer {
+			t.Errorf("ParseUserAgent(%q) OS = %q %q, want %q %q", tt.ua, got.OSName, got.OSVersion, tt.wantOS, tt.wantOSVer)
+		}
+	}
+
+	// Bots are detected whatever the rules
+	if got := rules.ParseUserAgent("curl/8.4.0"); got.BrowserName != "curl" || !got.CLI {
+		t.Errorf("ParseUserAgent(curl) = %q, CLI %v; want curl", got.BrowserName, got.CLI)
+	}
+
+	// Device rules replace the built-in vendor and model, except generic ones
+	got := rules.ParseUserAgent("Mozilla/5.0 (Linux; Android 14; PIXEL 8) Mobile")
+	if got.Device != DeviceMobile || got.Vendor != "Google" || got.Model != "PIXEL 8" {
+		t.Errorf("device = %q %q %q, want mobile Google PIXEL 8", got.Device, got.Vendor, got.Model)
+	}
+	got = rules.ParseUserAgent("Mozilla/5.0 (Linux; Android 14; SM-S918B; GENERIC) Mobile")
+	if got.Vendor != "Samsung" || got.Model != "SM-S918B" {
+		t.Errorf("device = %q %q, want the built-in Samsung SM-S918B", got.Vendor, got.Model)
+	}
+}
+
+// uapCoreRules is an excerpt of uap-core's regexes.yaml, kept in its own
+// idioms: empty alternatives for missing version parts and devices named by
+// their groups.
+const uapCoreRules = `user_agent_parsers:
+  # Edge
+  - regex: '(Edge?)/(\d+)(?:\.(\d+)|)(?:\.(\d+)|)(?:\.(\d+)|)'
+    family_replacement: 'Edge'
+
+  # Chrome Mobile
+  - regex: '(Chrome)/(\d+)\.(\d+)\.(\d+)\.(\d+) Mobile(?:[ /]|$)'
+    family_replacement: 'Chrome Mobile'
+
+  # Chrome/Chromium/major_version.minor_version
+  - regex: '(Chromium|Chrome)/(\d+)\.(\d+)(?:\.(\d+)|)(?:\.(\d+)|)'
+
+os_parsers:
+  - regex: '(Android)[ \-/](\d+)(?:\.(\d+)|)(?:[.\-]([a-z0-9]+)|)'
+
+  - regex: '(Windows NT 6\.1)'
+    os_replacement: 'Windows'
+    os_v1_replacement: '7'
+  - regex: '(Windows NT 10\.0)'
+    os_replacement: 'Windows'
+    os_v1_replacement: '10'
+
+device_parsers:
+  #########
+  # Fairphone
+  #########
+  - regex: '; {0,2}(Fairphone) ?([^;/]+?)(?: Build|\) AppleWebKit)'
+    regex_flag: 'i'
+    device_replacement: '$1 $2'
+    brand_replacement: '$1'
+    model_replacement: '$2'
+`
+
+func TestParseRules_UAPCore(t *testing.T) {
+	rules, err := ParseRules([]byte(uapCoreRules))
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+
+	windowsHints := &ClientHintsInfo{Platform: "Windows", PlatformVersion: "15.0.0"}
+	tests := []struct {
+		name        string
+		ua          string
+		hints       *ClientHintsInfo
+		wantBrowser string
+		wantVersion string
+		wantOS      string
+		wantOSVer   string
+		wantVendor  string
+		wantModel   string
+	}{
+		{
+			name:        "Chrome on Windows",
+			ua:          "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			wantBrowser: "Chrome", wantVersion: "120.0.0.0", wantOS: "Windows", wantOSVer: "10",
+		},
+		{
+			// The hints name the release, which replaces the rule's "10"
+			name:        "Chrome on Windows with hints",
+			ua:          "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			hints:       windowsHints,
+			wantBrowser: "Chrome", wantVersion: "120.0.0.0", wantOS: "Windows 11",
+		},
+		{
+			name:        "Edge on Windows with hints",
+			ua:          "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			hints:       windowsHints,
+			wantBrowser: "Edge", wantVersion: "120.0.2210.91", wantOS: "Windows 11",
+		},
+		{
+			name:        "Chrome Mobile on a Fairphone with hints",
+			ua:          "Mozilla/5.0 (Linux; Android 13; Fairphone 4 Build/TKQ1.230127.002) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			hints:       &ClientHintsInfo{Platform: "Android", PlatformVersion: "13.0.0"},
+			wantBrowser: "Chrome Mobile", wantVersion: "120.0.6099.144", wantOS: "Android", wantOSVer: "13.0.0",
+			wantVendor: "Fairphone", wantModel: "4",
+		},
+	}
+	for _, tt := range tests {
+		got := rules.ParseUserAgent(tt.ua)
+		got.MergeClientHints(tt.hints)
+		if got.BrowserName != tt.wantBrowser || got.BrowserVersion != tt.wantVersion {
+			t.Errorf("%s: browser = %q %q, want %q %q", tt.name, got.BrowserName, got.BrowserVersion, tt.wantBrowser, tt.wantVersion)
+		}
+		if got.OSName != tt.wantOS || got.OSVersion != tt.wantOSVer {
+			t.Errorf("%s: OS = %q %q, want %q %q", tt.name, got.OSName, got.OSVersion, tt.wantOS, tt.wantOSVer)
+		}
+		if got.Vendor != tt.wantVendor || got.Model != tt.wantModel {
+			t.Errorf("%s: device = %q %q, want %q %q", tt.name, got.Vendor, got.Model, tt.wantVendor, tt.wantModel)
+		}
+	}
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		want  string
+	}{
+		{"no browser rules", "os_parsers:\n  - regex: '(Linux)'\n", "no user_agent_parsers"},
+		{"unknown section", "user_agent_parsers:\n  - regex: '(a)'\nbots:\n", `unknown section "bots"`},
+		{"bad regex", "user_agent_parsers:\n  - regex: '(a)'\n  - regex: '(b'\n", "line 3: error parsing regexp"},
+		{"missing regex", "user_agent_parsers:\n  - family_replacement: 'A'\n", "line 2: rule has no regex"},
+		{"unknown key", "user_agent_parsers:\n  - regex: '(a)'\n    os_replacement: 'A'\n", `line 2: unknown key "os_replacement"`},
+		{"group out of range", "user_agent_parsers:\n  - regex: '(a)'\n    v1_replacement: '$2'\n", "line 2: v1_replacement refers to $2, but the regex has 1 groups"},
+		{"no name", "user_agent_parsers:\n  - regex: 'a'\n", "line 2: regex has no group and no family_replacement"},
+		{"bad flag", "user_agent_parsers:\n  - regex: '(a)'\ndevice_parsers:\n  - regex: 'b'\n    regex_flag: 'x'\n", `line 4: unknown regex_flag "x"`},
+		{"unterminated", "user_agent_parsers:\n  - regex: '(a)\n", "line 2: unterminated quoted string"},
+		{"trailing text", "user_agent_parsers:\n  - regex: '(a)' b\n", `line 2: unexpected "b" after quoted string`},
+		{"block value", "user_agent_parsers:\n  - regex: >\n      (a)\n", "line 2: unsupported value"},
+		{"not a list", "user_agent_parsers: '(a)'\n", "line 1: user_agent_parsers must be a list of rules"},
+		{"no item", "user_agent_parsers:\n    regex: '(a)'\n", "line 2: expected a rule starting with -"},
+		{"repeated key", "user_agent_parsers:\n  - regex: '(a)'\n    regex: '(b)'\n", "line 3: regex is set twice"},
+	}
+	for _, tt := range tests {
+		_, err := ParseRules([]byte(tt.rules))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: ParseRules() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRulesFile_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regexes.yaml")
+	writeRules(t, path, "user_agent_parsers:\n  - regex: '(Lynx)/(\\d+)'\n")
+
+	f, err := OpenRules(path)
+	if err != nil {
+		t.Fatalf("OpenRules() error = %v", err)
+	}
+	if got := f.Rules().ParseUserAgent("Lynx/2.8.9"); got.BrowserName != "Lynx" {
+		t.Errorf("BrowserName = %q, want Lynx", got.BrowserName)
+	}
+
+	if reloaded, err := f.Reload(); reloaded || err != nil {
+		t.Errorf("Reload() of an unchanged file = %v, %v; want false, nil", reloaded, err)
+	}
+
+	writeRules(t, path, "user_agent_parsers:\n  - regex: 'Lynx/(\\d+)'\n    family_replacement: 'Text browser'\n")
+	touch(t, path, time.Now().Add(time.Minute))
+
+	if reloaded, err := f.Reload(); !reloaded || err != nil {
+		t.Fatalf("Reload() of a changed file = %v, %v; want true, nil", reloaded, err)
+	}
+	if got := f.Rules().ParseUserAgent("Lynx/2.8.9"); got.BrowserName != "Text browser" {
+		t.Errorf("BrowserName after reload = %q, want %q", got.BrowserName, "Text browser")
+	}
+
+	writeRules(t, path, "user_agent_parsers:\n  - regex: '(Lynx'\n")
+	touch(t, path, time.Now().Add(2*time.Minute))
+
+	if _, err := f.Reload(); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Reload() of an invalid file error = %v, want one naming the file", err)
+	}
+	if got := f.Rules().ParseUserAgent("Lynx/2.8.9"); got.BrowserName != "Text browser" {
+		t.Errorf("BrowserName after a failed reload = %q, want the previous rules", got.BrowserName)
+	}
+
+	// The invalid file is reported once, until it changes again
+	if reloaded, err := f.Reload(); reloaded || err != nil {
+		t.Errorf("Reload() of the unchanged invalid file = %v, %v; want false, nil", reloaded, err)
+	}
+	writeRules(t, path, "user_agent_parsers:\n  - regex: '(Lynx)/(\\d+)'\n")
+	touch(t, path, time.Now().Add(3*time.Minute))
+	if reloaded, err := f.Reload(); !reloaded || err != nil {
+		t.Errorf("Reload() of the fixed file = %v, %v; want true, nil", reloaded, err)
+	}
+
+	if _, err := OpenRules(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("OpenRules() should fail for a missing file")
+	}
+}
+
+func writeRules(t *testing.T, path, rules string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// touch sets the modification time explicitly, since a rewrite within the
+// file system's timestamp granularity would otherwise go unnoticed.
+func touch(t *testing.T, path string, mtime time.Time) {
+	t.Helper()
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
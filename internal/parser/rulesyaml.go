@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ruleEntry is a rule as written in a rules file: its keys and values, and
+// the line it starts on.
+type ruleEntry struct {
+	line   int
+	fields map[string]string
+}
+
+// decodeRules decodes the subset of YAML that regexes.yaml files use:
+// top-level sections, each a list of rules whose values are quoted or
+// plain strings on a single line. Comments and blank lines are skipped.
+func decodeRules(data []byte) (map[string][]ruleEntry, error) {
+	sections := make(map[string][]ruleEntry)
+	var section string
+	for n, line := range strings.Split(string(data), "\n") {
+		n++
+		line = strings.TrimRight(line, " \t\r")
+		text := strings.TrimLeft(line, " ")
+		if text == "" || text[0] == '#' || line == "---" {
+			continue
+		}
+		if text[0] == '\t' {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", n)
+		}
+
+		// A section starts at the beginning of a line
+		if text == line {
+			name, value, ok := strings.Cut(line, ":")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected a section name", n)
+			}
+			if i := strings.Index(value, "#"); i >= 0 {
+				value = value[:i]
+			}
+			if value = strings.TrimSpace(value); value != "" && value != "[]" {
+				return nil, fmt.Errorf("line %d: %s must be a list of rules", n, name)
+			}
+			if _, ok := sections[name]; ok {
+				return nil, fmt.Errorf("line %d: section %s is repeated", n, name)
+			}
+			section = name
+			sections[section] = []ruleEntry{}
+			continue
+		}
+
+		if section == "" {
+			return nil, fmt.Errorf("line %d: rule outside a section", n)
+		}
+		if item, ok := strings.CutPrefix(text, "-"); ok && (item == "" || item[0] == ' ') {
+			sections[section] = append(sections[section], ruleEntry{line: n, fields: make(map[string]string)})
+			if text = strings.TrimSpace(item); text == "" {
+				continue
+			}
+		} else if len(sections[section]) == 0 {
+			return nil, fmt.Errorf("line %d: expected a rule starting with -", n)
+		}
+
+		key, raw, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", n)
+		}
+		key = strings.TrimSpace(key)
+		value, err := decodeScalar(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		fields := sections[section][len(sections[section])-1].fields
+		if _, ok := fields[key]; ok {
+			return nil, fmt.Errorf("line %d: %s is set twice", n, key)
+		}
+		fields[key] = value
+	}
+	return sections, nil
+}
+
+// Escapes of double-quoted YAML strings that regexes.yaml files use.
+var yamlEscapes = map[byte]byte{'\\': '\\', '"': '"', '/': '/', 'n': '\n', 't': '\t'}
+
+// decodeScalar decodes a single-quoted, double-quoted or plain YAML string,
+// which may be followed by a comment.
+func decodeScalar(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	var b strings.Builder
+	switch s[0] {
+	case '\'':
+		// Quotes are escaped by doubling them
+		for i := 1; i < len(s); i++ {
+			switch {
+			case s[i] != '\'':
+				b.WriteByte(s[i])
+			case i+1 < len(s) && s[i+1] == '\'':
+				b.WriteByte('\'')
+				i++
+			default:
+				return b.String(), onlyComment(s[i+1:])
+			}
+		}
+	case '"':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				if i++; i == len(s) {
+					break
+				}
+				c, ok := yamlEscapes[s[i]]
+				if !ok {
+					return "", fmt.Errorf(`unsupported escape \%c`, s[i])
+				}
+				b.WriteByte(c)
+			case '"':
+				return b.String(), onlyComment(s[i+1:])
+			default:
+				b.WriteByte(s[i])
+			}
+		}
+	case '[', '{', '|', '>', '&', '*', '!':
+		return "", fmt.Errorf("unsupported value %q", s)
+	default:
+		if i := strings.Index(s, " #"); i >= 0 {
+			s = s[:i]
+		}
+		return strings.TrimSpace(s), nil
+	}
+	return "", errors.New("unterminated quoted string")
+}
+
+// onlyComment checks that nothing but a comment follows a quoted string.
+func onlyComment(s string) error {
+	if s = strings.TrimSpace(s); s != "" && s[0] != '#' {
+		return fmt.Errorf("unexpected %q after quoted string", s)
+	}
+	return nil
+}
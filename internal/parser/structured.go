@@ -0,0 +1,297 @@
+package parser
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// Structured field values (RFC 8941), which the Client Hints headers use.
+// Only parsing is supported, and dictionaries are not, as no header read
+// here is one.
+
+// sfItem is an item or inner list of a structured field.
+type sfItem struct {
+	Value  any // string, sfToken, int64, float64, bool, []byte, or []sfItem for an inner list
+	Params []sfParam
+}
+
+// sfToken is a token, which unlike a string is not quoted.
+type sfToken string
+
+// sfParam is a parameter of an item or inner list.
+type sfParam struct {
+	Key   string
+	Value any // A bare item, as in sfItem
+}
+
+// Param returns the value of the parameter key.
+func (it sfItem) Param(key string) (any, bool) {
+	for _, p := range it.Params {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+// String returns the value of a string or token item, or an empty string.
+func (it sfItem) String() string {
+	return sfString(it.Value)
+}
+
+func sfString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case sfToken:
+		return string(v)
+	}
+	return ""
+}
+
+// parseSFList parses a structured field list (RFC 8941, section 4.2.1).
+func parseSFList(s string) ([]sfItem, bool) {
+	p := &sfParser{s: strings.Trim(s, " ")}
+	var list []sfItem
+	for !p.done() {
+		item, ok := p.itemOrInnerList()
+		if !ok {
+			return nil, false
+		}
+		list = append(list, item)
+		p.skipOWS()
+		if p.done() {
+			break
+		}
+		if !p.consume(',') {
+			return nil, false
+		}
+		p.skipOWS()
+		if p.done() {
+			return nil, false // Trailing comma
+		}
+	}
+	return list, true
+}
+
+// parseSFItem parses a structured field item (RFC 8941, section 4.2.3).
+func parseSFItem(s string) (sfItem, bool) {
+	p := &sfParser{s: strings.Trim(s, " ")}
+	item, ok := p.item()
+	if !ok || !p.done() {
+		return sfItem{}, false
+	}
+	return item, true
+}
+
+type sfParser struct {
+	s string
+	i int
+}
+
+func (p *sfParser) done() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *sfParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *sfParser) consume(c byte) bool {
+	if p.done() || p.s[p.i] != c {
+		return false
+	}
+	p.i++
+	return true
+}
+
+func (p *sfParser) skipSP() {
+	for p.peek() == ' ' {
+		p.i++
+	}
+}
+
+func (p *sfParser) skipOWS() {
+	for c := p.peek(); c == ' ' || c == '\t'; c = p.peek() {
+		p.i++
+	}
+}
+
+func (p *sfParser) itemOrInnerList() (sfItem, bool) {
+	if p.peek() != '(' {
+		return p.item()
+	}
+	p.i++
+	var inner []sfItem
+	for !p.done() {
+		p.skipSP()
+		if p.consume(')') {
+			params, ok := p.params()
+			return sfItem{Value: inner, Params: params}, ok
+		}
+		item, ok := p.item()
+		if !ok {
+			return sfItem{}, false
+		}
+		inner = append(inner, item)
+		if c := p.peek(); c != ' ' && c != ')' {
+			return sfItem{}, false
+		}
+	}
+	return sfItem{}, false
+}
+
+func (p *sfParser) item() (sfItem, bool) {
+	value, ok := p.bareItem()
+	if !ok {
+		return sfItem{}, false
+	}
+	params, ok := p.params()
+	return sfItem{Value: value, Params: params}, ok
+}
+
+func (p *sfParser) params() ([]sfParam, bool) {
+	var params []sfParam
+	for p.consume(';') {
+		p.skipSP()
+		key, ok := p.key()
+		if !ok {
+			return nil, false
+		}
+		var value any = true
+		if p.consume('=') {
+			if value, ok = p.bareItem(); !ok {
+				return nil, false
+			}
+		}
+		params = append(params, sfParam{Key: key, Value: value})
+	}
+	return params, true
+}
+
+func (p *sfParser) key() (string, bool) {
+	start := p.i
+	if c := p.peek(); !(c >= 'a' && c <= 'z' || c == '*') {
+		return "", false
+	}
+	for c := p.peek(); c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("_-.*", c) >= 0; c = p.peek() {
+		p.i++
+	}
+	return p.s[start:p.i], true
+}
+
+func (p *sfParser) bareItem() (any, bool) {
+	switch c := p.peek(); {
+	case c == '-' || c >= '0' && c <= '9':
+		return p.number()
+	case c == '"':
+		return p.string()
+	case c == '*' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		return p.token(), true
+	case c == ':':
+		return p.byteSequence()
+	case c == '?':
+		return p.boolean()
+	}
+	return nil, false
+}
+
+// number parses an integer of up to 15 digits or a decimal with up to 12
+// integer and 3 fractional digits.
+func (p *sfParser) number() (any, bool) {
+	start := p.i
+	p.consume('-')
+	digits, dot := 0, -1
+	for !p.done() {
+		c := p.peek()
+		if c == '.' && dot < 0 {
+			if digits > 12 {
+				return nil, false
+			}
+			dot = digits
+		} else if c < '0' || c > '9' {
+			break
+		} else {
+			digits++
+		}
+		p.i++
+	}
+	num := p.s[start:p.i]
+	if dot < 0 {
+		if digits == 0 || digits > 15 {
+			return nil, false
+		}
+		n, err := strconv.ParseInt(num, 10, 64)
+		return n, err == nil
+	}
+	if fraction := digits - dot; dot == 0 || fraction == 0 || fraction > 3 {
+		return nil, false
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	return f, err == nil
+}
+
+func (p *sfParser) string() (any, bool) {
+	p.i++ // Opening quote
+	var b strings.Builder
+	for !p.done() {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == '\\':
+			if p.done() || p.peek() != '"' && p.peek() != '\\' {
+				return nil, false
+			}
+			b.WriteByte(p.s[p.i])
+			p.i++
+		case c == '"':
+			return b.String(), true
+		case c < 0x20 || c > 0x7e:
+			return nil, false
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return nil, false
+}
+
+func (p *sfParser) token() sfToken {
+	start := p.i
+	p.i++
+	for !p.done() && (isTokenChar(p.peek()) || p.peek() == ':' || p.peek() == '/') {
+		p.i++
+	}
+	return sfToken(p.s[start:p.i])
+}
+
+func (p *sfParser) byteSequence() (any, bool) {
+	p.i++ // Opening colon
+	end := strings.IndexByte(p.s[p.i:], ':')
+	if end < 0 {
+		return nil, false
+	}
+	b, err := base64.StdEncoding.DecodeString(p.s[p.i : p.i+end])
+	p.i += end + 1
+	return b, err == nil
+}
+
+func (p *sfParser) boolean() (any, bool) {
+	p.i++ // Question mark
+	switch {
+	case p.consume('1'):
+		return true, true
+	case p.consume('0'):
+		return false, true
+	}
+	return nil, false
+}
+
+// isTokenChar reports whether c is a tchar (RFC 9110, section 5.6.2).
+func isTokenChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
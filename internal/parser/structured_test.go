@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSFList(t *testing.T) {
+	list, ok := parseSFList(`"Chromium";v="120", tok/en;a;b=?0, 42, -1.5, :aGk=:, ("a" b);x=1, "q\"\\"`)
+	if !ok {
+		t.Fatal("parseSFList() failed")
+	}
+
+	want := []sfItem{
+		{Value: "Chromium", Params: []sfParam{{"v", "120"}}},
+		{Value: sfToken("tok/en"), Params: []sfParam{{"a", true}, {"b", false}}},
+		{Value: int64(42)},
+		{Value: -1.5},
+		{Value: []byte("hi")},
+		{Value: []sfItem{{Value: "a"}, {Value: sfToken("b")}}, Params: []sfParam{{"x", int64(1)}}},
+		{Value: `q"\`},
+	}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("parseSFList() = %#v, want %#v", list, want)
+	}
+}
+
+func TestParseSFList_Invalid(t *testing.T) {
+	for _, s := range []string{
+		`"a",`,
+		`"a" "b"`,
+		`"unterminated`,
+		`"bad\escape"`,
+		`1234567890123456`,
+		`1.2345`,
+		`?2`,
+		`a;B=1`,
+		`("a"`,
+		`:not base64:`,
+	} {
+		if list, ok := parseSFList(s); ok {
+			t.Errorf("parseSFList(%q) = %#v, want an error", s, list)
+		}
+	}
+}
+
+func TestParseSFItem(t *testing.T) {
+	tests := []struct {
+		in   string
+		want any
+		ok   bool
+	}{
+		{`"Windows"`, "Windows", true},
+		{` ?1 `, true, true},
+		{`?0`, false, true},
+		{`"a", "b"`, nil, false},
+		{``, nil, false},
+	}
+
+	for _, tt := range tests {
+		item, ok := parseSFItem(tt.in)
+		if ok != tt.ok || ok && !reflect.DeepEqual(item.Value, tt.want) {
+			t.Errorf("parseSFItem(%q) = %#v, %v; want %#v, %v", tt.in, item.Value, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -1,56 +1,93 @@
 package parser
 
-import (
-	"regexp"
-	"strings"
-)
+import "regexp"
 
 // UserAgentInfo contains parsed information from a User-Agent string.
 type UserAgentInfo struct {
 	Raw            string // Original User-Agent header
-	BrowserName    string // e.g., "Chrome", "Firefox", "Safari"
-	BrowserVersion string // e.g., "120.0"
-	OSName         string // e.g., "Windows 10", "macOS", "Linux"
+	BrowserName    string // e.g., "Chrome", "Firefox", "Safari"; the product for other clients, e.g., "Googlebot"
+	BrowserVersion string // Full version as sent, e.g., "120.0.6099.130"
+	Engine         string // Rendering engine: "Blink", "Gecko", "WebKit", "Trident", "Presto" or "EdgeHTML"
+	EngineVersion  string // e.g., "605.1.15"
+	OSName         string // e.g., "Windows 10/11", "macOS", "Ubuntu"
+	OSVersion      string // e.g., "14.1.0"; empty if unknown
+	Mobile         bool   // Whether the client reports a mobile device
+	Device         string // One of the Device* classes; empty if unknown
+	Vendor         string // Device vendor, e.g., "Samsung" or "Apple"
+	Model          string // Device model, e.g., "Pixel 7" or "SM-S918B"
+	Architecture   string // CPU architecture, e.g., "x86" or "arm"
+	Bitness        string // e.g., "64"
+	Category       string // One of the Category* constants
+	Bot            *Bot   // Well-known bot, monitor or crawler; nil otherwise
+	CLI            bool   // Whether the client is a command-line tool such as curl
 	Parsed         bool   // Whether parsing succeeded
+
+	Hints   *ClientHintsInfo // User-Agent Client Hints as sent; nil if none were
+	Sources UserAgentSources // Header each detected field came from
+}
+
+// SourceUserAgent is the source of fields detected from the User-Agent
+// string. Fields from Client Hints name their Hint* header as the source.
+const SourceUserAgent = "User-Agent"
+
+// UserAgentSources names the header each field of a UserAgentInfo was
+// detected from. Fields that were not detected have no source.
+type UserAgentSources struct {
+	Browser        string
+	BrowserVersion string
+	Engine         string
+	OS             string
+	OSVersion      string
+	Mobile         string
+	Device         string
+	Vendor         string
+	Model          string
+	Architecture   string
+	Bitness        string
 }
 
-// Browser patterns - order matters, check more specific patterns first
-var browserPatterns = []struct {
+// Command-line client patterns, checked before browser patterns
+var cliPatterns = []struct {
 	name    string
 	pattern *regexp.Regexp
 }{
-	{"Edge", regexp.MustCompile(`Edg(?:e|A|iOS)?/(\d+(?:\.\d+)?)`)},
-	{"Opera", regexp.MustCompile(`(?:OPR|Opera)[/ ](\d+(?:\.\d+)?)`)},
-	{"Chrome", regexp.MustCompile(`Chrome/(\d+(?:\.\d+)?)`)},
-	{"Firefox", regexp.MustCompile(`Firefox/(\d+(?:\.\d+)?)`)},
-	{"Safari", regexp.MustCompile(`Version/(\d+(?:\.\d+)?).*Safari`)},
+	{"curl", regexp.MustCompile(`^curl/(\d+(?:\.\d+)*)`)},
+	{"Wget", regexp.MustCompile(`^Wget/(\d+(?:\.\d+)*)`)},
+	{"HTTPie", regexp.MustCompile(`^HTTPie/(\d+(?:\.\d+)*)`)},
+	{"PowerShell", regexp.MustCompile(`\b(?:Windows)?PowerShell/(\d+(?:\.\d+)*)`)},
 }
 
-// OS patterns - order matters, check more specific patterns first
-var osPatterns = []struct {
+// linuxDistributions are the OS names of the built-in rules that Client
+// Hints call "Linux".
+var linuxDistributions = []string{"Ubuntu", "Fedora", "Debian", "Linux Mint", "openSUSE", "Arch Linux", "Manjaro", "CentOS", "Red Hat"}
+
+// CPU architecture patterns, in the names the User-Agent uses - order
+// matters: a 32-bit browser on a 64-bit system reports both
+var archPatterns = []struct {
 	name    string
 	pattern *regexp.Regexp
 }{
-	{"Windows 11", regexp.MustCompile(`Windows NT 10\.0.*Win64`)},
-	{"Windows 10", regexp.MustCompile(`Windows NT 10\.0`)},
-	{"Windows 8.1", regexp.MustCompile(`Windows NT 6\.3`)},
-	{"Windows 8", regexp.MustCompile(`Windows NT 6\.2`)},
-	{"Windows 7", regexp.MustCompile(`Windows NT 6\.1`)},
-	{"Windows", regexp.MustCompile(`Windows`)},
-	{"iOS", regexp.MustCompile(`iPhone|iPad|iPod`)},         // Check iOS before macOS (iOS UA contains "like Mac OS X")
-	{"macOS", regexp.MustCompile(`Mac OS X|Macintosh`)},
-	{"Android", regexp.MustCompile(`Android`)},
-	{"ChromeOS", regexp.MustCompile(`CrOS`)},                // Check ChromeOS before Linux (ChromeOS contains "Linux")
-	{"Linux", regexp.MustCompile(`Linux`)},
+	{"arm64", regexp.MustCompile(`\b(?:aarch64|arm64|ARM64)\b`)},
+	{"x86_64", regexp.MustCompile(`\b(?:x86_64|x64|amd64|Win64|WOW64)\b`)},
+	{"i686", regexp.MustCompile(`\b(?:i686|i386)\b`)},
+	{"armv7l", regexp.MustCompile(`\barmv7l?\b`)},
 }
 
-// ParseUserAgent parses a User-Agent string and extracts browser and OS information.
+// ParseUserAgent parses a User-Agent string with the built-in rules.
 func ParseUserAgent(ua string) UserAgentInfo {
+	return DefaultRules.ParseUserAgent(ua)
+}
+
+// ParseUserAgent parses a User-Agent string and extracts browser and OS
+// information. Bots, command-line clients, engines and device classes are
+// detected by the built-in patterns whatever the rules.
+func (r *Rules) ParseUserAgent(ua string) UserAgentInfo {
 	info := UserAgentInfo{
 		Raw:            ua,
 		BrowserName:    "Unknown",
 		BrowserVersion: "",
 		OSName:         "Unknown",
+		Category:       CategoryUnknown,
 		Parsed:         false,
 	}
 
@@ -58,38 +95,62 @@ func ParseUserAgent(ua string) UserAgentInfo {
 		return info
 	}
 
+	// Parse bots, libraries and other automated clients
+	matched := matchAgent(ua, &info)
+	if matched {
+		info.Parsed = true
+	}
+
+	// Parse command-line clients
+	for _, cp := range cliPatterns {
+		if matched {
+			break
+		}
+		if matches := cp.pattern.FindStringSubmatch(ua); matches != nil {
+			info.BrowserName = cp.name
+			info.BrowserVersion = matches[1]
+			info.Sources.Browser, info.Sources.BrowserVersion = SourceUserAgent, SourceUserAgent
+			info.Category, info.CLI = CategoryCLI, true
+			info.Parsed = true
+			break
+		}
+	}
+
 	// Parse browser
-	for _, bp := range browserPatterns {
-		if matches := bp.pattern.FindStringSubmatch(ua); matches != nil {
-			info.BrowserName = bp.name
-			if len(matches) > 1 {
-				info.BrowserVersion = matches[1]
+	if !matched && !info.CLI {
+		if fields := match(r.userAgent, ua); fields != nil && fields[0] != "" {
+			info.BrowserName, info.Category = fields[0], CategoryBrowser
+			info.Sources.Browser = SourceUserAgent
+			if version := joinVersion(fields[1:]); version != "" {
+				info.BrowserVersion = version
+				info.Sources.BrowserVersion = SourceUserAgent
 			}
 			info.Parsed = true
-			break
 		}
 	}
 
 	// Parse OS
-	for _, op := range osPatterns {
-		if op.pattern.MatchString(ua) {
-			info.OSName = op.name
-			info.Parsed = true
-			break
+	if fields := match(r.os, ua); fields != nil && fields[0] != "" {
+		info.OSName = fields[0]
+		info.Sources.OS = SourceUserAgent
+		if version := joinVersion(fields[1:]); version != "" {
+			info.OSVersion = version
+			info.Sources.OSVersion = SourceUserAgent
 		}
+		info.Parsed = true
 	}
 
-	// Special case: if we detect Safari but also Chrome, it's actually Chrome
-	// (Chrome includes Safari in its UA string)
-	if info.BrowserName == "Safari" && strings.Contains(ua, "Chrome") {
-		// Re-check for Chrome
-		if matches := browserPatterns[2].pattern.FindStringSubmatch(ua); matches != nil {
-			info.BrowserName = "Chrome"
-			if len(matches) > 1 {
-				info.BrowserVersion = matches[1]
-			}
+	// Parse architecture
+	for _, ap := range archPatterns {
+		if ap.pattern.MatchString(ua) {
+			info.Architecture = ap.name
+			info.Sources.Architecture = SourceUserAgent
+			break
 		}
 	}
 
+	detectEngine(ua, &info)
+	detectDevice(ua, &info, r.device)
+
 	return info
 }
@@ -4,19 +4,20 @@ import "testing"
 
 func TestParseUserAgent(t *testing.T) {
 	tests := []struct {
-		name            string
-		ua              string
-		wantBrowser     string
-		wantVersion     string
-		wantOS          string
-		wantParsed      bool
+		name        string
+		ua          string
+		wantBrowser string
+		wantVersion string
+		wantOS      string
+		wantCLI     bool
+		wantParsed  bool
 	}{
 		{
 			name:        "Chrome on Windows",
 			ua:          "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
 			wantBrowser: "Chrome",
-			wantVersion: "120.0",
-			wantOS:      "Windows 11",
+			wantVersion: "120.0.0.0",
+			wantOS:      "Windows 10/11",
 			wantParsed:  true,
 		},
 		{
@@ -39,23 +40,23 @@ func TestParseUserAgent(t *testing.T) {
 			name:        "Edge on Windows",
 			ua:          "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
 			wantBrowser: "Edge",
-			wantVersion: "120.0",
-			wantOS:      "Windows 11",
+			wantVersion: "120.0.0.0",
+			wantOS:      "Windows 10/11",
 			wantParsed:  true,
 		},
 		{
 			name:        "Opera on Windows",
 			ua:          "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 OPR/106.0.0.0",
 			wantBrowser: "Opera",
-			wantVersion: "106.0",
-			wantOS:      "Windows 11",
+			wantVersion: "106.0.0.0",
+			wantOS:      "Windows 10/11",
 			wantParsed:  true,
 		},
 		{
 			name:        "Chrome on Android",
 			ua:          "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.43 Mobile Safari/537.36",
 			wantBrowser: "Chrome",
-			wantVersion: "120.0",
+			wantVersion: "120.0.6099.43",
 			wantOS:      "Android",
 			wantParsed:  true,
 		},
@@ -68,21 +69,29 @@ func TestParseUserAgent(t *testing.T) {
 			wantParsed:  true,
 		},
 		{
-			name:        "Chrome on Windows 10",
+			name:        "Chrome on 32-bit Windows",
 			ua:          "Mozilla/5.0 (Windows NT 10.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
 			wantBrowser: "Chrome",
-			wantVersion: "120.0",
-			wantOS:      "Windows 10",
+			wantVersion: "120.0.0.0",
+			wantOS:      "Windows 10/11",
 			wantParsed:  true,
 		},
 		{
 			name:        "Unknown browser",
-			ua:          "CustomBot/1.0",
+			ua:          "CustomClient/1.0",
 			wantBrowser: "Unknown",
 			wantVersion: "",
 			wantOS:      "Unknown",
 			wantParsed:  false,
 		},
+		{
+			name:        "Unknown bot",
+			ua:          "CustomBot/1.0",
+			wantBrowser: "CustomBot",
+			wantVersion: "1.0",
+			wantOS:      "Unknown",
+			wantParsed:  true,
+		},
 		{
 			name:        "Empty user agent",
 			ua:          "",
@@ -94,10 +103,47 @@ func TestParseUserAgent(t *testing.T) {
 		{
 			name:        "curl",
 			ua:          "curl/8.4.0",
-			wantBrowser: "Unknown",
-			wantVersion: "",
+			wantBrowser: "curl",
+			wantVersion: "8.4.0",
 			wantOS:      "Unknown",
-			wantParsed:  false,
+			wantCLI:     true,
+			wantParsed:  true,
+		},
+		{
+			name:        "Wget",
+			ua:          "Wget/1.21.4",
+			wantBrowser: "Wget",
+			wantVersion: "1.21.4",
+			wantOS:      "Unknown",
+			wantCLI:     true,
+			wantParsed:  true,
+		},
+		{
+			name:        "HTTPie",
+			ua:          "HTTPie/3.2.2",
+			wantBrowser: "HTTPie",
+			wantVersion: "3.2.2",
+			wantOS:      "Unknown",
+			wantCLI:     true,
+			wantParsed:  true,
+		},
+		{
+			name:        "PowerShell Invoke-WebRequest",
+			ua:          "Mozilla/5.0 (Windows NT 10.0; Microsoft Windows 10.0.19045; en-US) PowerShell/7.4.0",
+			wantBrowser: "PowerShell",
+			wantVersion: "7.4.0",
+			wantOS:      "Windows 10",
+			wantCLI:     true,
+			wantParsed:  true,
+		},
+		{
+			name:        "Windows PowerShell",
+			ua:          "Mozilla/5.0 (Windows NT; Windows NT 10.0; en-US) WindowsPowerShell/5.1.19041.1682",
+			wantBrowser: "PowerShell",
+			wantVersion: "5.1.19041.1682",
+			wantOS:      "Windows 10/11",
+			wantCLI:     true,
+			wantParsed:  true,
 		},
 	}
 
@@ -117,9 +163,114 @@ func TestParseUserAgent(t *testing.T) {
 			if result.OSName != tt.wantOS {
 				t.Errorf("OSName = %q, want %q", result.OSName, tt.wantOS)
 			}
+			if result.CLI != tt.wantCLI {
+				t.Errorf("CLI = %v, want %v", result.CLI, tt.wantCLI)
+			}
 			if result.Parsed != tt.wantParsed {
 				t.Errorf("Parsed = %v, want %v", result.Parsed, tt.wantParsed)
 			}
 		})
 	}
 }
+
+func TestParseUserAgent_OSVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		ua       string
+		wantOS   string
+		wantVer  string
+		wantArch string
+	}{
+		{
+			name:    "macOS",
+			ua:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			wantOS:  "macOS",
+			wantVer: "10.15.7",
+		},
+		{
+			name:    "macOS Firefox",
+			ua:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:121.0) Gecko/20100101 Firefox/121.0",
+			wantOS:  "macOS",
+			wantVer: "10.15",
+		},
+		{
+			name:    "iPhone",
+			ua:      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			wantOS:  "iOS",
+			wantVer: "17.2",
+		},
+		{
+			name:    "iPad",
+			ua:      "Mozilla/5.0 (iPad; CPU OS 16_6_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			wantOS:  "iOS",
+			wantVer: "16.6.1",
+		},
+		{
+			name:    "Android",
+			ua:      "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.43 Mobile Safari/537.36",
+			wantOS:  "Android",
+			wantVer: "14",
+		},
+		{
+			name:     "ChromeOS",
+			ua:       "Mozilla/5.0 (X11; CrOS x86_64 15633.69.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.6045.212 Safari/537.36",
+			wantOS:   "ChromeOS",
+			wantVer:  "15633.69.0",
+			wantArch: "x86_64",
+		},
+		{
+			name:     "Ubuntu",
+			ua:       "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			wantOS:   "Ubuntu",
+			wantArch: "x86_64",
+		},
+		{
+			name:     "Fedora",
+			ua:       "Mozilla/5.0 (X11; Fedora; Linux aarch64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			wantOS:   "Fedora",
+			wantArch: "arm64",
+		},
+		{
+			name:     "32-bit Linux",
+			ua:       "Mozilla/5.0 (X11; Linux i686; rv:109.0) Gecko/20100101 Firefox/115.0",
+			wantOS:   "Linux",
+			wantArch: "i686",
+		},
+		{
+			name:     "Windows",
+			ua:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			wantOS:   "Windows 10/11",
+			wantArch: "x86_64",
+		},
+		{
+			name:    "Windows 11 build",
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Microsoft Windows 10.0.22631; en-US) PowerShell/7.4.0",
+			wantOS:  "Windows 11",
+			wantVer: "10.0.22631",
+		},
+		{
+			name:    "Windows 10 build",
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Microsoft Windows 10.0.19045; en-US) PowerShell/7.4.0",
+			wantOS:  "Windows 10",
+			wantVer: "10.0.19045",
+		},
+		{
+			name:     "Windows 7",
+			ua:       "Mozilla/5.0 (Windows NT 6.1; WOW64; rv:52.0) Gecko/20100101 Firefox/52.0",
+			wantOS:   "Windows 7",
+			wantArch: "x86_64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseUserAgent(tt.ua)
+			if result.OSName != tt.wantOS || result.OSVersion != tt.wantVer {
+				t.Errorf("OS = %q %q, want %q %q", result.OSName, result.OSVersion, tt.wantOS, tt.wantVer)
+			}
+			if result.Architecture != tt.wantArch {
+				t.Errorf("Architecture = %q, want %q", result.Architecture, tt.wantArch)
+			}
+		})
+	}
+}
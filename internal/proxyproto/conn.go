@@ -0,0 +1,154 @@
+package proxyproto
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// DefaultHeaderTimeout bounds how long a connection may take to send its
+// PROXY protocol header.
+const DefaultHeaderTimeout = 5 * time.Second
+
+// Listener wraps a net.Listener and decodes PROXY protocol headers on
+// connections from allowed upstream addresses. Connections from other
+// addresses are passed through untouched, so their bytes are never
+// interpreted as a header.
+type Listener struct {
+	net.Listener
+	Allowed       []netip.Prefix // Upstreams permitted to send PROXY headers
+	HeaderTimeout time.Duration  // Zero means DefaultHeaderTimeout
+}
+
+// Accept waits for the next connection. The header of an allowed connection
+// is read lazily, on first use, so a slow upstream cannot stall the accept
+// loop.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if !l.allowed(c.RemoteAddr()) {
+		return c, nil
+	}
+
+	timeout := l.HeaderTimeout
+	if timeout == 0 {
+		timeout = DefaultHeaderTimeout
+	}
+	return &Conn{Conn: c, reader: bufio.NewReader(c), timeout: timeout}, nil
+}
+
+func (l *Listener) allowed(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	ip, ok := netip.AddrFromSlice(tcp.IP)
+	if !ok {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range l.Allowed {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Conn is a connection from an allowed upstream that must begin with a PROXY
+// protocol header. Its RemoteAddr and LocalAddr report the addresses from
+// the header.
+type Conn struct {
+	net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+
+	once   sync.Once
+	header *Header
+	err    error
+}
+
+// readHeader reads the header exactly once, under a deadline.
+func (c *Conn) readHeader() {
+	c.once.Do(func() {
+		if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+			c.err = err
+			return
+		}
+		c.header, c.err = ReadHeader(c.reader)
+		if err := c.Conn.SetReadDeadline(time.Time{}); err != nil && c.err == nil {
+			c.err = err
+		}
+	})
+}
+
+// Read reads application data following the header. A missing or malformed
+// header is returned as an error, which closes the connection for net/http.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the original client address from the header, or the
+// upstream's own address for LOCAL and UNKNOWN headers.
+func (c *Conn) RemoteAddr() net.Addr {
+	if c.Proxied() {
+		return net.TCPAddrFromAddrPort(c.header.Source)
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// LocalAddr returns the original destination address from the header, or
+// the socket's local address for LOCAL and UNKNOWN headers.
+func (c *Conn) LocalAddr() net.Addr {
+	if c.Proxied() {
+		return net.TCPAddrFromAddrPort(c.header.Destination)
+	}
+	return c.Conn.LocalAddr()
+}
+
+// PeerAddr returns the address of the upstream that sent the header.
+func (c *Conn) PeerAddr() net.Addr {
+	return c.Conn.RemoteAddr()
+}
+
+// Header returns the decoded header, or nil if it was missing or malformed.
+func (c *Conn) Header() *Header {
+	c.readHeader()
+	return c.header
+}
+
+// NetConn returns the underlying connection.
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// Proxied reports whether the header carries a usable client address, so
+// that RemoteAddr differs from PeerAddr.
+func (c *Conn) Proxied() bool {
+	c.readHeader()
+	return c.err == nil && c.header != nil && c.header.Command == "PROXY" && c.header.Source.IsValid()
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the PROXY protocol connection.
+func NewContext(ctx context.Context, c *Conn) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the PROXY protocol connection stored by NewContext,
+// or nil if the request did not arrive through one.
+func FromContext(ctx context.Context) *Conn {
+	c, _ := ctx.Value(contextKey{}).(*Conn)
+	return c
+}
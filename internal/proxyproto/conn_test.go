@@ -0,0 +1,126 @@
+package proxyproto
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// acceptOne starts a Listener on loopback, dials it, writes payload and
+// returns the accepted server-side connection.
+func acceptOne(t *testing.T, allowed []netip.Prefix, payload string) net.Conn {
+	t.Helper()
+
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	ln := &Listener{Listener: inner, Allowed: allowed, HeaderTimeout: 200 * time.Millisecond}
+	t.Cleanup(func() { ln.Close() })
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if _, err := io.WriteString(client, payload); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+var loopback = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+
+func TestListener_AllowedUpstream(t *testing.T) {
+	conn := acceptOne(t, loopback, "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\nhello")
+
+	pc, ok := conn.(*Conn)
+	if !ok {
+		t.Fatalf("Accept() returned %T, want *Conn", conn)
+	}
+	if got := conn.RemoteAddr().String(); got != "192.0.2.1:56324" {
+		t.Errorf("RemoteAddr() = %q, want %q", got, "192.0.2.1:56324")
+	}
+	if got := conn.LocalAddr().String(); got != "198.51.100.1:443" {
+		t.Errorf("LocalAddr() = %q, want %q", got, "198.51.100.1:443")
+	}
+	if got := pc.PeerAddr().String(); got[:10] != "127.0.0.1:" {
+		t.Errorf("PeerAddr() = %q, want loopback", got)
+	}
+	if !pc.Proxied() || pc.Header() == nil {
+		t.Errorf("Proxied() = %v, Header() = %v", pc.Proxied(), pc.Header())
+	}
+
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "hello" {
+		t.Errorf("Read() = %q, %v; want %q", buf, err, "hello")
+	}
+
+	ctx := NewContext(context.Background(), pc)
+	if FromContext(ctx) != pc {
+		t.Errorf("FromContext() did not return the stored connection")
+	}
+	if FromContext(context.Background()) != nil {
+		t.Errorf("FromContext() on an empty context should be nil")
+	}
+}
+
+func TestListener_DisallowedUpstreamPassesThrough(t *testing.T) {
+	conn := acceptOne(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n")
+
+	if _, ok := conn.(*Conn); ok {
+		t.Fatalf("connections from disallowed upstreams should not be wrapped")
+	}
+
+	buf := make([]byte, 6)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "PROXY " {
+		t.Errorf("Read() = %q, %v; header bytes should be passed through", buf, err)
+	}
+}
+
+func TestListener_MissingHeader(t *testing.T) {
+	conn := acceptOne(t, loopback, "GET / HTTP/1.1\r\n\r\n")
+
+	if _, err := conn.Read(make([]byte, 1)); err != ErrNoHeader {
+		t.Errorf("Read() error = %v, want ErrNoHeader", err)
+	}
+	if got := conn.RemoteAddr().String(); got[:10] != "127.0.0.1:" {
+		t.Errorf("RemoteAddr() = %q, want the real peer", got)
+	}
+}
+
+func TestListener_HeaderTimeout(t *testing.T) {
+	conn := acceptOne(t, loopback, "PROXY TCP4")
+
+	start := time.Now()
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Errorf("Read() should fail when the header never completes")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("header read took %v, want it bounded by the timeout", elapsed)
+	}
+}
+
+func TestConn_LocalCommand(t *testing.T) {
+	conn := acceptOne(t, loopback, string(v2Header(0x0, 0x00, nil, nil, false)))
+
+	pc := conn.(*Conn)
+	if pc.Proxied() {
+		t.Errorf("LOCAL connections should not be treated as proxied")
+	}
+	if pc.Header() == nil || pc.Header().Command != "LOCAL" {
+		t.Errorf("Header() = %+v, want LOCAL", pc.Header())
+	}
+	if conn.RemoteAddr().String() != pc.PeerAddr().String() {
+		t.Errorf("RemoteAddr() = %v, want the real peer %v", conn.RemoteAddr(), pc.PeerAddr())
+	}
+}
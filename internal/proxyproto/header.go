@@ -0,0 +1,369 @@
+// Package proxyproto implements the receiving side of the HAProxy PROXY
+// protocol (versions 1 and 2), which TCP load balancers use to pass the
+// original client address to the backend ahead of the application data.
+//
+// Specification: https://www.haproxy.org/download/2.9/doc/proxy-protocol.txt
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Header is a decoded PROXY protocol header.
+type Header struct {
+	Version     int            // 1 or 2
+	Command     string         // "PROXY", or "LOCAL" for connections the proxy made itself
+	Transport   string         // "TCP4", "TCP6", "UDP4", "UDP6", "UNIX", "UNIX-DGRAM" or "UNKNOWN"
+	Source      netip.AddrPort // Original client address; invalid if not conveyed
+	Destination netip.AddrPort // Original destination address; invalid if not conveyed
+	TLVs        []TLV          // Type-length-value extensions in received order (v2 only)
+
+	// Well-known TLVs, decoded
+	ALPN             string   // Application protocol negotiated by the proxy
+	Authority        string   // Host name the client asked for (usually TLS SNI)
+	UniqueID         string   // Connection ID, hex-encoded
+	NetNS            string   // Network namespace name
+	AWSVPCEndpointID string   // AWS PrivateLink VPC endpoint ID
+	CRC32CVerified   bool     // Whether a CRC32c checksum was present and matched
+	SSL              *SSLInfo // TLS details if the client connected over TLS to the proxy
+}
+
+// SSLInfo holds the contents of a PP2_TYPE_SSL TLV.
+type SSLInfo struct {
+	ClientSSL      bool   // Client connected over SSL/TLS
+	ClientCertConn bool   // Client presented a certificate on this connection
+	ClientCertSess bool   // Client presented a certificate at least once in this session
+	Verified       bool   // Client certificate verified successfully (verify field is zero)
+	Version        string // e.g., "TLSv1.3"
+	CN             string // Client certificate common name
+	Cipher         string // e.g., "ECDHE-RSA-AES128-GCM-SHA256"
+	SigAlg         string // Certificate signature algorithm
+	KeyAlg         string // Certificate key algorithm
+}
+
+// TLV is a raw PROXY protocol v2 type-length-value extension.
+type TLV struct {
+	Type  byte
+	Value []byte
+}
+
+// TLV types defined by the specification, plus the AWS extension.
+const (
+	TypeALPN      = 0x01
+	TypeAuthority = 0x02
+	TypeCRC32C    = 0x03
+	TypeNoop      = 0x04
+	TypeUniqueID  = 0x05
+	TypeSSL       = 0x20
+	TypeNetNS     = 0x30
+	TypeAWS       = 0xEA
+
+	subtypeSSLVersion = 0x21
+	subtypeSSLCN      = 0x22
+	subtypeSSLCipher  = 0x23
+	subtypeSSLSigAlg  = 0x24
+	subtypeSSLKeyAlg  = 0x25
+	subtypeAWSVPCE    = 0x01
+)
+
+// Name returns a human-readable name for the TLV type.
+func (t TLV) Name() string {
+	switch t.Type {
+	case TypeALPN:
+		return "ALPN"
+	case TypeAuthority:
+		return "AUTHORITY"
+	case TypeCRC32C:
+		return "CRC32C"
+	case TypeNoop:
+		return "NOOP"
+	case TypeUniqueID:
+		return "UNIQUE_ID"
+	case TypeSSL:
+		return "SSL"
+	case TypeNetNS:
+		return "NETNS"
+	case TypeAWS:
+		return "AWS"
+	default:
+		return fmt.Sprintf("0x%02X", t.Type)
+	}
+}
+
+var (
+	// ErrNoHeader is returned when the connection does not start with a
+	// PROXY protocol signature.
+	ErrNoHeader = errors.New("proxyproto: missing PROXY protocol header")
+
+	errMalformed = errors.New("proxyproto: malformed header")
+)
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// v1MaxLength is the longest valid v1 header, including the CRLF.
+const v1MaxLength = 107
+
+// ReadHeader reads and decodes a PROXY protocol header from r. Any bytes
+// after the header remain buffered in r.
+func ReadHeader(r *bufio.Reader) (*Header, error) {
+	sig, err := r.Peek(len(v1Prefix))
+	if err != nil {
+		return nil, ErrNoHeader
+	}
+	if bytes.Equal(sig, v1Prefix) {
+		return readV1(r)
+	}
+
+	sig, err = r.Peek(len(v2Signature))
+	if err != nil || !bytes.Equal(sig, v2Signature) {
+		return nil, ErrNoHeader
+	}
+	return readV2(r)
+}
+
+// readV1 decodes the text format, e.g. "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n".
+func readV1(r *bufio.Reader) (*Header, error) {
+	var line []byte
+	for len(line) < v1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errMalformed, err)
+		}
+		line = append(line, b)
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("%w: v1 header too long", errMalformed)
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	h := &Header{Version: 1, Command: "PROXY", Transport: fields[1]}
+
+	switch h.Transport {
+	case "UNKNOWN":
+		// The rest of the line is ignored; the receiver uses the real addresses
+		return h, nil
+	case "TCP4", "TCP6":
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("%w: v1 header needs 6 fields", errMalformed)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown v1 protocol %q", errMalformed, h.Transport)
+	}
+
+	src, err := parseV1Address(fields[2], fields[4], h.Transport)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := parseV1Address(fields[3], fields[5], h.Transport)
+	if err != nil {
+		return nil, err
+	}
+	h.Source, h.Destination = src, dst
+	return h, nil
+}
+
+func parseV1Address(ip, port, transport string) (netip.AddrPort, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || addr.Zone() != "" || addr.Is4() != (transport == "TCP4") {
+		return netip.AddrPort{}, fmt.Errorf("%w: bad %s address %q", errMalformed, transport, ip)
+	}
+	// Ports are decimal without leading zeros
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || (len(port) > 1 && port[0] == '0') {
+		return netip.AddrPort{}, fmt.Errorf("%w: bad port %q", errMalformed, port)
+	}
+	return netip.AddrPortFrom(addr, uint16(p)), nil
+}
+
+// readV2 decodes the binary format.
+func readV2(r *bufio.Reader) (*Header, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, fmt.Errorf("%w: %v", errMalformed, err)
+	}
+
+	verCmd, famProto := fixed[12], fixed[13]
+	length := binary.BigEndian.Uint16(fixed[14:16])
+
+	if verCmd>>4 != 2 {
+		return nil, fmt.Errorf("%w: unsupported version %d", errMalformed, verCmd>>4)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", errMalformed, err)
+	}
+
+	h := &Header{Version: 2}
+	switch verCmd & 0x0F {
+	case 0x0:
+		h.Command = "LOCAL"
+	case 0x1:
+		h.Command = "PROXY"
+	default:
+		return nil, fmt.Errorf("%w: unknown command 0x%X", errMalformed, verCmd&0x0F)
+	}
+
+	var addrLen int
+	switch famProto {
+	case 0x11:
+		h.Transport, addrLen = "TCP4", 12
+	case 0x12:
+		h.Transport, addrLen = "UDP4", 12
+	case 0x21:
+		h.Transport, addrLen = "TCP6", 36
+	case 0x22:
+		h.Transport, addrLen = "UDP6", 36
+	case 0x31:
+		h.Transport, addrLen = "UNIX", 216
+	case 0x32:
+		h.Transport, addrLen = "UNIX-DGRAM", 216
+	default:
+		h.Transport = "UNKNOWN"
+	}
+	if len(payload) < addrLen {
+		return nil, fmt.Errorf("%w: address block too short for %s", errMalformed, h.Transport)
+	}
+
+	addrs := payload[:addrLen]
+	switch addrLen {
+	case 12:
+		h.Source = netip.AddrPortFrom(netip.AddrFrom4([4]byte(addrs[0:4])), binary.BigEndian.Uint16(addrs[8:10]))
+		h.Destination = netip.AddrPortFrom(netip.AddrFrom4([4]byte(addrs[4:8])), binary.BigEndian.Uint16(addrs[10:12]))
+	case 36:
+		h.Source = netip.AddrPortFrom(netip.AddrFrom16([16]byte(addrs[0:16])), binary.BigEndian.Uint16(addrs[32:34]))
+		h.Destination = netip.AddrPortFrom(netip.AddrFrom16([16]byte(addrs[16:32])), binary.BigEndian.Uint16(addrs[34:36]))
+	}
+
+	tlvs, err := parseTLVs(payload[addrLen:])
+	if err != nil {
+		return nil, err
+	}
+	h.TLVs = tlvs
+
+	for _, tlv := range tlvs {
+		switch tlv.Type {
+		case TypeALPN:
+			h.ALPN = string(tlv.Value)
+		case TypeAuthority:
+			h.Authority = string(tlv.Value)
+		case TypeUniqueID:
+			h.UniqueID = hex.EncodeToString(tlv.Value)
+		case TypeNetNS:
+			h.NetNS = string(tlv.Value)
+		case TypeCRC32C:
+			if err := verifyCRC32C(fixed, payload, addrLen); err != nil {
+				return nil, err
+			}
+			h.CRC32CVerified = true
+		case TypeSSL:
+			ssl, err := parseSSL(tlv.Value)
+			if err != nil {
+				return nil, err
+			}
+			h.SSL = ssl
+		case TypeAWS:
+			if len(tlv.Value) > 0 && tlv.Value[0] == subtypeAWSVPCE {
+				h.AWSVPCEndpointID = string(tlv.Value[1:])
+			}
+		}
+	}
+
+	return h, nil
+}
+
+// parseTLVs splits a TLV block into its entries.
+func parseTLVs(b []byte) ([]TLV, error) {
+	var tlvs []TLV
+	for len(b) > 0 {
+		if len(b) < 3 {
+			return nil, fmt.Errorf("%w: truncated TLV", errMalformed)
+		}
+		n := int(binary.BigEndian.Uint16(b[1:3]))
+		if len(b) < 3+n {
+			return nil, fmt.Errorf("%w: TLV 0x%02X overruns header", errMalformed, b[0])
+		}
+		tlvs = append(tlvs, TLV{Type: b[0], Value: b[3 : 3+n]})
+		b = b[3+n:]
+	}
+	return tlvs, nil
+}
+
+// parseSSL decodes a PP2_TYPE_SSL value: a client flags byte, a 32-bit
+// verify result and nested sub-TLVs.
+func parseSSL(b []byte) (*SSLInfo, error) {
+	if len(b) < 5 {
+		return nil, fmt.Errorf("%w: SSL TLV too short", errMalformed)
+	}
+
+	ssl := &SSLInfo{
+		ClientSSL:      b[0]&0x01 != 0,
+		ClientCertConn: b[0]&0x02 != 0,
+		ClientCertSess: b[0]&0x04 != 0,
+		Verified:       binary.BigEndian.Uint32(b[1:5]) == 0,
+	}
+
+	subs, err := parseTLVs(b[5:])
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		switch sub.Type {
+		case subtypeSSLVersion:
+			ssl.Version = string(sub.Value)
+		case subtypeSSLCN:
+			ssl.CN = string(sub.Value)
+		case subtypeSSLCipher:
+			ssl.Cipher = string(sub.Value)
+		case subtypeSSLSigAlg:
+			ssl.SigAlg = string(sub.Value)
+		case subtypeSSLKeyAlg:
+			ssl.KeyAlg = string(sub.Value)
+		}
+	}
+	return ssl, nil
+}
+
+// verifyCRC32C checks a PP2_TYPE_CRC32C checksum, which covers the whole
+// header with the checksum value itself zeroed. tlvStart is the offset of the
+// TLV block within payload.
+func verifyCRC32C(fixed, payload []byte, tlvStart int) error {
+	zeroed := append([]byte(nil), payload...)
+
+	var want uint32
+	for i := tlvStart; i+3 <= len(zeroed); {
+		n := int(binary.BigEndian.Uint16(zeroed[i+1 : i+3]))
+		if zeroed[i] == TypeCRC32C {
+			if n != 4 {
+				return fmt.Errorf("%w: CRC32C TLV must be 4 bytes", errMalformed)
+			}
+			want = binary.BigEndian.Uint32(zeroed[i+3 : i+7])
+			copy(zeroed[i+3:i+7], []byte{0, 0, 0, 0})
+			break
+		}
+		i += 3 + n
+	}
+
+	table := crc32.MakeTable(crc32.Castagnoli)
+	got := crc32.Update(crc32.Checksum(fixed, table), table, zeroed)
+	if got != want {
+		return fmt.Errorf("%w: CRC32C mismatch", errMalformed)
+	}
+	return nil
+}
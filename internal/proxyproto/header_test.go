@@ -0,0 +1,210 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestReadHeader_V1(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		transport string
+		src, dst  string
+	}{
+		{"TCP4", "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n", "TCP4", "192.0.2.1:56324", "198.51.100.1:443"},
+		{"TCP6", "PROXY TCP6 2001:db8::1 2001:db8::2 4711 80\r\n", "TCP6", "[2001:db8::1]:4711", "[2001:db8::2]:80"},
+		{"UNKNOWN", "PROXY UNKNOWN ffff::1 ffff::2 1 2\r\n", "UNKNOWN", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tt.input + "GET / HTTP/1.1\r\n"))
+			h, err := ReadHeader(r)
+			if err != nil {
+				t.Fatalf("ReadHeader() error = %v", err)
+			}
+
+			if h.Version != 1 || h.Command != "PROXY" || h.Transport != tt.transport {
+				t.Errorf("header = %+v", h)
+			}
+			if tt.src != "" && (h.Source.String() != tt.src || h.Destination.String() != tt.dst) {
+				t.Errorf("Source/Destination = %v/%v, want %v/%v", h.Source, h.Destination, tt.src, tt.dst)
+			}
+
+			rest, _ := io.ReadAll(r)
+			if string(rest) != "GET / HTTP/1.1\r\n" {
+				t.Errorf("data after header = %q", rest)
+			}
+		})
+	}
+}
+
+func TestReadHeader_V1Malformed(t *testing.T) {
+	inputs := []string{
+		"PROXY TCP4 192.0.2.1 198.51.100.1 56324\r\n",
+		"PROXY TCP4 2001:db8::1 198.51.100.1 1 2\r\n",
+		"PROXY TCP4 192.0.2.1 198.51.100.1 056324 443\r\n",
+		"PROXY TCP4 192.0.2.1 198.51.100.1 65536 443\r\n",
+		"PROXY UDP4 192.0.2.1 198.51.100.1 1 2\r\n",
+		"PROXY TCP4 " + strings.Repeat("1", 120) + "\r\n",
+	}
+
+	for _, input := range inputs {
+		if _, err := ReadHeader(bufio.NewReader(strings.NewReader(input))); err == nil || errors.Is(err, ErrNoHeader) {
+			t.Errorf("ReadHeader(%q) error = %v, want malformed header error", input, err)
+		}
+	}
+}
+
+func TestReadHeader_NoHeader(t *testing.T) {
+	for _, input := range []string{"GET / HTTP/1.1\r\n\r\n", "", "\r\n\r\n\x00\r\nQUIX\n"} {
+		if _, err := ReadHeader(bufio.NewReader(strings.NewReader(input))); !errors.Is(err, ErrNoHeader) {
+			t.Errorf("ReadHeader(%q) error = %v, want ErrNoHeader", input, err)
+		}
+	}
+}
+
+// v2Header builds a binary header. If withCRC is set, a CRC32c TLV with the
+// correct checksum is appended.
+func v2Header(cmd, famProto byte, addrs []byte, tlvs []TLV, withCRC bool) []byte {
+	var payload bytes.Buffer
+	payload.Write(addrs)
+	for _, tlv := range tlvs {
+		payload.WriteByte(tlv.Type)
+		binary.Write(&payload, binary.BigEndian, uint16(len(tlv.Value)))
+		payload.Write(tlv.Value)
+	}
+	if withCRC {
+		payload.Write([]byte{TypeCRC32C, 0, 4, 0, 0, 0, 0})
+	}
+
+	var b bytes.Buffer
+	b.Write(v2Signature)
+	b.WriteByte(0x20 | cmd)
+	b.WriteByte(famProto)
+	binary.Write(&b, binary.BigEndian, uint16(payload.Len()))
+	b.Write(payload.Bytes())
+
+	out := b.Bytes()
+	if withCRC {
+		sum := crc32.Checksum(out, crc32.MakeTable(crc32.Castagnoli))
+		binary.BigEndian.PutUint32(out[len(out)-4:], sum)
+	}
+	return out
+}
+
+func TestReadHeader_V2(t *testing.T) {
+	addrs := []byte{192, 0, 2, 1, 198, 51, 100, 1, 0xDC, 0x04, 0x01, 0xBB}
+
+	var ssl bytes.Buffer
+	ssl.WriteByte(0x01 | 0x02)
+	ssl.Write([]byte{0, 0, 0, 0})
+	for _, sub := range []TLV{
+		{Type: subtypeSSLVersion, Value: []byte("TLSv1.3")},
+		{Type: subtypeSSLCN, Value: []byte("client.example")},
+		{Type: subtypeSSLCipher, Value: []byte("TLS_AES_128_GCM_SHA256")},
+	} {
+		ssl.WriteByte(sub.Type)
+		binary.Write(&ssl, binary.BigEndian, uint16(len(sub.Value)))
+		ssl.Write(sub.Value)
+	}
+
+	tlvs := []TLV{
+		{Type: TypeALPN, Value: []byte("h2")},
+		{Type: TypeAuthority, Value: []byte("example.com")},
+		{Type: TypeUniqueID, Value: []byte{0xDE, 0xAD, 0xBE, 0xEF}},
+		{Type: TypeSSL, Value: ssl.Bytes()},
+		{Type: TypeAWS, Value: append([]byte{subtypeAWSVPCE}, "vpce-0123456789abcdef0"...)},
+		{Type: 0xE0, Value: []byte("custom")},
+	}
+
+	input := append(v2Header(0x1, 0x11, addrs, tlvs, true), "GET / HTTP/1.1\r\n"...)
+	r := bufio.NewReader(bytes.NewReader(input))
+	h, err := ReadHeader(r)
+	if err != nil {
+		t.Fatalf("ReadHeader() error = %v", err)
+	}
+
+	if h.Version != 2 || h.Command != "PROXY" || h.Transport != "TCP4" {
+		t.Errorf("header = %+v", h)
+	}
+	if h.Source != netip.MustParseAddrPort("192.0.2.1:56324") || h.Destination != netip.MustParseAddrPort("198.51.100.1:443") {
+		t.Errorf("Source/Destination = %v/%v", h.Source, h.Destination)
+	}
+	if h.ALPN != "h2" || h.Authority != "example.com" || h.UniqueID != "deadbeef" || h.AWSVPCEndpointID != "vpce-0123456789abcdef0" {
+		t.Errorf("decoded TLVs = %+v", h)
+	}
+	if !h.CRC32CVerified {
+		t.Errorf("CRC32CVerified = false, want true")
+	}
+	if h.SSL == nil || !h.SSL.ClientSSL || !h.SSL.ClientCertConn || !h.SSL.Verified || h.SSL.Version != "TLSv1.3" || h.SSL.CN != "client.example" || h.SSL.Cipher != "TLS_AES_128_GCM_SHA256" {
+		t.Errorf("SSL = %+v", h.SSL)
+	}
+	if len(h.TLVs) != 7 || h.TLVs[5].Name() != "0xE0" || h.TLVs[6].Name() != "CRC32C" {
+		t.Errorf("TLVs = %+v", h.TLVs)
+	}
+
+	rest, _ := io.ReadAll(r)
+	if string(rest) != "GET / HTTP/1.1\r\n" {
+		t.Errorf("data after header = %q", rest)
+	}
+}
+
+func TestReadHeader_V2IPv6AndLocal(t *testing.T) {
+	src := netip.MustParseAddr("2001:db8::1").As16()
+	dst := netip.MustParseAddr("2001:db8::2").As16()
+	addrs := append(append(src[:], dst[:]...), 0x12, 0x67, 0x00, 0x50)
+
+	h, err := ReadHeader(bufio.NewReader(bytes.NewReader(v2Header(0x1, 0x21, addrs, nil, false))))
+	if err != nil {
+		t.Fatalf("ReadHeader() error = %v", err)
+	}
+	if h.Transport != "TCP6" || h.Source.String() != "[2001:db8::1]:4711" || h.Destination.String() != "[2001:db8::2]:80" {
+		t.Errorf("header = %+v", h)
+	}
+
+	h, err = ReadHeader(bufio.NewReader(bytes.NewReader(v2Header(0x0, 0x00, nil, nil, false))))
+	if err != nil {
+		t.Fatalf("ReadHeader() error = %v", err)
+	}
+	if h.Command != "LOCAL" || h.Transport != "UNKNOWN" || h.Source.IsValid() {
+		t.Errorf("LOCAL header = %+v", h)
+	}
+}
+
+func TestReadHeader_V2Malformed(t *testing.T) {
+	addrs := []byte{192, 0, 2, 1, 198, 51, 100, 1, 0, 1, 0, 2}
+
+	badCRC := v2Header(0x1, 0x11, addrs, nil, true)
+	badCRC[len(badCRC)-1] ^= 0xFF
+
+	truncatedTLV := v2Header(0x1, 0x11, addrs, []TLV{{Type: TypeALPN, Value: []byte("h2")}}, false)
+	binary.BigEndian.PutUint16(truncatedTLV[len(truncatedTLV)-4:], 10)
+
+	badVersion := v2Header(0x1, 0x11, addrs, nil, false)
+	badVersion[12] = 0x11
+
+	tests := map[string][]byte{
+		"CRC mismatch":        badCRC,
+		"TLV overrun":         truncatedTLV,
+		"short address block": v2Header(0x1, 0x21, addrs, nil, false),
+		"bad version":         badVersion,
+		"bad command":         v2Header(0x2, 0x11, addrs, nil, false),
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ReadHeader(bufio.NewReader(bytes.NewReader(input))); err == nil || errors.Is(err, ErrNoHeader) {
+				t.Errorf("ReadHeader() error = %v, want malformed header error", err)
+			}
+		})
+	}
+}
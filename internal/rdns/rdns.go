@@ -0,0 +1,183 @@
+// Package rdns looks up the reverse DNS names of IP addresses and checks
+// whether they are forward-confirmed: whether a PTR name resolves back to
+// the address it was found for.
+package rdns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lookup timeout and cache lifetimes. Failed lookups, including timeouts,
+// are cached briefly so a slow name server does not delay every request.
+const (
+	DefaultTimeout  = time.Second
+	DefaultCacheTTL = 10 * time.Minute
+	DefaultErrorTTL = 30 * time.Second
+	maxCacheEntries = 10000
+)
+
+// Result is the reverse DNS information for one address.
+type Result struct {
+	Names            []Name // PTR names, in the order the server returned them
+	ForwardConfirmed bool   // At least one name resolves back to the address
+	Error            string // Why the lookup failed, e.g. "timeout"; empty on success
+}
+
+// Name is a PTR name and whether it resolves back to the address.
+type Name struct {
+	Name      string // Host name without the trailing dot
+	Confirmed bool   // An A or AAAA record of Name is the looked-up address
+}
+
+// Resolver performs reverse DNS lookups and caches their results. It is
+// safe for concurrent use.
+type Resolver struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	errorTTL time.Duration
+	resolver *net.Resolver
+
+	mu    sync.Mutex
+	cache map[netip.Addr]cacheEntry
+}
+
+type cacheEntry struct {
+	result  Result
+	expires time.Time
+}
+
+// New creates a Resolver that queries server ("host:port"), or the system's
+// configured name servers if server is empty. Each lookup, including the
+// forward confirmation, is bounded by timeout; zero means DefaultTimeout.
+func New(server string, timeout time.Duration) *Resolver {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	r := &Resolver{
+		timeout:  timeout,
+		cacheTTL: DefaultCacheTTL,
+		errorTTL: DefaultErrorTTL,
+		cache:    make(map[netip.Addr]cacheEntry),
+		resolver: &net.Resolver{PreferGo: true},
+	}
+	if server != "" {
+		r.resolver.Dial = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		}
+	}
+	return r
+}
+
+// Lookup returns the reverse DNS information for addr, from the cache if a
+// recent result is available. It returns within the resolver's timeout even
+// if the name server does not answer.
+func (r *Resolver) Lookup(ctx context.Context, addr netip.Addr) Result {
+	addr = addr.Unmap().WithZone("")
+
+	now := time.Now()
+	r.mu.Lock()
+	entry, ok := r.cache[addr]
+	r.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.result
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	result := r.lookup(lookupCtx, addr)
+	if ctx.Err() != nil {
+		// The caller gave up; the result says nothing about the name server
+		return result
+	}
+
+	ttl := r.cacheTTL
+	if result.Error != "" {
+		ttl = r.errorTTL
+	}
+	r.store(addr, cacheEntry{result: result, expires: now.Add(ttl)})
+	return result
+}
+
+// lookup queries the PTR records of addr, then the addresses of every name
+// concurrently.
+func (r *Resolver) lookup(ctx context.Context, addr netip.Addr) Result {
+	names, err := r.resolver.LookupAddr(ctx, addr.String())
+	if err != nil && len(names) == 0 {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return Result{Names: []Name{}}
+		}
+		return Result{Names: []Name{}, Error: describe(err)}
+	}
+
+	result := Result{Names: make([]Name, len(names))}
+	var wg sync.WaitGroup
+	for i, name := range names {
+		result.Names[i].Name = strings.TrimSuffix(name, ".")
+		wg.Add(1)
+		go func(n *Name, fqdn string) {
+			defer wg.Done()
+			ips, err := r.resolver.LookupNetIP(ctx, "ip", fqdn)
+			if err != nil {
+				return
+			}
+			for _, ip := range ips {
+				if ip.Unmap() == addr {
+					n.Confirmed = true
+					return
+				}
+			}
+		}(&result.Names[i], name)
+	}
+	wg.Wait()
+
+	for _, n := range result.Names {
+		if n.Confirmed {
+			result.ForwardConfirmed = true
+		}
+	}
+	return result
+}
+
+// store caches an entry, discarding expired entries, or failing that all
+// entries, when the cache is full.
+func (r *Resolver) store(addr netip.Addr, entry cacheEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.cache) >= maxCacheEntries {
+		now := time.Now()
+		for a, e := range r.cache {
+			if now.After(e.expires) {
+				delete(r.cache, a)
+			}
+		}
+		if len(r.cache) >= maxCacheEntries {
+			r.cache = make(map[netip.Addr]cacheEntry)
+		}
+	}
+	r.cache[addr] = entry
+}
+
+// describe summarizes a lookup error for display.
+func describe(err error) string {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &dnsErr) && dnsErr.IsTimeout:
+		return "timeout"
+	case errors.As(err, &dnsErr):
+		return dnsErr.Err
+	default:
+		return err.Error()
+	}
+}
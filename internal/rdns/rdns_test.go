@@ -0,0 +1,232 @@
+package rdns
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// DNS record types used by the stub server.
+const (
+	typeA    = 1
+	typePTR  = 12
+	typeAAAA = 28
+)
+
+// stubServer is a minimal UDP DNS server answering from fixed PTR and
+// address tables. Unknown names get NXDOMAIN.
+type stubServer struct {
+	conn    net.PacketConn
+	ptr     map[string][]string     // "4.3.2.1.in-addr.arpa." -> names
+	addrs   map[string][]netip.Addr // "host.example." -> addresses
+	silent  bool                    // Never answer
+	queries atomic.Int32
+}
+
+func startStub(t *testing.T, s *stubServer) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	s.conn = conn
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			s.queries.Add(1)
+			if s.silent {
+				continue
+			}
+			if resp := s.answer(buf[:n]); resp != nil {
+				conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// answer builds the response to a query, or returns nil if it is malformed.
+func (s *stubServer) answer(query []byte) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+	name, end, ok := readName(query, 12)
+	if !ok || end+4 > len(query) {
+		return nil
+	}
+	qtype := binary.BigEndian.Uint16(query[end:])
+	question := query[12 : end+4]
+
+	var answers [][]byte
+	found := false
+	key := strings.ToLower(name)
+	switch qtype {
+	case typePTR:
+		for _, target := range s.ptr[key] {
+			answers = append(answers, encodeName(target))
+		}
+		_, found = s.ptr[key]
+	case typeA, typeAAAA:
+		for _, addr := range s.addrs[key] {
+			if addr.Is4() && qtype == typeA {
+				b := addr.As4()
+				answers = append(answers, b[:])
+			}
+			if addr.Is6() && qtype == typeAAAA {
+				b := addr.As16()
+				answers = append(answers, b[:])
+			}
+		}
+		_, found = s.addrs[key]
+	}
+
+	flags := uint16(0x8180) // Response, recursion desired and available
+	if !found {
+		flags |= 3 // NXDOMAIN
+	}
+	resp := binary.BigEndian.AppendUint16(nil, binary.BigEndian.Uint16(query))
+	resp = binary.BigEndian.AppendUint16(resp, flags)
+	resp = binary.BigEndian.AppendUint16(resp, 1)
+	resp = binary.BigEndian.AppendUint16(resp, uint16(len(answers)))
+	resp = append(resp, 0, 0, 0, 0)
+	resp = append(resp, question...)
+	for _, rdata := range answers {
+		resp = append(resp, 0xc0, 12) // Pointer to the question name
+		resp = binary.BigEndian.AppendUint16(resp, qtype)
+		resp = binary.BigEndian.AppendUint16(resp, 1) // IN
+		resp = binary.BigEndian.AppendUint32(resp, 300)
+		resp = binary.BigEndian.AppendUint16(resp, uint16(len(rdata)))
+		resp = append(resp, rdata...)
+	}
+	return resp
+}
+
+// readName reads an uncompressed domain name at offset.
+func readName(msg []byte, offset int) (string, int, bool) {
+	var labels []string
+	for offset < len(msg) {
+		n := int(msg[offset])
+		offset++
+		if n == 0 {
+			return strings.Join(labels, ".") + ".", offset, true
+		}
+		if n&0xc0 != 0 || offset+n > len(msg) {
+			return "", 0, false
+		}
+		labels = append(labels, string(msg[offset:offset+n]))
+		offset += n
+	}
+	return "", 0, false
+}
+
+func encodeName(name string) []byte {
+	var b []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+func TestResolver_Lookup(t *testing.T) {
+	stub := &stubServer{
+		ptr: map[string][]string{
+			"10.2.0.192.in-addr.arpa.": {"host.example.", "alias.example."},
+			"11.2.0.192.in-addr.arpa.": {"spoofed.example."},
+			"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.": {"v6.example."},
+		},
+		addrs: map[string][]netip.Addr{
+			"host.example.":    {netip.MustParseAddr("192.0.2.10"), netip.MustParseAddr("2001:db8::10")},
+			"alias.example.":   {netip.MustParseAddr("192.0.2.99")},
+			"spoofed.example.": {netip.MustParseAddr("198.51.100.1")},
+			"v6.example.":      {netip.MustParseAddr("2001:db8::1")},
+		},
+	}
+	r := New(startStub(t, stub), 2*time.Second)
+
+	tests := []struct {
+		addr string
+		want Result
+	}{
+		{
+			addr: "192.0.2.10",
+			want: Result{
+				Names:            []Name{{Name: "host.example", Confirmed: true}, {Name: "alias.example"}},
+				ForwardConfirmed: true,
+			},
+		},
+		{
+			addr: "::ffff:192.0.2.11",
+			want: Result{Names: []Name{{Name: "spoofed.example"}}},
+		},
+		{
+			addr: "2001:db8::1",
+			want: Result{Names: []Name{{Name: "v6.example", Confirmed: true}}, ForwardConfirmed: true},
+		},
+		{
+			addr: "192.0.2.12",
+			want: Result{Names: []Name{}},
+		},
+	}
+
+	for _, tt := range tests {
+		got := r.Lookup(context.Background(), netip.MustParseAddr(tt.addr))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%s) = %+v, want %+v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestResolver_Cache(t *testing.T) {
+	stub := &stubServer{
+		ptr:   map[string][]string{"10.2.0.192.in-addr.arpa.": {"host.example."}},
+		addrs: map[string][]netip.Addr{"host.example.": {netip.MustParseAddr("192.0.2.10")}},
+	}
+	r := New(startStub(t, stub), 2*time.Second)
+	addr := netip.MustParseAddr("192.0.2.10")
+
+	first := r.Lookup(context.Background(), addr)
+	queries := stub.queries.Load()
+	second := r.Lookup(context.Background(), addr)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("cached result %+v differs from %+v", second, first)
+	}
+	if got := stub.queries.Load(); got != queries {
+		t.Errorf("second lookup sent %d more queries, want it served from the cache", got-queries)
+	}
+}
+
+func TestResolver_Timeout(t *testing.T) {
+	stub := &stubServer{silent: true}
+	r := New(startStub(t, stub), 200*time.Millisecond)
+
+	start := time.Now()
+	got := r.Lookup(context.Background(), netip.MustParseAddr("192.0.2.10"))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Lookup() took %v, want it bounded by the 200ms timeout", elapsed)
+	}
+	if got.Error != "timeout" || len(got.Names) != 0 || got.ForwardConfirmed {
+		t.Errorf("Lookup() = %+v, want a timeout error", got)
+	}
+
+	// The failure is cached, so the next request does not wait again
+	start = time.Now()
+	r.Lookup(context.Background(), netip.MustParseAddr("192.0.2.10"))
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("repeated Lookup() took %v, want the cached failure", elapsed)
+	}
+}
@@ -0,0 +1,118 @@
+// Package redact masks credentials in request headers and query parameters
+// before they are shown, so that reports can be shared without leaking them.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultHeaders lists the headers whose values are masked by default.
+var DefaultHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"Api-Key",
+	"X-Auth-Token",
+	"X-Access-Token",
+	"X-Csrf-Token",
+	"X-Xsrf-Token",
+	"X-Amz-Security-Token",
+}
+
+// DefaultPatterns match credentials in the values of any header: bearer
+// tokens, of which only the token is masked, and JSON Web Tokens.
+var DefaultPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bbearer\s+([^\s,]+)`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+}
+
+// DefaultKeep is how many characters of a masked value are kept by default.
+const DefaultKeep = 4
+
+// Policy decides which values are masked and how.
+type Policy struct {
+	Headers     []string         // Headers whose values are masked entirely; case-insensitive
+	Patterns    []*regexp.Regexp // Matches in any value are masked, or only the first group if there is one
+	Keep        int              // Leading characters kept of each masked value
+	AllowReveal bool             // Whether ?reveal=1 may show values unmasked
+}
+
+// Default returns the policy used unless configured otherwise.
+func Default() Policy {
+	return Policy{Headers: DefaultHeaders, Patterns: DefaultPatterns, Keep: DefaultKeep}
+}
+
+// Header returns value with the parts the policy covers masked, and whether
+// anything was masked.
+func (p Policy) Header(name, value string) (string, bool) {
+	for _, h := range p.Headers {
+		if strings.EqualFold(h, name) {
+			return Mask(value, p.Keep), value != ""
+		}
+	}
+	return p.Text(value)
+}
+
+// Param returns the value of a query parameter with the parts the policy
+// covers masked, and whether anything was masked. Parameters are masked
+// entirely if their name matches one of the headers, ignoring case, an
+// "X-" prefix and the difference between "-" and "_": access_token matches
+// X-Access-Token, and api_key matches Api-Key.
+func (p Policy) Param(name, value string) (string, bool) {
+	name = paramName(name)
+	for _, h := range p.Headers {
+		if paramName(h) == name {
+			return Mask(value, p.Keep), value != ""
+		}
+	}
+	return p.Text(value)
+}
+
+// paramName normalizes a header or parameter name for Param.
+func paramName(name string) string {
+	name = strings.ReplaceAll(strings.ToLower(name), "-", "_")
+	return strings.TrimPrefix(name, "x_")
+}
+
+// Text returns s with every pattern match masked, and whether anything was
+// masked.
+func (p Policy) Text(s string) (string, bool) {
+	masked := false
+	for _, re := range p.Patterns {
+		var b strings.Builder
+		last := 0
+		for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			if start == end {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(Mask(s[start:end], p.Keep))
+			last, masked = end, true
+		}
+		if last > 0 {
+			b.WriteString(s[last:])
+			s = b.String()
+		}
+	}
+	return s, masked
+}
+
+// Mask replaces s with its first keep characters and its length, e.g.
+// "eyJh…(143 chars)". At most a quarter of s is kept, so that short
+// secrets are not given away.
+func Mask(s string, keep int) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+	keep = min(keep, len(runes)/4)
+	return fmt.Sprintf("%s…(%d chars)", string(runes[:keep]), len(runes))
+}
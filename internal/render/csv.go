@@ -0,0 +1,57 @@
+package render
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// RenderCSV writes the connection info as two-column CSV (field, value),
+// one row per leaf value. Field names are the JSON field names joined with
+// dots, with array indexes as path segments, e.g. "headers.0.name".
+func RenderCSV(w io.Writer, info ConnectionInfo) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"field", "value"}); err != nil {
+		return err
+	}
+	if err := writeCSVRows(cw, "", reportTree(info)); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeCSVRows(cw *csv.Writer, prefix string, v any) error {
+	join := func(segment string) string {
+		if prefix == "" {
+			return segment
+		}
+		return prefix + "." + segment
+	}
+
+	switch v := v.(type) {
+	case object:
+		for _, m := range v {
+			if err := writeCSVRows(cw, join(m.Key), m.Value); err != nil {
+				return err
+			}
+		}
+	case mapObject:
+		for _, m := range v {
+			if err := writeCSVRows(cw, join(m.Key), m.Value); err != nil {
+				return err
+			}
+		}
+	case array:
+		for i, item := range v {
+			if err := writeCSVRows(cw, join(strconv.Itoa(i)), item); err != nil {
+				return err
+			}
+		}
+	case nil:
+		return cw.Write([]string{prefix, ""})
+	default:
+		return cw.Write([]string{prefix, scalarString(v)})
+	}
+	return nil
+}
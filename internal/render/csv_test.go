@@ -0,0 +1,43 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestRenderCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderCSV(&buf, testInfo()); err != nil {
+		t.Fatalf("RenderCSV() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+
+	if len(records) == 0 || records[0][0] != "field" || records[0][1] != "value" {
+		t.Fatalf("missing header row, got %v", records)
+	}
+
+	got := make(map[string]string)
+	for _, record := range records[1:] {
+		got[record[0]] = record[1]
+	}
+
+	expected := map[string]string{
+		"client_ip":               "203.0.113.50",
+		"query_params.q.0":        "a&b",
+		"headers.0.name":          "Accept",
+		"headers.1.value":         "Mozilla/5.0 (X11; Linux x86_64) Firefox/121.0",
+		"user_agent.browser_name": "Firefox",
+		"user_agent.parsed":       "true",
+		"timestamp":               "2024-01-15T12:30:45Z",
+	}
+	for field, want := range expected {
+		if got[field] != want {
+			t.Errorf("%s = %q, want %q", field, got[field], want)
+		}
+	}
+}
@@ -1,22 +1,41 @@
 package render
 
 import (
+	"fmt"
 	"html/template"
 	"io"
+	"strings"
 	"time"
 
+	"connectionInfo/internal/geoip"
 	"connectionInfo/internal/parser"
+	"connectionInfo/internal/proxyproto"
+	"connectionInfo/internal/rdns"
 )
 
 // ConnectionInfo holds all data to be rendered in the HTML page.
 type ConnectionInfo struct {
 	ClientIP      string
+	ClientIPInfo  parser.IPInfo
+	Location      *geoip.Location
+	ReverseDNS    *rdns.Result
 	RawRemoteAddr string
 	Method        string
+	Protocol      string // e.g. "HTTP/1.1" or "HTTP/2.0"
 	Path          string
 	QueryParams   map[string][]string
 	Headers       []HeaderPair
+	HeaderOrder   *HeaderOrderInfo
+	RawRequest    *RawRequest
+	Cookies       parser.CookieInfo
+	Forwarded     []parser.ForwardedElement
+	Chain         []parser.Hop
+	ProxyProtocol *proxyproto.Header
+	TLS           *TLSInfo
+	HTTP2         *HTTP2Info
 	UserAgent     parser.UserAgentInfo
+	Negotiation   NegotiationInfo
+	Redacted      bool // Some header values are masked
 	Timestamp     time.Time
 }
 
@@ -26,6 +45,134 @@ type HeaderPair struct {
 	Value string
 }
 
+// HeaderOrderInfo lists the request headers the way an HTTP/1.x client sent
+// them.
+type HeaderOrderInfo struct {
+	Headers     []HeaderPair // In the order sent, with original casing and repeats
+	Fingerprint string       // Hash of the header names in order; see wire.Head.OrderHash
+}
+
+// NegotiationInfo holds the client's content negotiation preferences, each
+// ordered from most to least preferred.
+type NegotiationInfo struct {
+	Languages  []parser.LanguageRange // From Accept-Language
+	MediaTypes []parser.MediaRange    // From Accept
+	Encodings  []parser.Coding        // From Accept-Encoding
+}
+
+// TLSInfo describes the TLS connection a request arrived on.
+type TLSInfo struct {
+	Version     string // e.g. "TLS 1.3"
+	CipherSuite string // e.g. "TLS_AES_128_GCM_SHA256"
+	ServerName  string // SNI sent by the client; empty if none
+	ALPN        string // Negotiated application protocol; empty if none
+	Resumed     bool   // Session was resumed from an earlier connection
+	Curve       string // Key exchange group, e.g. "X25519MLKEM768"; empty if none
+
+	ClientCerts       []CertInfo // Chain presented by the client, leaf first
+	ClientVerified    bool       // The chain verified against the client CAs
+	ClientVerifyError string     // Why verification failed; empty if verified or no chain
+
+	ClientHello *ClientHelloInfo // Nil if the ClientHello was not captured
+}
+
+// ClientHelloInfo describes the ClientHello that opened the connection and
+// the fingerprints derived from it. Lists are in the order the client sent
+// them, including GREASE values.
+type ClientHelloInfo struct {
+	JA3     string // e.g. "771,4865-4866-4867,0-23-65281,29-23-24,0"
+	JA3Hash string // MD5 of JA3
+	JA4     string // e.g. "t13d1516h2_8daaf6152771_e5627efa2ab1"
+	JA4Raw  string // JA4_r: JA4 with the hashed lists spelled out
+
+	Version             CodePoint // legacy_version field
+	SupportedVersions   []CodePoint
+	CipherSuites        []CodePoint
+	Extensions          []CodePoint
+	SupportedGroups     []CodePoint
+	SignatureAlgorithms []CodePoint
+	ECPointFormats      []CodePoint
+	ALPN                []string
+	GREASE              []string // Distinct GREASE values, e.g. "0x0a0a"
+}
+
+// CodePoint is a numeric TLS protocol value and its registered name.
+type CodePoint struct {
+	Value uint16
+	Name  string // Empty if unknown; "GREASE" for GREASE values
+}
+
+// Hex returns the value as 4-digit hex, e.g. "0x1301".
+func (c CodePoint) Hex() string {
+	return fmt.Sprintf("0x%04x", c.Value)
+}
+
+// String returns the name followed by the value, e.g.
+// "TLS_AES_128_GCM_SHA256 (0x1301)", or only the value if it has no name.
+func (c CodePoint) String() string {
+	if c.Name == "" {
+		return c.Hex()
+	}
+	return c.Name + " (" + c.Hex() + ")"
+}
+
+// RawRequest is the request as the server received it: the request line,
+// the headers and the start of the body.
+type RawRequest struct {
+	RequestLine   string
+	Headers       []HeaderPair
+	Body          string // Body preview, with invalid UTF-8 replaced
+	Truncated     bool   // The body continues beyond the preview
+	Reconstructed bool   // Rebuilt from the parsed request, as for HTTP/2, rather than recorded
+}
+
+// String returns the request as text: the request line, one line per
+// header, an empty line and the body preview.
+func (r *RawRequest) String() string {
+	var b strings.Builder
+	b.WriteString(r.RequestLine + "\n")
+	for _, h := range r.Headers {
+		b.WriteString(h.Name + ": " + h.Value + "\n")
+	}
+	b.WriteString("\n" + r.Body)
+	return b.String()
+}
+
+// HTTP2Info describes how the client opened its HTTP/2 connection.
+type HTTP2Info struct {
+	Akamai          string // Akamai fingerprint, e.g. "1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p"
+	Settings        []HTTP2Setting
+	WindowUpdate    uint32   // Connection window increment; 0 if none was sent
+	Priorities      []string // PRIORITY frames as "stream:exclusive:parent:weight"
+	HeadersPriority string   // Priority of the first HEADERS frame, same format; empty if none
+	PseudoHeaders   []string // Pseudo-header order of the first request
+	Upgraded        bool     // Switched from HTTP/1.1 by an h2c Upgrade, so the pseudo-header order was not observed
+}
+
+// HTTP2Setting is a parameter of the client's first SETTINGS frame.
+type HTTP2Setting struct {
+	ID    uint16
+	Name  string // e.g. "INITIAL_WINDOW_SIZE"; empty if unknown
+	Value uint32
+}
+
+// CertInfo describes an X.509 certificate.
+type CertInfo struct {
+	Subject            string
+	Issuer             string
+	SANs               []string // Subject alternative names, e.g. "DNS:example.com", "IP:192.0.2.1"
+	Serial             string   // Hex, colon-separated
+	NotBefore          time.Time
+	NotAfter           time.Time
+	KeyType            string // "RSA", "ECDSA", "Ed25519" or "unknown"
+	KeyBits            int    // Key size in bits; curve size for ECDSA
+	KeyDetail          string // Curve name for ECDSA keys, e.g. "P-256"
+	SignatureAlgorithm string
+	IsCA               bool
+	SHA256             string // Fingerprint of the DER encoding, hex, colon-separated
+	SHA1               string
+}
+
 const htmlTemplate = `<!DOCTYPE html>
 <html lang="en">
 <head>
@@ -55,6 +202,11 @@ const htmlTemplate = `<!DOCTYPE html>
             margin-top: 30px;
             font-size: 1.2em;
         }
+        h3 {
+            color: #555;
+            margin: 20px 0 10px;
+            font-size: 1em;
+        }
         section {
             background: white;
             padding: 20px;
@@ -69,6 +221,24 @@ const htmlTemplate = `<!DOCTYPE html>
             font-family: monospace;
             margin: 10px 0;
         }
+        .badges {
+            margin: 0;
+        }
+        .badge {
+            display: inline-block;
+            padding: 2px 10px;
+            margin: 0 6px 6px 0;
+            border-radius: 12px;
+            background: #eaf4fc;
+            color: #2c3e50;
+            font-size: 0.85em;
+            font-weight: 600;
+        }
+        .badge-detail {
+            font-size: 0.85em;
+            color: #666;
+            font-family: monospace;
+        }
         dl {
             display: grid;
             grid-template-columns: auto 1fr;
@@ -111,6 +281,23 @@ const htmlTemplate = `<!DOCTYPE html>
             font-family: monospace;
             color: #666;
         }
+        tr.client-hop td {
+            background: #eaf4fc;
+        }
+        .note {
+            font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, "Helvetica Neue", Arial, sans-serif;
+            font-size: 0.85em;
+            color: #888;
+        }
+        pre {
+            margin: 0;
+            padding: 12px;
+            background: #f8f9fa;
+            border-radius: 4px;
+            font-size: 0.85em;
+            white-space: pre-wrap;
+            word-break: break-all;
+        }
         .raw-ua {
             font-size: 0.85em;
             color: #666;
@@ -138,13 +325,285 @@ const htmlTemplate = `<!DOCTYPE html>
     <section id="ip">
         <h2>Your IP Address</h2>
         <p class="ip-address">{{.ClientIP}}</p>
+        {{with .ClientIPInfo}}
+        <p class="badges">{{range .Labels}}<span class="badge">{{.}}</span>{{end}}</p>
+        {{if .EmbeddedIPv4}}
+        <p class="badge-detail">Embedded IPv4: {{.EmbeddedIPv4}}{{if .TeredoServer}} (Teredo server {{.TeredoServer}}){{end}}</p>
+        {{end}}
+        {{end}}
+    </section>
+
+    {{with .Location}}
+    <section id="location">
+        <h2>Location</h2>
+        <dl>
+            {{if .Country}}
+            <dt>Country</dt>
+            <dd>{{.Country}}{{if .CountryCode}} ({{.CountryCode}}){{end}}</dd>
+            {{end}}
+            {{if .Region}}
+            <dt>Region</dt>
+            <dd>{{.Region}}</dd>
+            {{end}}
+            {{if .City}}
+            <dt>City</dt>
+            <dd>{{.City}}</dd>
+            {{end}}
+            {{if .HasCoordinates}}
+            <dt>Coordinates</dt>
+            <dd>{{printf "%.4f, %.4f" .Latitude .Longitude}}{{if .AccuracyRadius}} <span class="note">(within {{.AccuracyRadius}} km)</span>{{end}}</dd>
+            {{end}}
+            {{if .TimeZone}}
+            <dt>Time Zone</dt>
+            <dd>{{.TimeZone}}</dd>
+            {{end}}
+            {{if .ASN}}
+            <dt>Network</dt>
+            <dd>AS{{.ASN}}{{if .Organization}} {{.Organization}}{{end}}</dd>
+            {{end}}
+        </dl>
+    </section>
+    {{end}}
+
+    {{with .ReverseDNS}}
+    <section id="reverse-dns">
+        <h2>Reverse DNS</h2>
+        {{if .Error}}
+        <p class="note">Lookup failed: {{.Error}}</p>
+        {{else if not .Names}}
+        <p class="note">No PTR record</p>
+        {{else}}
+        <dl>
+            {{range .Names}}
+            <dt>PTR</dt>
+            <dd>{{.Name}} {{if .Confirmed}}<span class="badge">forward-confirmed</span>{{else}}<span class="note">(does not resolve back)</span>{{end}}</dd>
+            {{end}}
+        </dl>
+        {{end}}
+    </section>
+    {{end}}
+
+    <section id="chain">
+        <h2>Proxy Chain</h2>
+        <table>
+            <thead>
+                <tr>
+                    <th>#</th>
+                    <th>Address</th>
+                    <th>Source</th>
+                    <th>Trusted</th>
+                    <th>Valid IP</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{range $i, $hop := .Chain}}
+                <tr{{if $hop.Client}} class="client-hop"{{end}}>
+                    <td>{{$i}}</td>
+                    <td>{{$hop.Address}}{{if $hop.Client}} <span class="note">(client)</span>{{end}}</td>
+                    <td>{{$hop.Source}}</td>
+                    <td>{{if $hop.Trusted}}yes{{else}}no{{end}}</td>
+                    <td>{{if $hop.Valid}}yes{{else}}no{{end}}</td>
+                </tr>
+                {{end}}
+            </tbody>
+        </table>
+    </section>
+
+    {{if .Forwarded}}
+    <section id="forwarded">
+        <h2>Forwarded Header</h2>
+        <table>
+            <thead>
+                <tr>
+                    <th>#</th>
+                    <th>For</th>
+                    <th>By</th>
+                    <th>Proto</th>
+                    <th>Host</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{range $i, $e := .Forwarded}}
+                <tr>
+                    <td>{{$i}}</td>
+                    <td>{{template "node" $e.For}}</td>
+                    <td>{{template "node" $e.By}}</td>
+                    <td>{{$e.Proto}}</td>
+                    <td>{{$e.Host}}</td>
+                </tr>
+                {{end}}
+            </tbody>
+        </table>
+    </section>
+    {{end}}
+
+    {{with .ProxyProtocol}}
+    <section id="proxy-protocol">
+        <h2>PROXY Protocol</h2>
+        <dl>
+            <dt>Version</dt>
+            <dd>v{{.Version}}</dd>
+            <dt>Command</dt>
+            <dd>{{.Command}}</dd>
+            <dt>Transport</dt>
+            <dd>{{.Transport}}</dd>
+            {{if .Source.IsValid}}
+            <dt>Source</dt>
+            <dd>{{.Source}}</dd>
+            <dt>Destination</dt>
+            <dd>{{.Destination}}</dd>
+            {{end}}
+            {{if .ALPN}}
+            <dt>ALPN</dt>
+            <dd>{{.ALPN}}</dd>
+            {{end}}
+            {{if .Authority}}
+            <dt>Authority</dt>
+            <dd>{{.Authority}}</dd>
+            {{end}}
+            {{if .UniqueID}}
+            <dt>Unique ID</dt>
+            <dd>{{.UniqueID}}</dd>
+            {{end}}
+            {{if .NetNS}}
+            <dt>Network Namespace</dt>
+            <dd>{{.NetNS}}</dd>
+            {{end}}
+            {{if .AWSVPCEndpointID}}
+            <dt>AWS VPC Endpoint</dt>
+            <dd>{{.AWSVPCEndpointID}}</dd>
+            {{end}}
+            {{if .CRC32CVerified}}
+            <dt>CRC32c</dt>
+            <dd>verified</dd>
+            {{end}}
+            {{with .SSL}}
+            <dt>SSL</dt>
+            <dd>{{if .ClientSSL}}client used TLS{{else}}no TLS{{end}}{{if .Version}}, {{.Version}}{{end}}{{if .Cipher}}, {{.Cipher}}{{end}}</dd>
+            <dt>Client Certificate</dt>
+            <dd>{{if .ClientCertConn}}presented{{if .CN}} (CN={{.CN}}){{end}}, {{if .Verified}}verified{{else}}not verified{{end}}{{else}}none{{end}}</dd>
+            {{if .SigAlg}}
+            <dt>Signature / Key Algorithm</dt>
+            <dd>{{.SigAlg}} / {{.KeyAlg}}</dd>
+            {{end}}
+            {{end}}
+            {{if .TLVs}}
+            <dt>TLVs</dt>
+            <dd>{{range $i, $tlv := .TLVs}}{{if $i}}, {{end}}{{$tlv.Name}} ({{len $tlv.Value}} bytes){{end}}</dd>
+            {{end}}
+        </dl>
+    </section>
+    {{end}}
+
+    {{with .TLS}}
+    <section id="tls">
+        <h2>TLS</h2>
+        <dl>
+            <dt>Version</dt>
+            <dd>{{.Version}}</dd>
+            <dt>Cipher Suite</dt>
+            <dd>{{.CipherSuite}}</dd>
+            <dt>Key Exchange</dt>
+            <dd>{{if .Curve}}{{.Curve}}{{else}}<span class="note">(none)</span>{{end}}</dd>
+            <dt>Server Name (SNI)</dt>
+            <dd>{{if .ServerName}}{{.ServerName}}{{else}}<span class="note">(not sent)</span>{{end}}</dd>
+            <dt>ALPN</dt>
+            <dd>{{if .ALPN}}{{.ALPN}}{{else}}<span class="note">(not negotiated)</span>{{end}}</dd>
+            <dt>Session</dt>
+            <dd>{{if .Resumed}}resumed{{else}}full handshake{{end}}</dd>
+        </dl>
+        {{if .ClientCerts}}
+        <h3>Client Certificate</h3>
+        <p class="badges">{{if .ClientVerified}}<span class="badge">verified</span>{{else}}<span class="badge">not verified</span> <span class="note">{{.ClientVerifyError}}</span>{{end}}</p>
+        {{range $i, $cert := .ClientCerts}}
+        {{if $i}}<h3>Chain Certificate {{$i}}</h3>{{end}}
+        <dl>
+            <dt>Subject</dt>
+            <dd>{{.Subject}}</dd>
+            <dt>Issuer</dt>
+            <dd>{{.Issuer}}</dd>
+            {{if .SANs}}
+            <dt>Alternative Names</dt>
+            <dd>{{range $j, $san := .SANs}}{{if $j}}, {{end}}{{$san}}{{end}}</dd>
+            {{end}}
+            <dt>Serial</dt>
+            <dd>{{.Serial}}</dd>
+            <dt>Valid</dt>
+            <dd>{{.NotBefore.Format "2006-01-02 15:04:05 MST"}} to {{.NotAfter.Format "2006-01-02 15:04:05 MST"}}</dd>
+            <dt>Public Key</dt>
+            <dd>{{.KeyType}}{{if .KeyBits}} {{.KeyBits}} bits{{end}}{{if .KeyDetail}} ({{.KeyDetail}}){{end}}{{if .IsCA}} <span class="badge">CA</span>{{end}}</dd>
+            <dt>Signature</dt>
+            <dd>{{.SignatureAlgorithm}}</dd>
+            <dt>SHA-256</dt>
+            <dd>{{.SHA256}}</dd>
+            <dt>SHA-1</dt>
+            <dd>{{.SHA1}}</dd>
+        </dl>
+        {{end}}
+        {{end}}
+        {{with .ClientHello}}
+        <h3>ClientHello</h3>
+        <dl>
+            <dt>JA3</dt>
+            <dd>{{.JA3Hash}}<br><span class="note">{{.JA3}}</span></dd>
+            <dt>JA4</dt>
+            <dd>{{.JA4}}<br><span class="note">{{.JA4Raw}}</span></dd>
+            <dt>Legacy Version</dt>
+            <dd>{{.Version}}</dd>
+            <dt>Supported Versions</dt>
+            <dd>{{range $i, $v := .SupportedVersions}}{{if $i}}<br>{{end}}{{$v}}{{else}}<span class="note">(none)</span>{{end}}</dd>
+            <dt>Cipher Suites</dt>
+            <dd>{{range $i, $v := .CipherSuites}}{{if $i}}<br>{{end}}{{$v}}{{end}}</dd>
+            <dt>Extensions</dt>
+            <dd>{{range $i, $v := .Extensions}}{{if $i}}<br>{{end}}{{$v}}{{else}}<span class="note">(none)</span>{{end}}</dd>
+            <dt>Supported Groups</dt>
+            <dd>{{range $i, $v := .SupportedGroups}}{{if $i}}<br>{{end}}{{$v}}{{else}}<span class="note">(none)</span>{{end}}</dd>
+            <dt>Signature Algorithms</dt>
+            <dd>{{range $i, $v := .SignatureAlgorithms}}{{if $i}}<br>{{end}}{{$v}}{{else}}<span class="note">(none)</span>{{end}}</dd>
+            {{if .ECPointFormats}}
+            <dt>EC Point Formats</dt>
+            <dd>{{range $i, $v := .ECPointFormats}}{{if $i}}, {{end}}{{$v}}{{end}}</dd>
+            {{end}}
+            <dt>ALPN</dt>
+            <dd>{{range $i, $v := .ALPN}}{{if $i}}, {{end}}{{$v}}{{else}}<span class="note">(none)</span>{{end}}</dd>
+            <dt>GREASE</dt>
+            <dd>{{range $i, $v := .GREASE}}{{if $i}}, {{end}}{{$v}}{{else}}<span class="note">(none)</span>{{end}}</dd>
+        </dl>
+        {{end}}
     </section>
+    {{end}}
+
+    {{with .HTTP2}}
+    <section id="http2">
+        <h2>HTTP/2</h2>
+        <dl>
+            <dt>Akamai Fingerprint</dt>
+            <dd>{{.Akamai}}</dd>
+            <dt>Settings</dt>
+            <dd>{{range $i, $s := .Settings}}{{if $i}}<br>{{end}}{{if $s.Name}}{{$s.Name}}{{else}}{{$s.ID}}{{end}} = {{$s.Value}}{{else}}<span class="note">(none)</span>{{end}}</dd>
+            <dt>Window Update</dt>
+            <dd>{{if .WindowUpdate}}{{.WindowUpdate}}{{else}}<span class="note">(none)</span>{{end}}</dd>
+            <dt>Priority Frames</dt>
+            <dd>{{range $i, $p := .Priorities}}{{if $i}}, {{end}}{{$p}}{{else}}<span class="note">(none)</span>{{end}}</dd>
+            {{if .HeadersPriority}}
+            <dt>HEADERS Priority</dt>
+            <dd>{{.HeadersPriority}}</dd>
+            {{end}}
+            <dt>Pseudo-Header Order</dt>
+            <dd>{{if .Upgraded}}<span class="note">(not observed (h2c upgrade))</span>{{else}}{{range $i, $h := .PseudoHeaders}}{{if $i}}, {{end}}{{$h}}{{else}}<span class="note">(not recorded)</span>{{end}}{{end}}</dd>
+        </dl>
+    </section>
+    {{end}}
 
     <section id="request">
         <h2>Request Details</h2>
         <dl>
             <dt>Method</dt>
             <dd>{{.Method}}</dd>
+            {{if .Protocol}}
+            <dt>Protocol</dt>
+            <dd>{{.Protocol}}</dd>
+            {{end}}
             <dt>Path</dt>
             <dd>{{.Path}}</dd>
             <dt>Query Parameters</dt>
@@ -152,20 +611,141 @@ const htmlTemplate = `<!DOCTYPE html>
         </dl>
     </section>
 
+    {{with .UserAgent}}
     <section id="useragent">
         <h2>Your Browser</h2>
         <dl>
             <dt>Browser</dt>
-            <dd>{{.UserAgent.BrowserName}}{{if .UserAgent.BrowserVersion}} {{.UserAgent.BrowserVersion}}{{end}}</dd>
+            <dd>{{.BrowserName}}{{if .BrowserVersion}} {{.BrowserVersion}}{{end}}{{template "source" .Sources.Browser}}</dd>
+            {{if .Engine}}
+            <dt>Engine</dt>
+            <dd>{{.Engine}}{{if .EngineVersion}} {{.EngineVersion}}{{end}}{{template "source" .Sources.Engine}}</dd>
+            {{end}}
+            <dt>Category</dt>
+            <dd>{{.Category}}</dd>
+            {{with .Bot}}
+            <dt>Operator</dt>
+            <dd>{{.Operator}}</dd>
+            {{if .Domains}}
+            <dt>Verification</dt>
+            <dd>{{if .VerifyError}}lookup failed: {{.VerifyError}}{{else if not .Verified}}<span class="note">(not checked)</span>{{else if isTrue .Verified}}verified as {{.VerifiedName}}{{else}}not verified: no forward-confirmed PTR name in {{range $i, $d := .Domains}}{{if $i}}, {{end}}{{$d}}{{end}}{{end}}</dd>
+            {{end}}
+            {{end}}
             <dt>Operating System</dt>
-            <dd>{{.UserAgent.OSName}}</dd>
+            <dd>{{.OSName}}{{if .OSVersion}} {{.OSVersion}}{{end}}{{template "source" .Sources.OS}}</dd>
+            {{if .Sources.Mobile}}
+            <dt>Mobile</dt>
+            <dd>{{if .Mobile}}yes{{else}}no{{end}}{{template "source" .Sources.Mobile}}</dd>
+            {{end}}
+            {{if .Architecture}}
+            <dt>Architecture</dt>
+            <dd>{{.Architecture}}{{if .Bitness}}, {{.Bitness}}-bit{{end}}{{template "source" .Sources.Architecture}}</dd>
+            {{end}}
+            {{if .Device}}
+            <dt>Device</dt>
+            <dd>{{.Device}}{{template "source" .Sources.Device}}</dd>
+            {{end}}
+            {{if or .Vendor .Model}}
+            <dt>Model</dt>
+            <dd>{{.Vendor}}{{if and .Vendor .Model}} {{end}}{{.Model}}{{template "source" .Sources.Model}}</dd>
+            {{end}}
             <dt>Raw User-Agent</dt>
-            <dd class="raw-ua">{{if .UserAgent.Raw}}{{.UserAgent.Raw}}{{else}}(not provided){{end}}</dd>
+            <dd class="raw-ua">{{if .Raw}}{{.Raw}}{{else}}(not provided){{end}}</dd>
+        </dl>
+        {{with .Hints}}
+        <h3>Client Hints</h3>
+        <dl>
+            <dt>Brands</dt>
+            <dd>{{template "brands" .Brands}}</dd>
+            <dt>Full Versions</dt>
+            <dd>{{template "brands" .FullVersionList}}</dd>
+            <dt>Platform</dt>
+            <dd>{{if .Platform}}{{.Platform}}{{if .PlatformVersion}} {{.PlatformVersion}}{{end}}{{else}}<span class="note">(not sent)</span>{{end}}</dd>
+            <dt>Mobile</dt>
+            <dd>{{with .Mobile}}{{if isTrue .}}yes{{else}}no{{end}}{{else}}<span class="note">(not sent)</span>{{end}}</dd>
+            <dt>Architecture</dt>
+            <dd>{{if .Arch}}{{.Arch}}{{if .Bitness}}, {{.Bitness}}-bit{{end}}{{else}}<span class="note">(not sent)</span>{{end}}</dd>
+            <dt>Model</dt>
+            <dd>{{if .Model}}{{.Model}}{{else}}<span class="note">(not sent)</span>{{end}}</dd>
+        </dl>
+        {{end}}
+    </section>
+    {{end}}
+
+    {{with .Negotiation}}{{if or .Languages .MediaTypes .Encodings}}
+    <section id="negotiation">
+        <h2>Negotiation Preferences</h2>
+        <dl>
+            <dt>Languages</dt>
+            <dd>{{range $i, $l := .Languages}}{{if $i}}<br>{{end}}{{$l.Tag}}{{if $l.Name}} ({{$l.Name}}){{end}} {{template "q" $l.Q}}{{else}}<span class="note">(not sent)</span>{{end}}</dd>
+            <dt>Media Types</dt>
+            <dd>{{range $i, $m := .MediaTypes}}{{if $i}}<br>{{end}}{{$m.MediaType}}{{range $k, $v := $m.Params}};{{$k}}={{$v}}{{end}} {{template "q" $m.Q}}{{else}}<span class="note">(not sent)</span>{{end}}</dd>
+            <dt>Encodings</dt>
+            <dd>{{range $i, $c := .Encodings}}{{if $i}}<br>{{end}}{{$c.Name}} {{template "q" $c.Q}}{{else}}<span class="note">(not sent)</span>{{end}}</dd>
         </dl>
     </section>
+    {{end}}{{end}}
 
     <section id="headers">
         <h2>Request Headers</h2>
+        {{if .Redacted}}<p class="note">Credentials and other sensitive values are masked, showing only their first characters and length.</p>{{end}}
+        <table>
+            <thead>
+                <tr>
+                    <th>Header</th>
+                    <th>Value</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{range .Headers}}
+                <tr>
+                    <td>{{.Name}}</td>
+                    <td>{{.Value}}</td>
+                </tr>
+                {{end}}
+            </tbody>
+        </table>
+    </section>
+
+    {{with .Cookies}}{{if .Cookies}}
+    <section id="cookies">
+        <h2>Cookies</h2>
+        <dl>
+            <dt>Total Size</dt>
+            <dd>{{.Size}} bytes in {{len .Cookies}} cookie{{if ne (len .Cookies) 1}}s{{end}}</dd>
+            {{if .Warnings}}
+            <dt>Warnings</dt>
+            <dd>{{range $i, $w := .Warnings}}{{if $i}}<br>{{end}}{{$w}}{{end}}</dd>
+            {{end}}
+        </dl>
+        <table>
+            <thead>
+                <tr>
+                    <th>Name</th>
+                    <th>Size</th>
+                    <th>Value</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{range .Cookies}}
+                <tr>
+                    <td>{{if .Name}}{{.Name}}{{else}}<span class="note">(no name)</span>{{end}}{{if .Duplicate}} <span class="badge">duplicate</span>{{end}}</td>
+                    <td>{{.Size}}</td>
+                    <td>{{.Value}}{{if .Encoding}} <span class="badge">{{.Encoding}}</span>{{end}}{{if .Decoded}}<br><span class="note">{{.Decoded}}</span>{{end}}{{if .JWTHeader}}<br><span class="note">{{.JWTHeader}}</span><br><span class="note">{{.JWTClaims}}</span>{{end}}</td>
+                </tr>
+                {{end}}
+            </tbody>
+        </table>
+    </section>
+    {{end}}{{end}}
+
+    {{with .HeaderOrder}}
+    <section id="header-order">
+        <h2>Headers as Sent</h2>
+        <dl>
+            <dt>Order Fingerprint</dt>
+            <dd>{{.Fingerprint}}</dd>
+        </dl>
         <table>
             <thead>
                 <tr>
@@ -183,15 +763,36 @@ const htmlTemplate = `<!DOCTYPE html>
             </tbody>
         </table>
     </section>
+    {{end}}
+
+    {{with .RawRequest}}
+    <section id="raw-request">
+        <h2>Raw Request</h2>
+        <pre>{{.String}}</pre>
+        {{if .Truncated}}<p class="note">The body continues beyond the preview.</p>{{end}}
+        {{if .Reconstructed}}<p class="note">Rebuilt from the parsed request: header names are canonical, repeated headers are joined, and the order is not the one received.</p>{{end}}
+    </section>
+    {{end}}
 
     <section id="timestamp">
         <h2>Server Timestamp</h2>
         <p class="timestamp">{{.Timestamp.Format "2006-01-02T15:04:05Z07:00"}}</p>
     </section>
 </body>
-</html>`
+</html>
+{{define "source"}}{{if .}} <span class="note">(from {{.}})</span>{{end}}{{end}}
+{{define "brands"}}{{range $i, $b := .}}{{if $i}}<br>{{end}}{{$b.Name}} {{$b.Version}}{{if $b.GREASE}} <span class="note">(GREASE)</span>{{end}}{{else}}<span class="note">(not sent)</span>{{end}}{{end}}
+{{define "q"}}<span class="note">{{if eq . 0.0}}(not acceptable){{else}}q={{.}}{{end}}</span>{{end}}
+{{define "node"}}{{.Raw}}{{if .Obfuscated}} <span class="note">(obfuscated)</span>{{else if .Unknown}} <span class="note">(unknown)</span>{{end}}{{end}}`
+
+// templateFuncs are the functions htmlTemplate uses besides the built-in ones.
+var templateFuncs = template.FuncMap{
+	// isTrue dereferences an optional flag, as if on its own treats any
+	// non-nil pointer as true
+	"isTrue": func(b *bool) bool { return b != nil && *b },
+}
 
-var tmpl = template.Must(template.New("connectionInfo").Parse(htmlTemplate))
+var tmpl = template.Must(template.New("connectionInfo").Funcs(templateFuncs).Parse(htmlTemplate))
 
 // Render writes the HTML page to the provided writer.
 func Render(w io.Writer, info ConnectionInfo) error {
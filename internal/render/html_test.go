@@ -2,11 +2,15 @@ package render
 
 import (
 	"bytes"
+	"net/netip"
 	"strings"
 	"testing"
 	"time"
 
+	"connectionInfo/internal/geoip"
 	"connectionInfo/internal/parser"
+	"connectionInfo/internal/proxyproto"
+	"connectionInfo/internal/rdns"
 )
 
 func TestRender(t *testing.T) {
@@ -153,3 +157,615 @@ func TestRender_EmptyUserAgent(t *testing.T) {
 		t.Errorf("rendered output should show '(not provided)' for empty user agent")
 	}
 }
+
+func TestRender_Forwarded(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.60",
+		Method:    "GET",
+		Path:      "/",
+		Timestamp: time.Now().UTC(),
+		Forwarded: []parser.ForwardedElement{
+			{
+				For:   parser.ForwardedNode{Raw: "[2001:db8::1]:4711", IP: "2001:db8::1", Port: "4711"},
+				By:    parser.ForwardedNode{Raw: "_lb1", Obfuscated: true},
+				Proto: "https",
+				Host:  "example.com",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{"Forwarded Header", "[2001:db8::1]:4711", "_lb1", "(obfuscated)", "https", "example.com"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+}
+
+func TestRender_NoForwardedSection(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, ConnectionInfo{Timestamp: time.Now().UTC()}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	if strings.Contains(buf.String(), "Forwarded Header") {
+		t.Errorf("Forwarded section should be omitted when the header is absent")
+	}
+}
+
+func TestRender_ProxyChain(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "203.0.113.50",
+		Method:    "GET",
+		Path:      "/",
+		Timestamp: time.Now().UTC(),
+		Chain: []parser.Hop{
+			{Address: "203.0.113.50", IP: "203.0.113.50", Source: parser.SourceXForwardedFor, Valid: true, Client: true},
+			{Address: "cdn.example", Source: parser.SourceVia},
+			{Address: "127.0.0.1:51234", IP: "127.0.0.1", Source: parser.SourceSocket, Trusted: true, Valid: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{"Proxy Chain", `class="client-hop"`, "(client)", "X-Forwarded-For", "cdn.example", "Via", "127.0.0.1:51234", "socket"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+}
+
+func TestRender_ProxyProtocol(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.1",
+		Method:    "GET",
+		Path:      "/",
+		Timestamp: time.Now().UTC(),
+		ProxyProtocol: &proxyproto.Header{
+			Version:     2,
+			Command:     "PROXY",
+			Transport:   "TCP4",
+			Source:      netip.MustParseAddrPort("192.0.2.1:56324"),
+			Destination: netip.MustParseAddrPort("198.51.100.1:443"),
+			ALPN:        "h2",
+			SSL:         &proxyproto.SSLInfo{ClientSSL: true, Version: "TLSv1.3"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{"PROXY Protocol", "192.0.2.1:56324", "198.51.100.1:443", "TCP4", "h2", "TLSv1.3"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+}
+
+func TestRender_IPBadges(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:     "2001:0:4136:e378:8000:63bf:3fff:fdd2",
+		ClientIPInfo: parser.ClassifyIP("2001:0:4136:e378:8000:63bf:3fff:fdd2"),
+		Method:       "GET",
+		Path:         "/",
+		Timestamp:    time.Now().UTC(),
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{`<span class="badge">IPv6</span>`, `<span class="badge">Teredo</span>`, "Embedded IPv4: 192.0.2.45", "Teredo server 65.54.227.120"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+}
+
+func TestRender_Location(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Method:    "GET",
+		Path:      "/",
+		Timestamp: time.Now().UTC(),
+		Location: &geoip.Location{
+			CountryCode:    "DE",
+			Country:        "Germany",
+			Region:         "Land Berlin",
+			City:           "Berlin",
+			HasCoordinates: true,
+			Latitude:       52.5196,
+			Longitude:      13.4069,
+			AccuracyRadius: 20,
+			TimeZone:       "Europe/Berlin",
+			ASN:            64500,
+			Organization:   "Example Networks",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{`id="location"`, "Germany (DE)", "Land Berlin", "Berlin", "52.5196, 13.4069", "within 20 km", "Europe/Berlin", "AS64500 Example Networks"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+
+	buf.Reset()
+	info.Location = nil
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(buf.String(), `id="location"`) {
+		t.Errorf("Location section should be omitted without GeoIP data")
+	}
+}
+
+func TestRender_ReverseDNS(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   rdns.Result
+		expected []string
+	}{
+		{
+			name: "names",
+			result: rdns.Result{
+				Names:            []rdns.Name{{Name: "host.example", Confirmed: true}, {Name: "alias.example"}},
+				ForwardConfirmed: true,
+			},
+			expected: []string{`id="reverse-dns"`, "host.example", "forward-confirmed", "alias.example", "does not resolve back"},
+		},
+		{
+			name:     "no record",
+			result:   rdns.Result{Names: []rdns.Name{}},
+			expected: []string{"No PTR record"},
+		},
+		{
+			name:     "timeout",
+			result:   rdns.Result{Names: []rdns.Name{}, Error: "timeout"},
+			expected: []string{"Lookup failed: timeout"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ConnectionInfo{ClientIP: "192.0.2.10", Timestamp: time.Now().UTC(), ReverseDNS: &tt.result}
+
+			var buf bytes.Buffer
+			if err := Render(&buf, info); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(buf.String(), expected) {
+					t.Errorf("rendered output does not contain %q", expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRender_TLS(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		TLS: &TLSInfo{
+			Version:     "TLS 1.3",
+			CipherSuite: "TLS_AES_128_GCM_SHA256",
+			ServerName:  "example.com",
+			ALPN:        "h2",
+			Curve:       "X25519MLKEM768",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{`id="tls"`, "TLS 1.3", "TLS_AES_128_GCM_SHA256", "example.com", "h2", "X25519MLKEM768", "full handshake"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+
+	buf.Reset()
+	info.TLS = nil
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(buf.String(), `id="tls"`) {
+		t.Errorf("TLS section should be omitted for plain HTTP")
+	}
+}
+
+func TestRender_ClientCertificate(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		TLS: &TLSInfo{
+			Version: "TLS 1.3",
+			ClientCerts: []CertInfo{
+				{Subject: "CN=alice", Issuer: "CN=Intermediate", SANs: []string{"email:alice@example.com"}, KeyType: "Ed25519", KeyBits: 256},
+				{Subject: "CN=Intermediate", Issuer: "CN=Root", KeyType: "RSA", KeyBits: 2048, IsCA: true},
+			},
+			ClientVerifyError: "x509: certificate signed by unknown authority",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{"Client Certificate", "CN=alice", "email:alice@example.com", "Ed25519 256 bits", "Chain Certificate 1", "RSA 2048 bits", "not verified", "unknown authority"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+}
+
+func TestRender_ClientHello(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		TLS: &TLSInfo{
+			Version: "TLS 1.3",
+			ClientHello: &ClientHelloInfo{
+				JA3Hash:      "ada70206e40642a3e4461f35503241d5",
+				JA4:          "t13d1516h2_8daaf6152771_e5627efa2ab1",
+				CipherSuites: []CodePoint{{Value: 0x1301, Name: "TLS_AES_128_GCM_SHA256"}, {Value: 0x00aa}},
+				ALPN:         []string{"h2"},
+				GREASE:       []string{"0x1a1a"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{"ClientHello", "ada70206e40642a3e4461f35503241d5", "t13d1516h2_8daaf6152771_e5627efa2ab1", "TLS_AES_128_GCM_SHA256 (0x1301)", "0x00aa", "0x1a1a"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+}
+
+func TestRender_HTTP2(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		Protocol:  "HTTP/2.0",
+		HTTP2: &HTTP2Info{
+			Akamai:        "1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p",
+			Settings:      []HTTP2Setting{{ID: 4, Name: "INITIAL_WINDOW_SIZE", Value: 6291456}, {ID: 0xff, Value: 1}},
+			WindowUpdate:  15663105,
+			PseudoHeaders: []string{":method", ":authority", ":scheme", ":path"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{`id="http2"`, "HTTP/2.0", "1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p", "INITIAL_WINDOW_SIZE = 6291456", "255 = 1", "15663105", ":method, :authority, :scheme, :path"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+
+	buf.Reset()
+	info.HTTP2 = nil
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(buf.String(), `id="http2"`) {
+		t.Error("HTTP/2 section should be omitted for other protocols")
+	}
+}
+
+func TestRender_HeaderOrder(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		Headers:   []HeaderPair{{Name: "Accept", Value: "*/*"}, {Name: "X-Dup", Value: "a, b"}},
+		HeaderOrder: &HeaderOrderInfo{
+			Headers:     []HeaderPair{{Name: "host", Value: "example.com"}, {Name: "X-Dup", Value: "a"}, {Name: "ACCEPT", Value: "*/*"}, {Name: "X-Dup", Value: "b"}},
+			Fingerprint: "5e1f0d6c2a9b",
+		},
+		Redacted: true,
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	if !strings.Contains(body, "sensitive values are masked") {
+		t.Error("rendered output should note that values are masked")
+	}
+	section := body[strings.Index(body, `id="header-order"`):]
+	for _, expected := range []string{"Headers as Sent", "5e1f0d6c2a9b", "<td>host</td>", "<td>ACCEPT</td>"} {
+		if !strings.Contains(section, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+	if strings.Index(section, "<td>host</td>") > strings.Index(section, "<td>ACCEPT</td>") {
+		t.Error("headers as sent should keep their order")
+	}
+	if strings.Count(section, "<td>X-Dup</td>") != 2 {
+		t.Error("repeated headers should be listed separately")
+	}
+
+	buf.Reset()
+	info.HeaderOrder = nil
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(buf.String(), `id="header-order"`) {
+		t.Error("headers as sent should be omitted when not recorded")
+	}
+}
+
+func TestRender_RawRequest(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		RawRequest: &RawRequest{
+			RequestLine: "POST /form HTTP/1.1",
+			Headers:     []HeaderPair{{Name: "host", Value: "example.com"}, {Name: "X-Test", Value: "<b>"}},
+			Body:        "a=1",
+			Truncated:   true,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{`id="raw-request"`, "<pre>POST /form HTTP/1.1\nhost: example.com\nX-Test: &lt;b&gt;\n\na=1</pre>", "continues beyond the preview"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+	if strings.Contains(body, "Rebuilt from the parsed request") {
+		t.Error("a recorded request should not be marked as rebuilt")
+	}
+}
+
+func TestRender_Cookies(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		Cookies: parser.CookieInfo{
+			Cookies: []parser.Cookie{
+				{Name: "sid", Value: "abc", Size: 6, Duplicate: true},
+				{Name: "prefs", Value: "a%3Db", Size: 10, Encoding: "url", Decoded: "a=b"},
+				{Name: "token", Value: "eyJh…(92 chars)", Size: 97, Encoding: "jwt"},
+				{Name: "sid", Value: "def", Size: 6, Duplicate: true},
+			},
+			Size:     140,
+			Warnings: []string{`"sid" is sent 2 times, usually for different paths or domains`},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	section := body[strings.Index(body, `id="cookies"`):]
+	section = section[:strings.Index(section, "</section>")]
+	for _, expected := range []string{
+		"140 bytes in 4 cookies",
+		"&#34;sid&#34; is sent 2 times",
+		`sid <span class="badge">duplicate</span>`,
+		`a%3Db <span class="badge">url</span><br><span class="note">a=b</span>`,
+		`eyJh…(92 chars) <span class="badge">jwt</span></td>`,
+	} {
+		if !strings.Contains(section, expected) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", expected, section)
+		}
+	}
+
+	buf.Reset()
+	info.Cookies = parser.CookieInfo{}
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(buf.String(), `id="cookies"`) {
+		t.Error("the cookies section should be omitted without cookies")
+	}
+}
+
+func TestRender_Negotiation(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		Negotiation: NegotiationInfo{
+			Languages:  parser.ParseAcceptLanguage("de-CH, en;q=0.9"),
+			MediaTypes: parser.ParseAccept("text/plain; format=flowed, */*;q=0"),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	section := body[strings.Index(body, `id="negotiation"`):]
+	section = section[:strings.Index(section, "</section>")]
+	for _, expected := range []string{
+		"Negotiation Preferences",
+		`de-CH (German (Switzerland)) <span class="note">q=1</span><br>en (English) <span class="note">q=0.9</span>`,
+		`text/plain;format=flowed <span class="note">q=1</span>`,
+		`*/* <span class="note">(not acceptable)</span>`,
+		`<dt>Encodings</dt>
+            <dd><span class="note">(not sent)</span></dd>`,
+	} {
+		if !strings.Contains(section, expected) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", expected, section)
+		}
+	}
+	if strings.Index(body, `id="useragent"`) > strings.Index(body, `id="negotiation"`) {
+		t.Error("negotiation preferences should follow the browser section")
+	}
+
+	buf.Reset()
+	info.Negotiation = NegotiationInfo{}
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(buf.String(), `id="negotiation"`) {
+		t.Error("negotiation preferences should be omitted when none were sent")
+	}
+}
+
+func TestRender_ClientHints(t *testing.T) {
+	mobile := false
+	ua := parser.ParseUserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	ua.MergeClientHints(&parser.ClientHintsInfo{
+		Brands:          []parser.Brand{{Name: "Not_A Brand", Version: "8", GREASE: true}, {Name: "Google Chrome", Version: "120"}},
+		FullVersionList: []parser.Brand{{Name: "Google Chrome", Version: "120.0.6099.129"}},
+		Mobile:          &mobile,
+		Platform:        "macOS",
+		PlatformVersion: "14.2.1",
+		Arch:            "arm",
+		Bitness:         "64",
+	})
+	info := ConnectionInfo{ClientIP: "192.0.2.10", UserAgent: ua, Timestamp: time.Now().UTC()}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	body := buf.String()
+	section := body[strings.Index(body, `id="useragent"`):]
+	section = section[:strings.Index(section, "</section>")]
+	for _, expected := range []string{
+		`Chrome 120.0.6099.129 <span class="note">(from Sec-CH-UA-Full-Version-List)</span>`,
+		`macOS 14.2.1 <span class="note">(from User-Agent)</span>`,
+		`<dt>Engine</dt>
+            <dd>Blink 120.0.0.0 <span class="note">(from User-Agent)</span></dd>`,
+		`arm, 64-bit <span class="note">(from Sec-CH-UA-Arch)</span>`,
+		`<h3>Client Hints</h3>`,
+		`Not_A Brand 8 <span class="note">(GREASE)</span><br>Google Chrome 120`,
+		`<dt>Mobile</dt>
+            <dd>no</dd>`,
+		`<dt>Model</dt>
+            <dd><span class="note">(not sent)</span></dd>`,
+	} {
+		if !strings.Contains(section, expected) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", expected, section)
+		}
+	}
+
+	buf.Reset()
+	info.UserAgent = parser.ParseUserAgent("curl/8.4.0")
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "Client Hints") {
+		t.Error("the Client Hints table should be omitted without hints")
+	}
+}
+
+func TestRender_Bot(t *testing.T) {
+	ua := parser.ParseUserAgent("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)")
+	info := ConnectionInfo{ClientIP: "66.249.66.1", UserAgent: ua, Timestamp: time.Now().UTC()}
+
+	verified, unverified := true, false
+	tests := []struct {
+		name     string
+		verify   func(b *parser.Bot)
+		expected string
+	}{
+		{"not checked", func(b *parser.Bot) {}, `<span class="note">(not checked)</span>`},
+		{"verified", func(b *parser.Bot) { b.Verified, b.VerifiedName = &verified, "crawl-66-249-66-1.googlebot.com" }, "verified as crawl-66-249-66-1.googlebot.com"},
+		{"not verified", func(b *parser.Bot) { b.Verified = &unverified }, "not verified: no forward-confirmed PTR name in googlebot.com, google.com, googleusercontent.com"},
+		{"lookup failed", func(b *parser.Bot) { b.VerifyError = "timeout" }, "lookup failed: timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := *ua.Bot
+			tt.verify(&bot)
+			info.UserAgent.Bot = &bot
+
+			var buf bytes.Buffer
+			if err := Render(&buf, info); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			body := buf.String()
+			for _, expected := range []string{
+				"<dd>Googlebot 2.1",
+				"<dt>Category</dt>\n            <dd>bot</dd>",
+				"<dt>Operator</dt>\n            <dd>Google</dd>",
+				tt.expected,
+			} {
+				if !strings.Contains(body, expected) {
+					t.Errorf("rendered output does not contain %q", expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRender_Device(t *testing.T) {
+	ua := parser.ParseUserAgent("Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36")
+	info := ConnectionInfo{ClientIP: "192.0.2.10", UserAgent: ua, Timestamp: time.Now().UTC()}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	body := buf.String()
+	for _, expected := range []string{
+		`<dt>Device</dt>
+            <dd>mobile <span class="note">(from User-Agent)</span></dd>`,
+		`<dt>Model</dt>
+            <dd>Samsung SM-S918B <span class="note">(from User-Agent)</span></dd>`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q", expected)
+		}
+	}
+
+	buf.Reset()
+	info.UserAgent = parser.ParseUserAgent("curl/8.4.0")
+	if err := Render(&buf, info); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "<dt>Device</dt>") {
+		t.Error("the device should be omitted when unknown")
+	}
+}
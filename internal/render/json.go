@@ -0,0 +1,729 @@
+package render
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+
+	"connectionInfo/internal/geoip"
+	"connectionInfo/internal/parser"
+	"connectionInfo/internal/proxyproto"
+	"connectionInfo/internal/rdns"
+)
+
+// report is the machine-readable schema of a ConnectionInfo. The JSON field
+// names are a stable, documented API (see docs/connectionInfo.md): new fields
+// may be added, but existing ones must not be renamed or removed.
+type report struct {
+	ClientIP    string              `json:"client_ip"`
+	IPInfo      ipInfoReport        `json:"client_ip_info"`
+	Location    *locationReport     `json:"location"`
+	ReverseDNS  *reverseDNSReport   `json:"reverse_dns"`
+	RemoteAddr  string              `json:"remote_addr"`
+	Method      string              `json:"method"`
+	Protocol    string              `json:"protocol"`
+	Path        string              `json:"path"`
+	QueryParams map[string][]string `json:"query_params"`
+	Headers     []headerReport      `json:"headers"`
+	HeaderOrder *headerOrderReport  `json:"header_order"`
+	Cookies     cookiesReport       `json:"cookies"`
+	Forwarded   []forwardedReport   `json:"forwarded"`
+	ProxyChain  []hopReport         `json:"proxy_chain"`
+	ProxyProto  *proxyProtoReport   `json:"proxy_protocol"`
+	TLS         *tlsReport          `json:"tls"`
+	HTTP2       *http2Report        `json:"http2"`
+	UserAgent   userAgentReport     `json:"user_agent"`
+	Negotiation negotiationReport   `json:"negotiation"`
+	Redacted    bool                `json:"redacted"`
+	Timestamp   time.Time           `json:"timestamp"`
+}
+
+type ipInfoReport struct {
+	Version       int    `json:"version"`
+	IPv4Mapped    bool   `json:"ipv4_mapped"`
+	Public        bool   `json:"public"`
+	Unspecified   bool   `json:"unspecified"`
+	Loopback      bool   `json:"loopback"`
+	Private       bool   `json:"private"`
+	CGNAT         bool   `json:"cgnat"`
+	LinkLocal     bool   `json:"link_local"`
+	UniqueLocal   bool   `json:"unique_local"`
+	Documentation bool   `json:"documentation"`
+	Multicast     bool   `json:"multicast"`
+	Teredo        bool   `json:"teredo"`
+	SixToFour     bool   `json:"six_to_four"`
+	EmbeddedIPv4  string `json:"embedded_ipv4"`
+	TeredoServer  string `json:"teredo_server"`
+}
+
+func newIPInfoReport(i parser.IPInfo) ipInfoReport {
+	return ipInfoReport{
+		Version:       i.Version,
+		IPv4Mapped:    i.IPv4Mapped,
+		Public:        i.Public,
+		Unspecified:   i.Unspecified,
+		Loopback:      i.Loopback,
+		Private:       i.Private,
+		CGNAT:         i.CGNAT,
+		LinkLocal:     i.LinkLocal,
+		UniqueLocal:   i.UniqueLocal,
+		Documentation: i.Documentation,
+		Multicast:     i.Multicast,
+		Teredo:        i.Teredo,
+		SixToFour:     i.SixToFour,
+		EmbeddedIPv4:  i.EmbeddedIPv4,
+		TeredoServer:  i.TeredoServer,
+	}
+}
+
+type locationReport struct {
+	CountryCode    string   `json:"country_code"`
+	Country        string   `json:"country"`
+	RegionCode     string   `json:"region_code"`
+	Region         string   `json:"region"`
+	City           string   `json:"city"`
+	Latitude       *float64 `json:"latitude"`
+	Longitude      *float64 `json:"longitude"`
+	AccuracyRadius uint     `json:"accuracy_radius_km"`
+	TimeZone       string   `json:"time_zone"`
+	ASN            uint     `json:"asn"`
+	Organization   string   `json:"organization"`
+}
+
+func newLocationReport(loc *geoip.Location) *locationReport {
+	if loc == nil {
+		return nil
+	}
+
+	rep := &locationReport{
+		CountryCode:    loc.CountryCode,
+		Country:        loc.Country,
+		RegionCode:     loc.RegionCode,
+		Region:         loc.Region,
+		City:           loc.City,
+		AccuracyRadius: loc.AccuracyRadius,
+		TimeZone:       loc.TimeZone,
+		ASN:            loc.ASN,
+		Organization:   loc.Organization,
+	}
+	if loc.HasCoordinates {
+		lat, lon := loc.Latitude, loc.Longitude
+		rep.Latitude, rep.Longitude = &lat, &lon
+	}
+	return rep
+}
+
+type reverseDNSReport struct {
+	Names            []ptrNameReport `json:"names"`
+	ForwardConfirmed bool            `json:"forward_confirmed"`
+	Error            string          `json:"error"`
+}
+
+type ptrNameReport struct {
+	Name             string `json:"name"`
+	ForwardConfirmed bool   `json:"forward_confirmed"`
+}
+
+func newReverseDNSReport(res *rdns.Result) *reverseDNSReport {
+	if res == nil {
+		return nil
+	}
+
+	rep := &reverseDNSReport{
+		Names:            make([]ptrNameReport, 0, len(res.Names)),
+		ForwardConfirmed: res.ForwardConfirmed,
+		Error:            res.Error,
+	}
+	for _, n := range res.Names {
+		rep.Names = append(rep.Names, ptrNameReport{Name: n.Name, ForwardConfirmed: n.Confirmed})
+	}
+	return rep
+}
+
+type tlsReport struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipher_suite"`
+	ServerName  string `json:"server_name"`
+	ALPN        string `json:"alpn"`
+	Resumed     bool   `json:"resumed"`
+	Curve       string `json:"curve"`
+
+	ClientCertificates []certReport `json:"client_certificates"`
+	ClientVerified     bool         `json:"client_verified"`
+	ClientVerifyError  string       `json:"client_verify_error"`
+
+	ClientHello *clientHelloReport `json:"client_hello"`
+}
+
+type clientHelloReport struct {
+	JA3                 string            `json:"ja3"`
+	JA3Hash             string            `json:"ja3_hash"`
+	JA4                 string            `json:"ja4"`
+	JA4Raw              string            `json:"ja4_r"`
+	Version             codePointReport   `json:"version"`
+	SupportedVersions   []codePointReport `json:"supported_versions"`
+	CipherSuites        []codePointReport `json:"cipher_suites"`
+	Extensions          []codePointReport `json:"extensions"`
+	SupportedGroups     []codePointReport `json:"supported_groups"`
+	SignatureAlgorithms []codePointReport `json:"signature_algorithms"`
+	ECPointFormats      []codePointReport `json:"ec_point_formats"`
+	ALPN                []string          `json:"alpn"`
+	GREASE              []string          `json:"grease"`
+}
+
+type codePointReport struct {
+	Value string `json:"value"`
+	Name  string `json:"name"`
+}
+
+func newClientHelloReport(h *ClientHelloInfo) *clientHelloReport {
+	if h == nil {
+		return nil
+	}
+	return &clientHelloReport{
+		JA3:                 h.JA3,
+		JA3Hash:             h.JA3Hash,
+		JA4:                 h.JA4,
+		JA4Raw:              h.JA4Raw,
+		Version:             codePointReport{Value: h.Version.Hex(), Name: h.Version.Name},
+		SupportedVersions:   newCodePointReports(h.SupportedVersions),
+		CipherSuites:        newCodePointReports(h.CipherSuites),
+		Extensions:          newCodePointReports(h.Extensions),
+		SupportedGroups:     newCodePointReports(h.SupportedGroups),
+		SignatureAlgorithms: newCodePointReports(h.SignatureAlgorithms),
+		ECPointFormats:      newCodePointReports(h.ECPointFormats),
+		ALPN:                nonNil(h.ALPN),
+		GREASE:              nonNil(h.GREASE),
+	}
+}
+
+func newCodePointReports(points []CodePoint) []codePointReport {
+	reps := make([]codePointReport, 0, len(points))
+	for _, p := range points {
+		reps = append(reps, codePointReport{Value: p.Hex(), Name: p.Name})
+	}
+	return reps
+}
+
+// nonNil returns s, or an empty slice if it is nil, so that it encodes as
+// an array rather than null.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+type certReport struct {
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	SANs               []string  `json:"sans"`
+	Serial             string    `json:"serial"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	KeyType            string    `json:"key_type"`
+	KeyBits            int       `json:"key_bits"`
+	KeyDetail          string    `json:"key_detail"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	IsCA               bool      `json:"is_ca"`
+	SHA256             string    `json:"sha256"`
+	SHA1               string    `json:"sha1"`
+}
+
+func newTLSReport(t *TLSInfo) *tlsReport {
+	if t == nil {
+		return nil
+	}
+	rep := &tlsReport{
+		Version:            t.Version,
+		CipherSuite:        t.CipherSuite,
+		ServerName:         t.ServerName,
+		ALPN:               t.ALPN,
+		Resumed:            t.Resumed,
+		Curve:              t.Curve,
+		ClientCertificates: make([]certReport, 0, len(t.ClientCerts)),
+		ClientVerified:     t.ClientVerified,
+		ClientVerifyError:  t.ClientVerifyError,
+		ClientHello:        newClientHelloReport(t.ClientHello),
+	}
+	for _, c := range t.ClientCerts {
+		rep.ClientCertificates = append(rep.ClientCertificates, certReport{
+			Subject:            c.Subject,
+			Issuer:             c.Issuer,
+			SANs:               nonNil(c.SANs),
+			Serial:             c.Serial,
+			NotBefore:          c.NotBefore,
+			NotAfter:           c.NotAfter,
+			KeyType:            c.KeyType,
+			KeyBits:            c.KeyBits,
+			KeyDetail:          c.KeyDetail,
+			SignatureAlgorithm: c.SignatureAlgorithm,
+			IsCA:               c.IsCA,
+			SHA256:             c.SHA256,
+			SHA1:               c.SHA1,
+		})
+	}
+	return rep
+}
+
+type http2Report struct {
+	Akamai            string          `json:"akamai"`
+	Settings          []settingReport `json:"settings"`
+	WindowUpdate      uint32          `json:"window_update"`
+	Priorities        []string        `json:"priorities"`
+	HeadersPriority   string          `json:"headers_priority"`
+	PseudoHeaderOrder []string        `json:"pseudo_header_order"`
+	H2CUpgrade        bool            `json:"h2c_upgrade"`
+}
+
+type settingReport struct {
+	ID    uint16 `json:"id"`
+	Name  string `json:"name"`
+	Value uint32 `json:"value"`
+}
+
+func newHTTP2Report(h *HTTP2Info) *http2Report {
+	if h == nil {
+		return nil
+	}
+	rep := &http2Report{
+		Akamai:            h.Akamai,
+		Settings:          make([]settingReport, 0, len(h.Settings)),
+		WindowUpdate:      h.WindowUpdate,
+		Priorities:        nonNil(h.Priorities),
+		HeadersPriority:   h.HeadersPriority,
+		PseudoHeaderOrder: nonNil(h.PseudoHeaders),
+		H2CUpgrade:        h.Upgraded,
+	}
+	// The first request of an upgraded connection came as HTTP/1.1
+	if h.Upgraded {
+		rep.PseudoHeaderOrder = nil
+	}
+	for _, s := range h.Settings {
+		rep.Settings = append(rep.Settings, settingReport{ID: s.ID, Name: s.Name, Value: s.Value})
+	}
+	return rep
+}
+
+type headerReport struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type headerOrderReport struct {
+	Headers     []headerReport `json:"headers"`
+	Fingerprint string         `json:"fingerprint"`
+}
+
+func newHeaderOrderReport(h *HeaderOrderInfo) *headerOrderReport {
+	if h == nil {
+		return nil
+	}
+	rep := &headerOrderReport{
+		Headers:     make([]headerReport, 0, len(h.Headers)),
+		Fingerprint: h.Fingerprint,
+	}
+	for _, header := range h.Headers {
+		rep.Headers = append(rep.Headers, headerReport{Name: header.Name, Value: header.Value})
+	}
+	return rep
+}
+
+type cookiesReport struct {
+	List      []cookieReport `json:"list"`
+	TotalSize int            `json:"total_size"`
+	Warnings  []string       `json:"warnings"`
+}
+
+type cookieReport struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Size      int    `json:"size"`
+	Duplicate bool   `json:"duplicate"`
+	Encoding  string `json:"encoding"`
+	Decoded   string `json:"decoded"`
+	JWTHeader string `json:"jwt_header"`
+	JWTClaims string `json:"jwt_claims"`
+}
+
+func newCookiesReport(c parser.CookieInfo) cookiesReport {
+	rep := cookiesReport{
+		List:      make([]cookieReport, 0, len(c.Cookies)),
+		TotalSize: c.Size,
+		Warnings:  nonNil(c.Warnings),
+	}
+	for _, cookie := range c.Cookies {
+		rep.List = append(rep.List, cookieReport{
+			Name:      cookie.Name,
+			Value:     cookie.Value,
+			Size:      cookie.Size,
+			Duplicate: cookie.Duplicate,
+			Encoding:  cookie.Encoding,
+			Decoded:   cookie.Decoded,
+			JWTHeader: cookie.JWTHeader,
+			JWTClaims: cookie.JWTClaims,
+		})
+	}
+	return rep
+}
+
+type hopReport struct {
+	Address string `json:"address"`
+	IP      string `json:"ip"`
+	Source  string `json:"source"`
+	Trusted bool   `json:"trusted"`
+	Valid   bool   `json:"valid"`
+	Client  bool   `json:"client"`
+}
+
+type proxyProtoReport struct {
+	Version          int              `json:"version"`
+	Command          string           `json:"command"`
+	Transport        string           `json:"transport"`
+	Source           string           `json:"source"`
+	Destination      string           `json:"destination"`
+	ALPN             string           `json:"alpn"`
+	Authority        string           `json:"authority"`
+	UniqueID         string           `json:"unique_id"`
+	NetNS            string           `json:"netns"`
+	AWSVPCEndpointID string           `json:"aws_vpc_endpoint_id"`
+	CRC32CVerified   bool             `json:"crc32c_verified"`
+	SSL              *proxySSLReport  `json:"ssl"`
+	TLVs             []proxyTLVReport `json:"tlvs"`
+}
+
+type proxySSLReport struct {
+	ClientSSL      bool   `json:"client_ssl"`
+	ClientCertConn bool   `json:"client_cert_conn"`
+	ClientCertSess bool   `json:"client_cert_sess"`
+	Verified       bool   `json:"verified"`
+	Version        string `json:"version"`
+	CN             string `json:"cn"`
+	Cipher         string `json:"cipher"`
+	SigAlg         string `json:"sig_alg"`
+	KeyAlg         string `json:"key_alg"`
+}
+
+type proxyTLVReport struct {
+	Type  int    `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"` // hex-encoded
+}
+
+func newProxyProtoReport(h *proxyproto.Header) *proxyProtoReport {
+	if h == nil {
+		return nil
+	}
+
+	rep := &proxyProtoReport{
+		Version:          h.Version,
+		Command:          h.Command,
+		Transport:        h.Transport,
+		ALPN:             h.ALPN,
+		Authority:        h.Authority,
+		UniqueID:         h.UniqueID,
+		NetNS:            h.NetNS,
+		AWSVPCEndpointID: h.AWSVPCEndpointID,
+		CRC32CVerified:   h.CRC32CVerified,
+		TLVs:             make([]proxyTLVReport, 0, len(h.TLVs)),
+	}
+	if h.Source.IsValid() {
+		rep.Source = h.Source.String()
+		rep.Destination = h.Destination.String()
+	}
+	if h.SSL != nil {
+		rep.SSL = &proxySSLReport{
+			ClientSSL:      h.SSL.ClientSSL,
+			ClientCertConn: h.SSL.ClientCertConn,
+			ClientCertSess: h.SSL.ClientCertSess,
+			Verified:       h.SSL.Verified,
+			Version:        h.SSL.Version,
+			CN:             h.SSL.CN,
+			Cipher:         h.SSL.Cipher,
+			SigAlg:         h.SSL.SigAlg,
+			KeyAlg:         h.SSL.KeyAlg,
+		}
+	}
+	for _, tlv := range h.TLVs {
+		rep.TLVs = append(rep.TLVs, proxyTLVReport{
+			Type:  int(tlv.Type),
+			Name:  tlv.Name(),
+			Value: hex.EncodeToString(tlv.Value),
+		})
+	}
+	return rep
+}
+
+type forwardedReport struct {
+	For   forwardedNodeReport `json:"for"`
+	By    forwardedNodeReport `json:"by"`
+	Proto string              `json:"proto"`
+	Host  string              `json:"host"`
+}
+
+type forwardedNodeReport struct {
+	Raw        string `json:"raw"`
+	IP         string `json:"ip"`
+	Port       string `json:"port"`
+	Obfuscated bool   `json:"obfuscated"`
+	Unknown    bool   `json:"unknown"`
+}
+
+func newForwardedNodeReport(n parser.ForwardedNode) forwardedNodeReport {
+	return forwardedNodeReport{
+		Raw:        n.Raw,
+		IP:         n.IP,
+		Port:       n.Port,
+		Obfuscated: n.Obfuscated,
+		Unknown:    n.Unknown,
+	}
+}
+
+type userAgentReport struct {
+	Raw            string                 `json:"raw"`
+	BrowserName    string                 `json:"browser_name"`
+	BrowserVersion string                 `json:"browser_version"`
+	Engine         string                 `json:"engine"`
+	EngineVersion  string                 `json:"engine_version"`
+	OSName         string                 `json:"os_name"`
+	OSVersion      string                 `json:"os_version"`
+	Mobile         bool                   `json:"mobile"`
+	Device         string                 `json:"device"`
+	Vendor         string                 `json:"vendor"`
+	Model          string                 `json:"model"`
+	Architecture   string                 `json:"architecture"`
+	Bitness        string                 `json:"bitness"`
+	Category       string                 `json:"category"`
+	Bot            *botReport             `json:"bot"`
+	CLI            bool                   `json:"cli"`
+	Parsed         bool                   `json:"parsed"`
+	Sources        userAgentSourcesReport `json:"sources"`
+	ClientHints    *clientHintsReport     `json:"client_hints"`
+}
+
+type userAgentSourcesReport struct {
+	BrowserName    string `json:"browser_name"`
+	BrowserVersion string `json:"browser_version"`
+	Engine         string `json:"engine"`
+	OSName         string `json:"os_name"`
+	OSVersion      string `json:"os_version"`
+	Mobile         string `json:"mobile"`
+	Device         string `json:"device"`
+	Vendor         string `json:"vendor"`
+	Model          string `json:"model"`
+	Architecture   string `json:"architecture"`
+	Bitness        string `json:"bitness"`
+}
+
+type botReport struct {
+	Name         string   `json:"name"`
+	Operator     string   `json:"operator"`
+	Domains      []string `json:"domains"`
+	Verified     *bool    `json:"verified"`
+	VerifiedName string   `json:"verified_name"`
+	VerifyError  string   `json:"verify_error"`
+}
+
+type clientHintsReport struct {
+	Brands          []brandReport `json:"brands"`
+	FullVersionList []brandReport `json:"full_version_list"`
+	Mobile          *bool         `json:"mobile"`
+	Platform        string        `json:"platform"`
+	PlatformVersion string        `json:"platform_version"`
+	Arch            string        `json:"arch"`
+	Model           string        `json:"model"`
+	Bitness         string        `json:"bitness"`
+}
+
+type brandReport struct {
+	Brand   string `json:"brand"`
+	Version string `json:"version"`
+	GREASE  bool   `json:"grease"`
+}
+
+func newUserAgentReport(ua parser.UserAgentInfo) userAgentReport {
+	return userAgentReport{
+		Raw:            ua.Raw,
+		BrowserName:    ua.BrowserName,
+		BrowserVersion: ua.BrowserVersion,
+		Engine:         ua.Engine,
+		EngineVersion:  ua.EngineVersion,
+		OSName:         ua.OSName,
+		OSVersion:      ua.OSVersion,
+		Mobile:         ua.Mobile,
+		Device:         ua.Device,
+		Vendor:         ua.Vendor,
+		Model:          ua.Model,
+		Architecture:   ua.Architecture,
+		Bitness:        ua.Bitness,
+		Category:       ua.Category,
+		Bot:            newBotReport(ua.Bot),
+		CLI:            ua.CLI,
+		Parsed:         ua.Parsed,
+		Sources: userAgentSourcesReport{
+			BrowserName:    ua.Sources.Browser,
+			BrowserVersion: ua.Sources.BrowserVersion,
+			Engine:         ua.Sources.Engine,
+			OSName:         ua.Sources.OS,
+			OSVersion:      ua.Sources.OSVersion,
+			Mobile:         ua.Sources.Mobile,
+			Device:         ua.Sources.Device,
+			Vendor:         ua.Sources.Vendor,
+			Model:          ua.Sources.Model,
+			Architecture:   ua.Sources.Architecture,
+			Bitness:        ua.Sources.Bitness,
+		},
+		ClientHints: newClientHintsReport(ua.Hints),
+	}
+}
+
+func newBotReport(b *parser.Bot) *botReport {
+	if b == nil {
+		return nil
+	}
+	return &botReport{
+		Name:         b.Name,
+		Operator:     b.Operator,
+		Domains:      nonNil(b.Domains),
+		Verified:     b.Verified,
+		VerifiedName: b.VerifiedName,
+		VerifyError:  b.VerifyError,
+	}
+}
+
+func newClientHintsReport(h *parser.ClientHintsInfo) *clientHintsReport {
+	if h == nil {
+		return nil
+	}
+	return &clientHintsReport{
+		Brands:          newBrandReports(h.Brands),
+		FullVersionList: newBrandReports(h.FullVersionList),
+		Mobile:          h.Mobile,
+		Platform:        h.Platform,
+		PlatformVersion: h.PlatformVersion,
+		Arch:            h.Arch,
+		Model:           h.Model,
+		Bitness:         h.Bitness,
+	}
+}
+
+func newBrandReports(brands []parser.Brand) []brandReport {
+	reps := make([]brandReport, 0, len(brands))
+	for _, b := range brands {
+		reps = append(reps, brandReport{Brand: b.Name, Version: b.Version, GREASE: b.GREASE})
+	}
+	return reps
+}
+
+type negotiationReport struct {
+	Languages  []languageReport  `json:"languages"`
+	MediaTypes []mediaTypeReport `json:"media_types"`
+	Encodings  []encodingReport  `json:"encodings"`
+}
+
+type languageReport struct {
+	Tag  string  `json:"tag"`
+	Name string  `json:"name"`
+	Q    float64 `json:"q"`
+}
+
+type mediaTypeReport struct {
+	MediaType string            `json:"media_type"`
+	Params    map[string]string `json:"params"`
+	Q         float64           `json:"q"`
+}
+
+type encodingReport struct {
+	Coding string  `json:"coding"`
+	Q      float64 `json:"q"`
+}
+
+func newNegotiationReport(n NegotiationInfo) negotiationReport {
+	rep := negotiationReport{
+		Languages:  make([]languageReport, 0, len(n.Languages)),
+		MediaTypes: make([]mediaTypeReport, 0, len(n.MediaTypes)),
+		Encodings:  make([]encodingReport, 0, len(n.Encodings)),
+	}
+	for _, l := range n.Languages {
+		rep.Languages = append(rep.Languages, languageReport{Tag: l.Tag, Name: l.Name, Q: l.Q})
+	}
+	for _, m := range n.MediaTypes {
+		params := m.Params
+		if params == nil {
+			params = map[string]string{}
+		}
+		rep.MediaTypes = append(rep.MediaTypes, mediaTypeReport{MediaType: m.MediaType(), Params: params, Q: m.Q})
+	}
+	for _, c := range n.Encodings {
+		rep.Encodings = append(rep.Encodings, encodingReport{Coding: c.Name, Q: c.Q})
+	}
+	return rep
+}
+
+// newReport converts a ConnectionInfo into its machine-readable form.
+// Collections are always non-nil so they encode as {} and [] rather than null.
+func newReport(info ConnectionInfo) report {
+	query := info.QueryParams
+	if query == nil {
+		query = map[string][]string{}
+	}
+
+	headers := make([]headerReport, 0, len(info.Headers))
+	for _, h := range info.Headers {
+		headers = append(headers, headerReport{Name: h.Name, Value: h.Value})
+	}
+
+	forwarded := make([]forwardedReport, 0, len(info.Forwarded))
+	for _, fe := range info.Forwarded {
+		forwarded = append(forwarded, forwardedReport{
+			For:   newForwardedNodeReport(fe.For),
+			By:    newForwardedNodeReport(fe.By),
+			Proto: fe.Proto,
+			Host:  fe.Host,
+		})
+	}
+
+	chain := make([]hopReport, 0, len(info.Chain))
+	for _, hop := range info.Chain {
+		chain = append(chain, hopReport{
+			Address: hop.Address,
+			IP:      hop.IP,
+			Source:  hop.Source,
+			Trusted: hop.Trusted,
+			Valid:   hop.Valid,
+			Client:  hop.Client,
+		})
+	}
+
+	return report{
+		ClientIP:    info.ClientIP,
+		IPInfo:      newIPInfoReport(info.ClientIPInfo),
+		Location:    newLocationReport(info.Location),
+		ReverseDNS:  newReverseDNSReport(info.ReverseDNS),
+		RemoteAddr:  info.RawRemoteAddr,
+		Method:      info.Method,
+		Protocol:    info.Protocol,
+		Path:        info.Path,
+		QueryParams: query,
+		Headers:     headers,
+		HeaderOrder: newHeaderOrderReport(info.HeaderOrder),
+		Cookies:     newCookiesReport(info.Cookies),
+		Forwarded:   forwarded,
+		ProxyChain:  chain,
+		ProxyProto:  newProxyProtoReport(info.ProxyProtocol),
+		TLS:         newTLSReport(info.TLS),
+		HTTP2:       newHTTP2Report(info.HTTP2),
+		UserAgent:   newUserAgentReport(info.UserAgent),
+		Negotiation: newNegotiationReport(info.Negotiation),
+		Redacted:    info.Redacted,
+		Timestamp:   info.Timestamp,
+	}
+}
+
+// RenderJSON writes the connection info as an indented JSON document.
+func RenderJSON(w io.Writer, info ConnectionInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newReport(info))
+}
@@ -0,0 +1,552 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"connectionInfo/internal/geoip"
+	"connectionInfo/internal/parser"
+	"connectionInfo/internal/proxyproto"
+	"connectionInfo/internal/rdns"
+)
+
+func TestRenderJSON(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:      "192.168.1.100",
+		RawRemoteAddr: "192.168.1.100:12345",
+		Method:        "GET",
+		Path:          "/",
+		QueryParams:   map[string][]string{"test": {"value"}},
+		Headers: []HeaderPair{
+			{Name: "Accept", Value: "application/json"},
+		},
+		UserAgent: parser.UserAgentInfo{
+			Raw:            "Mozilla/5.0 Chrome/120.0",
+			BrowserName:    "Chrome",
+			BrowserVersion: "120.0",
+			OSName:         "Windows 10",
+			Parsed:         true,
+		},
+		Timestamp: time.Date(2024, 1, 15, 12, 30, 45, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	expected := map[string]any{
+		"client_ip":   "192.168.1.100",
+		"remote_addr": "192.168.1.100:12345",
+		"method":      "GET",
+		"path":        "/",
+		"timestamp":   "2024-01-15T12:30:45Z",
+	}
+	for key, want := range expected {
+		if got[key] != want {
+			t.Errorf("%s = %v, want %v", key, got[key], want)
+		}
+	}
+
+	ua, ok := got["user_agent"].(map[string]any)
+	if !ok {
+		t.Fatalf("user_agent is not an object: %v", got["user_agent"])
+	}
+	if ua["browser_name"] != "Chrome" || ua["browser_version"] != "120.0" || ua["os_name"] != "Windows 10" || ua["parsed"] != true {
+		t.Errorf("unexpected user_agent: %v", ua)
+	}
+
+	headers, ok := got["headers"].([]any)
+	if !ok || len(headers) != 1 {
+		t.Fatalf("headers = %v, want one entry", got["headers"])
+	}
+	if h := headers[0].(map[string]any); h["name"] != "Accept" || h["value"] != "application/json" {
+		t.Errorf("unexpected header entry: %v", h)
+	}
+}
+
+func TestRenderJSON_EmptyCollections(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.168.1.100",
+		Method:    "GET",
+		Path:      "/",
+		Timestamp: time.Now().UTC(),
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	body := buf.String()
+	if !strings.Contains(body, `"query_params": {}`) {
+		t.Errorf("nil query params should encode as {}, got %s", body)
+	}
+	if !strings.Contains(body, `"headers": []`) {
+		t.Errorf("nil headers should encode as [], got %s", body)
+	}
+	if !strings.Contains(body, `"tls": null`) {
+		t.Errorf("plain HTTP should encode tls as null, got %s", body)
+	}
+	if !strings.Contains(body, `"reverse_dns": null`) {
+		t.Errorf("missing reverse DNS should encode as null, got %s", body)
+	}
+	if !strings.Contains(body, `"location": null`) {
+		t.Errorf("missing location should encode as null, got %s", body)
+	}
+	if !strings.Contains(body, `"proxy_protocol": null`) {
+		t.Errorf("missing PROXY header should encode as null, got %s", body)
+	}
+}
+
+func TestRenderJSON_ProxyProtocol(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.1",
+		Method:    "GET",
+		Path:      "/",
+		Timestamp: time.Now().UTC(),
+		ProxyProtocol: &proxyproto.Header{
+			Version:     2,
+			Command:     "PROXY",
+			Transport:   "TCP4",
+			Source:      netip.MustParseAddrPort("192.0.2.1:56324"),
+			Destination: netip.MustParseAddrPort("198.51.100.1:443"),
+			TLVs:        []proxyproto.TLV{{Type: proxyproto.TypeALPN, Value: []byte("h2")}},
+			ALPN:        "h2",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var got struct {
+		ProxyProtocol struct {
+			Version     int    `json:"version"`
+			Source      string `json:"source"`
+			Destination string `json:"destination"`
+			ALPN        string `json:"alpn"`
+			TLVs        []struct {
+				Type  int    `json:"type"`
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"tlvs"`
+		} `json:"proxy_protocol"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	pp := got.ProxyProtocol
+	if pp.Version != 2 || pp.Source != "192.0.2.1:56324" || pp.Destination != "198.51.100.1:443" || pp.ALPN != "h2" {
+		t.Errorf("unexpected proxy_protocol: %+v", pp)
+	}
+	if len(pp.TLVs) != 1 || pp.TLVs[0].Value != "6832" {
+		t.Errorf("tlvs = %+v, want one hex-encoded ALPN entry", pp.TLVs)
+	}
+}
+
+func TestRenderJSON_Location(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		Location:  &geoip.Location{CountryCode: "DE", ASN: 64500, Organization: "Example Networks"},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var got struct {
+		Location map[string]any `json:"location"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	loc := got.Location
+	if loc["country_code"] != "DE" || loc["asn"] != float64(64500) || loc["organization"] != "Example Networks" {
+		t.Errorf("unexpected location: %v", loc)
+	}
+	if lat, ok := loc["latitude"]; !ok || lat != nil {
+		t.Errorf("latitude = %v, want null without coordinates", lat)
+	}
+}
+
+func TestRenderJSON_ReverseDNS(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		ReverseDNS: &rdns.Result{
+			Names:            []rdns.Name{{Name: "host.example", Confirmed: true}},
+			ForwardConfirmed: true,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	for _, expected := range []string{
+		`"forward_confirmed": true,`,
+		`"name": "host.example",`,
+		`"error": ""`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("output does not contain %s, got %s", expected, buf.String())
+		}
+	}
+}
+
+func TestRenderJSON_ClientCertificates(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		TLS: &TLSInfo{
+			Version: "TLS 1.3",
+			ClientCerts: []CertInfo{{
+				Subject:   "CN=alice",
+				Issuer:    "CN=Example CA",
+				SANs:      []string{"email:alice@example.com"},
+				Serial:    "12:34",
+				NotBefore: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				NotAfter:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				KeyType:   "ECDSA",
+				KeyBits:   256,
+				KeyDetail: "P-256",
+			}},
+			ClientVerified: true,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	for _, expected := range []string{
+		`"subject": "CN=alice",`,
+		`"sans": [`,
+		`"email:alice@example.com"`,
+		`"serial": "12:34",`,
+		`"not_before": "2024-01-01T00:00:00Z",`,
+		`"key_detail": "P-256",`,
+		`"client_verified": true,`,
+		`"client_verify_error": ""`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("output does not contain %s, got %s", expected, buf.String())
+		}
+	}
+
+	buf.Reset()
+	info.TLS = &TLSInfo{Version: "TLS 1.3"}
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"client_certificates": [],`) {
+		t.Errorf("client_certificates should be an empty array, got %s", buf.String())
+	}
+}
+
+func TestRenderJSON_ClientHello(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		TLS: &TLSInfo{
+			Version: "TLS 1.3",
+			ClientHello: &ClientHelloInfo{
+				JA4:          "t13d1516h2_8daaf6152771_e5627efa2ab1",
+				Version:      CodePoint{Value: 0x0303, Name: "TLS 1.2"},
+				CipherSuites: []CodePoint{{Value: 0x1a1a, Name: "GREASE"}, {Value: 0x1301, Name: "TLS_AES_128_GCM_SHA256"}},
+				GREASE:       []string{"0x1a1a"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	for _, expected := range []string{
+		`"ja4": "t13d1516h2_8daaf6152771_e5627efa2ab1",`,
+		`"value": "0x0303",`,
+		`"name": "TLS_AES_128_GCM_SHA256"`,
+		`"grease": [`,
+		`"extensions": [],`,
+		`"alpn": []`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("output does not contain %s, got %s", expected, buf.String())
+		}
+	}
+
+	buf.Reset()
+	info.TLS.ClientHello = nil
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"client_hello": null`) {
+		t.Errorf("client_hello should be null when not captured, got %s", buf.String())
+	}
+}
+
+func TestRenderJSON_HTTP2(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		Protocol:  "HTTP/2.0",
+		HTTP2: &HTTP2Info{
+			Akamai:   "2:0|00|0|",
+			Settings: []HTTP2Setting{{ID: 2, Name: "ENABLE_PUSH", Value: 0}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	for _, expected := range []string{
+		`"protocol": "HTTP/2.0",`,
+		`"akamai": "2:0|00|0|",`,
+		`"name": "ENABLE_PUSH",`,
+		`"window_update": 0,`,
+		`"priorities": [],`,
+		`"pseudo_header_order": []`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("output does not contain %s, got %s", expected, buf.String())
+		}
+	}
+
+	buf.Reset()
+	info.HTTP2 = nil
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"http2": null`) {
+		t.Errorf("http2 should be null for other protocols, got %s", buf.String())
+	}
+}
+
+func TestRenderJSON_HeaderOrder(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		HeaderOrder: &HeaderOrderInfo{
+			Headers:     []HeaderPair{{Name: "host", Value: "example.com"}, {Name: "X-Dup", Value: "a"}, {Name: "X-Dup", Value: "b"}},
+			Fingerprint: "5e1f0d6c2a9b",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var got struct {
+		HeaderOrder struct {
+			Headers     []headerReport `json:"headers"`
+			Fingerprint string         `json:"fingerprint"`
+		} `json:"header_order"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if want := []headerReport{{"host", "example.com"}, {"X-Dup", "a"}, {"X-Dup", "b"}}; !slices.Equal(got.HeaderOrder.Headers, want) {
+		t.Errorf("header_order.headers = %v, want %v", got.HeaderOrder.Headers, want)
+	}
+	if got.HeaderOrder.Fingerprint != "5e1f0d6c2a9b" {
+		t.Errorf("header_order.fingerprint = %q", got.HeaderOrder.Fingerprint)
+	}
+
+	buf.Reset()
+	info.HeaderOrder = nil
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"header_order": null`) {
+		t.Errorf("header_order should be null when not recorded, got %s", buf.String())
+	}
+}
+
+func TestRenderJSON_Cookies(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		Cookies: parser.CookieInfo{
+			Cookies: []parser.Cookie{
+				{Name: "token", Value: "x.y.z", Size: 10, Encoding: "jwt", JWTHeader: `{"alg":"HS256"}`, JWTClaims: `{"sub":"1"}`},
+			},
+			Size: 11,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var got struct {
+		Cookies struct {
+			List      []cookieReport `json:"list"`
+			TotalSize int            `json:"total_size"`
+			Warnings  []string       `json:"warnings"`
+		} `json:"cookies"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	want := cookieReport{Name: "token", Value: "x.y.z", Size: 10, Encoding: "jwt", JWTHeader: `{"alg":"HS256"}`, JWTClaims: `{"sub":"1"}`}
+	if len(got.Cookies.List) != 1 || got.Cookies.List[0] != want {
+		t.Errorf("cookies.list = %+v, want [%+v]", got.Cookies.List, want)
+	}
+	if got.Cookies.TotalSize != 11 {
+		t.Errorf("cookies.total_size = %d, want 11", got.Cookies.TotalSize)
+	}
+
+	buf.Reset()
+	if err := RenderJSON(&buf, ConnectionInfo{}); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"list": []`) || !strings.Contains(buf.String(), `"warnings": []`) {
+		t.Errorf("cookies should encode empty lists without cookies, got %s", buf.String())
+	}
+}
+
+func TestRenderJSON_Negotiation(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Timestamp: time.Now().UTC(),
+		Negotiation: NegotiationInfo{
+			Languages:  parser.ParseAcceptLanguage("en-US, en;q=0.9"),
+			MediaTypes: parser.ParseAccept("text/plain; format=flowed, application/json;q=0.5"),
+			Encodings:  parser.ParseAcceptEncoding("gzip, br"),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var got struct {
+		Negotiation negotiationReport `json:"negotiation"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	n := got.Negotiation
+	if want := []languageReport{{"en-US", "English (United States)", 1}, {"en", "English", 0.9}}; !slices.Equal(n.Languages, want) {
+		t.Errorf("negotiation.languages = %v, want %v", n.Languages, want)
+	}
+	if len(n.MediaTypes) != 2 || n.MediaTypes[0].MediaType != "text/plain" || n.MediaTypes[0].Params["format"] != "flowed" || n.MediaTypes[1].Q != 0.5 {
+		t.Errorf("negotiation.media_types = %+v", n.MediaTypes)
+	}
+	if want := []encodingReport{{"gzip", 1}, {"br", 1}}; !slices.Equal(n.Encodings, want) {
+		t.Errorf("negotiation.encodings = %v, want %v", n.Encodings, want)
+	}
+
+	buf.Reset()
+	info.Negotiation = NegotiationInfo{MediaTypes: parser.ParseAccept("*/*")}
+	if err := RenderJSON(&buf, info); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+	for _, expected := range []string{`"languages": []`, `"params": {}`, `"encodings": []`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("output does not contain %s, got %s", expected, buf.String())
+		}
+	}
+}
+
+func TestRenderJSON_ClientHints(t *testing.T) {
+	mobile := true
+	ua := parser.ParseUserAgent("Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Mobile Safari/537.36")
+	ua.MergeClientHints(&parser.ClientHintsInfo{
+		Brands:   []parser.Brand{{Name: "Chromium", Version: "121"}},
+		Mobile:   &mobile,
+		Platform: "Android",
+		Model:    "Pixel 7",
+	})
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, ConnectionInfo{UserAgent: ua}); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var got struct {
+		UserAgent userAgentReport `json:"user_agent"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	u := got.UserAgent
+	if u.BrowserName != "Chromium" || !u.Mobile || u.Device != "mobile" || u.Vendor != "Google" || u.Model != "Pixel 7" {
+		t.Errorf("user_agent = %+v", u)
+	}
+	wantSources := userAgentSourcesReport{BrowserName: "Sec-CH-UA", BrowserVersion: "Sec-CH-UA", Engine: "User-Agent", OSName: "User-Agent", OSVersion: "User-Agent", Mobile: "Sec-CH-UA-Mobile", Device: "User-Agent", Vendor: "Sec-CH-UA-Model", Model: "Sec-CH-UA-Model"}
+	if u.Sources != wantSources {
+		t.Errorf("user_agent.sources = %+v, want %+v", u.Sources, wantSources)
+	}
+	if h := u.ClientHints; h == nil || len(h.Brands) != 1 || h.Brands[0].Brand != "Chromium" || h.Mobile == nil || !*h.Mobile || len(h.FullVersionList) != 0 || h.FullVersionList == nil {
+		t.Errorf("user_agent.client_hints = %+v", h)
+	}
+
+	buf.Reset()
+	if err := RenderJSON(&buf, ConnectionInfo{UserAgent: parser.ParseUserAgent("curl/8.4.0")}); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"client_hints": null`) {
+		t.Errorf("client_hints should be null without hints, got %s", buf.String())
+	}
+}
+
+func TestRenderJSON_Bot(t *testing.T) {
+	ua := parser.ParseUserAgent("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)")
+	ua.Bot.Verify([]string{"crawl-66-249-66-1.googlebot.com"})
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, ConnectionInfo{UserAgent: ua}); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var got struct {
+		UserAgent userAgentReport `json:"user_agent"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	u := got.UserAgent
+	if u.BrowserName != "Googlebot" || u.Category != "bot" || u.CLI {
+		t.Errorf("user_agent = %+v", u)
+	}
+	if b := u.Bot; b == nil || b.Name != "Googlebot" || b.Operator != "Google" || len(b.Domains) != 3 ||
+		b.Verified == nil || !*b.Verified || b.VerifiedName != "crawl-66-249-66-1.googlebot.com" {
+		t.Errorf("user_agent.bot = %+v", b)
+	}
+
+	buf.Reset()
+	if err := RenderJSON(&buf, ConnectionInfo{UserAgent: parser.ParseUserAgent("curl/8.4.0")}); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+	for _, expected := range []string{`"category": "cli"`, `"bot": null`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("output does not contain %s, got %s", expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package render
+
+import (
+	"io"
+
+	"connectionInfo/internal/parser"
+)
+
+// Renderer writes a ConnectionInfo in one output format.
+type Renderer struct {
+	Format      string   // Name used with ?format=, e.g. "json"
+	MediaType   string   // Primary media type, e.g. "application/json"
+	Aliases     []string // Other media types that select this renderer
+	ContentType string   // Content-Type response header value
+	Render      func(io.Writer, ConnectionInfo) error
+}
+
+// registry holds the renderers available for content negotiation, in server
+// preference order.
+var registry = []Renderer{
+	{Format: "html", MediaType: "text/html", ContentType: "text/html; charset=utf-8", Render: Render},
+	{Format: "json", MediaType: "application/json", ContentType: "application/json", Render: RenderJSON},
+	{Format: "text", MediaType: "text/plain", ContentType: "text/plain; charset=utf-8", Render: RenderText},
+	{Format: "yaml", MediaType: "application/yaml", Aliases: []string{"application/x-yaml", "text/yaml", "text/x-yaml"}, ContentType: "application/yaml", Render: RenderYAML},
+	{Format: "xml", MediaType: "application/xml", Aliases: []string{"text/xml"}, ContentType: "application/xml; charset=utf-8", Render: RenderXML},
+	{Format: "csv", MediaType: "text/csv", ContentType: "text/csv; charset=utf-8", Render: RenderCSV},
+}
+
+// namedOnly holds renderers that share text/plain with the text renderer,
+// so they are reachable by name but never chosen by negotiation: ip prints
+// only the client IP, and raw the request as received.
+var namedOnly = []Renderer{
+	{Format: "ip", MediaType: "text/plain", ContentType: "text/plain; charset=utf-8", Render: RenderIP},
+	{Format: "raw", MediaType: "text/plain", ContentType: "text/plain; charset=utf-8", Render: RenderRaw},
+}
+
+// MediaTypes returns the primary media types available for negotiation.
+func MediaTypes() []string {
+	types := make([]string, 0, len(registry))
+	for _, r := range registry {
+		types = append(types, r.MediaType)
+	}
+	return types
+}
+
+// Lookup returns the renderer registered under the given ?format= name.
+func Lookup(format string) (Renderer, bool) {
+	for _, r := range namedOnly {
+		if r.Format == format {
+			return r, true
+		}
+	}
+	for _, r := range registry {
+		if r.Format == format {
+			return r, true
+		}
+	}
+	return Renderer{}, false
+}
+
+// Negotiate picks the renderer that best satisfies the client's Accept
+// ranges. Each renderer is scored by the most specific range that matches
+// one of its media types; the highest quality wins, then the most specific
+// match, then the range the client listed first, then the preferred format,
+// then registry order. An empty Accept header is treated as "*/*". The
+// boolean is false when nothing acceptable is available (406).
+func Negotiate(accept []parser.MediaRange, preferred string) (Renderer, bool) {
+	if len(accept) == 0 {
+		accept = []parser.MediaRange{{Type: "*", Subtype: "*", Q: 1}}
+	}
+
+	var best *candidate
+	for _, r := range registry {
+		c := candidate{renderer: r, specificity: -1}
+		for _, mediaType := range append([]string{r.MediaType}, r.Aliases...) {
+			for i, mr := range accept {
+				if !mr.Matches(mediaType) || mr.Specificity() < c.specificity {
+					continue
+				}
+				if mr.Specificity() > c.specificity || mr.Q > c.q {
+					c.q, c.specificity, c.position = mr.Q, mr.Specificity(), i
+				}
+			}
+		}
+		if c.specificity < 0 || c.q <= 0 {
+			continue
+		}
+		if best == nil || c.beats(*best, preferred) {
+			best = &c
+		}
+	}
+
+	if best == nil {
+		return Renderer{}, false
+	}
+	return best.renderer, true
+}
+
+// candidate is a renderer scored against an Accept header.
+type candidate struct {
+	renderer    Renderer
+	q           float64
+	specificity int
+	position    int
+}
+
+// beats reports whether c ranks above other. Registry order is the final
+// tie-breaker, which Negotiate gets for free by only replacing on a win.
+func (c candidate) beats(other candidate, preferred string) bool {
+	switch {
+	case c.q != other.q:
+		return c.q > other.q
+	case c.specificity != other.specificity:
+		return c.specificity > other.specificity
+	case c.position != other.position:
+		return c.position < other.position
+	default:
+		return c.renderer.Format == preferred
+	}
+}
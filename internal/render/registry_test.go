@@ -0,0 +1,64 @@
+package render
+
+import (
+	"testing"
+
+	"connectionInfo/internal/parser"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name      string
+		accept    string
+		preferred string
+		want      string
+	}{
+		{"empty header uses preferred", "", "html", "html"},
+		{"wildcard uses preferred", "*/*", "text", "text"},
+		{"browser", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "html", "html"},
+		{"exact json", "application/json", "html", "json"},
+		{"q-values", "application/json;q=0.5, application/yaml;q=0.9", "html", "yaml"},
+		{"yaml alias", "text/x-yaml", "html", "yaml"},
+		{"xml alias", "text/xml", "html", "xml"},
+		{"csv", "text/csv", "html", "csv"},
+		{"client order breaks ties", "application/json, text/html", "html", "json"},
+		{"exact beats wildcard at equal q", "*/*, text/plain", "html", "text"},
+		{"type wildcard", "text/*", "html", "html"},
+		{"type wildcard with preference", "text/*", "text", "text"},
+		{"specific q=0 excludes", "text/html;q=0, */*", "html", "json"},
+		{"specific range overrides wildcard q", "*/*;q=0.1, application/xml", "html", "xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Negotiate(parser.ParseAccept(tt.accept), tt.preferred)
+			if !ok {
+				t.Fatalf("Negotiate(%q) found no renderer", tt.accept)
+			}
+			if got.Format != tt.want {
+				t.Errorf("Negotiate(%q) = %s, want %s", tt.accept, got.Format, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiate_NotAcceptable(t *testing.T) {
+	for _, accept := range []string{"image/png", "*/*;q=0", "application/pdf, image/*"} {
+		if got, ok := Negotiate(parser.ParseAccept(accept), "html"); ok {
+			t.Errorf("Negotiate(%q) = %s, want no renderer", accept, got.Format)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, format := range []string{"html", "json", "text", "yaml", "xml", "csv", "ip", "raw"} {
+		r, ok := Lookup(format)
+		if !ok || r.Format != format || r.Render == nil || r.ContentType == "" {
+			t.Errorf("Lookup(%q) = %+v, %v", format, r, ok)
+		}
+	}
+
+	if _, ok := Lookup("pdf"); ok {
+		t.Errorf("Lookup(%q) should fail", "pdf")
+	}
+}
@@ -0,0 +1,326 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"connectionInfo/internal/geoip"
+	"connectionInfo/internal/parser"
+	"connectionInfo/internal/rdns"
+)
+
+// RenderText writes a compact plain-text report suited to terminals.
+func RenderText(w io.Writer, info ConnectionInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+
+	client := info.UserAgent.BrowserName
+	if info.UserAgent.BrowserVersion != "" {
+		client += " " + info.UserAgent.BrowserVersion
+	}
+	if info.UserAgent.OSName != "Unknown" {
+		client += " on " + info.UserAgent.OSName
+		if info.UserAgent.OSVersion != "" {
+			client += " " + info.UserAgent.OSVersion
+		}
+	}
+	switch ua := info.UserAgent; ua.Category {
+	case parser.CategoryBrowser, parser.CategoryCLI, parser.CategoryUnknown, "":
+	default:
+		// Flag automated clients, and whether a bot's claim checked out
+		client += " (" + ua.Category
+		if ua.Bot != nil && ua.Bot.Verified != nil {
+			if *ua.Bot.Verified {
+				client += ", verified"
+			} else {
+				client += ", not verified"
+			}
+		}
+		client += ")"
+	}
+
+	fmt.Fprintf(tw, "IP:\t%s\n", info.ClientIP)
+	if labels := info.ClientIPInfo.Labels(); len(labels) > 0 {
+		class := strings.Join(labels, ", ")
+		if info.ClientIPInfo.EmbeddedIPv4 != "" {
+			class += " (embedded " + info.ClientIPInfo.EmbeddedIPv4 + ")"
+		}
+		fmt.Fprintf(tw, "IP Type:\t%s\n", class)
+	}
+	if loc := info.Location; loc != nil {
+		if place := formatPlace(loc); place != "" {
+			fmt.Fprintf(tw, "Location:\t%s\n", place)
+		}
+		if loc.TimeZone != "" {
+			fmt.Fprintf(tw, "Time Zone:\t%s\n", loc.TimeZone)
+		}
+		if loc.ASN != 0 {
+			fmt.Fprintf(tw, "Network:\t%s\n", strings.TrimSpace(fmt.Sprintf("AS%d %s", loc.ASN, loc.Organization)))
+		}
+	}
+	if res := info.ReverseDNS; res != nil {
+		fmt.Fprintf(tw, "Reverse DNS:\t%s\n", formatReverseDNS(res))
+	}
+	fmt.Fprintf(tw, "Remote Addr:\t%s\n", info.RawRemoteAddr)
+	if t := info.TLS; t != nil {
+		fmt.Fprintf(tw, "TLS:\t%s\n", formatTLS(t))
+		if len(t.ClientCerts) > 0 {
+			fmt.Fprintf(tw, "Client Cert:\t%s\n", formatClientCert(t))
+		}
+		if h := t.ClientHello; h != nil {
+			fmt.Fprintf(tw, "JA3:\t%s\n", h.JA3Hash)
+			fmt.Fprintf(tw, "JA4:\t%s\n", h.JA4)
+		}
+	}
+	fmt.Fprintf(tw, "Method:\t%s\n", info.Method)
+	if info.Protocol != "" {
+		fmt.Fprintf(tw, "Protocol:\t%s\n", info.Protocol)
+	}
+	if h := info.HTTP2; h != nil {
+		akamai := h.Akamai
+		if h.Upgraded {
+			akamai += " (h2c upgrade; pseudo-header order not observed)"
+		}
+		fmt.Fprintf(tw, "HTTP/2:\t%s\n", akamai)
+	}
+	if h := info.HeaderOrder; h != nil {
+		fmt.Fprintf(tw, "Header Hash:\t%s\n", h.Fingerprint)
+	}
+	if c := info.Cookies; len(c.Cookies) > 0 {
+		fmt.Fprintf(tw, "Cookies:\t%d, %d bytes\n", len(c.Cookies), c.Size)
+	}
+	fmt.Fprintf(tw, "Path:\t%s\n", info.Path)
+	fmt.Fprintf(tw, "Query:\t%s\n", formatQuery(info.QueryParams))
+	fmt.Fprintf(tw, "Client:\t%s\n", client)
+	if ua := info.UserAgent; ua.Device != "" {
+		device := ua.Device
+		if model := strings.TrimSpace(ua.Vendor + " " + ua.Model); model != "" {
+			device += ", " + model
+		}
+		fmt.Fprintf(tw, "Device:\t%s\n", device)
+	}
+	fmt.Fprintf(tw, "User-Agent:\t%s\n", orNotProvided(info.UserAgent.Raw))
+	if langs := info.Negotiation.Languages; len(langs) > 0 {
+		list := make([]string, 0, len(langs))
+		for _, l := range langs {
+			list = append(list, weighted(l.Tag, l.Q))
+		}
+		fmt.Fprintf(tw, "Languages:\t%s\n", strings.Join(list, ", "))
+	}
+	if codings := info.Negotiation.Encodings; len(codings) > 0 {
+		list := make([]string, 0, len(codings))
+		for _, c := range codings {
+			list = append(list, weighted(c.Name, c.Q))
+		}
+		fmt.Fprintf(tw, "Encodings:\t%s\n", strings.Join(list, ", "))
+	}
+	fmt.Fprintf(tw, "Timestamp:\t%s\n", info.Timestamp.Format("2006-01-02T15:04:05Z07:00"))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(info.Chain) > 0 {
+		if _, err := fmt.Fprintln(w, "\nProxy chain:"); err != nil {
+			return err
+		}
+		for i, hop := range info.Chain {
+			var flags []string
+			if hop.Trusted {
+				flags = append(flags, "trusted")
+			}
+			if !hop.Valid {
+				flags = append(flags, "invalid")
+			}
+			if hop.Client {
+				flags = append(flags, "client")
+			}
+			line := fmt.Sprintf("  %d: %s (%s)", i, hop.Address, hop.Source)
+			if len(flags) > 0 {
+				line += " [" + strings.Join(flags, ", ") + "]"
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	if h := info.ProxyProtocol; h != nil {
+		line := fmt.Sprintf("\nPROXY protocol: v%d %s %s", h.Version, h.Command, h.Transport)
+		if h.Source.IsValid() {
+			line += fmt.Sprintf(" %s -> %s", h.Source, h.Destination)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	if len(info.Forwarded) > 0 {
+		if _, err := fmt.Fprintln(w, "\nForwarded:"); err != nil {
+			return err
+		}
+		for i, fe := range info.Forwarded {
+			if _, err := fmt.Fprintf(w, "  %d: for=%s by=%s proto=%s host=%s\n", i, fe.For.Raw, fe.By.Raw, fe.Proto, fe.Host); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(info.Cookies.Warnings) > 0 {
+		if _, err := fmt.Fprintln(w, "\nCookie warnings:"); err != nil {
+			return err
+		}
+		for _, warning := range info.Cookies.Warnings {
+			if _, err := fmt.Fprintf(w, "  %s\n", warning); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Headers are listed as sent when that is known
+	headers := info.Headers
+	if info.HeaderOrder != nil {
+		headers = info.HeaderOrder.Headers
+	}
+	if _, err := fmt.Fprintln(w, "\nHeaders:"); err != nil {
+		return err
+	}
+	for _, h := range headers {
+		if _, err := fmt.Fprintf(w, "  %s: %s\n", h.Name, h.Value); err != nil {
+			return err
+		}
+	}
+	if info.Redacted {
+		if _, err := fmt.Fprintln(w, "  (sensitive values are masked)"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderIP writes only the client IP address followed by a newline.
+func RenderIP(w io.Writer, info ConnectionInfo) error {
+	_, err := fmt.Fprintln(w, info.ClientIP)
+	return err
+}
+
+// RenderRaw writes the request as the server received it, followed by a
+// note if the body preview is truncated.
+func RenderRaw(w io.Writer, info ConnectionInfo) error {
+	if info.RawRequest == nil {
+		return nil
+	}
+	if _, err := io.WriteString(w, info.RawRequest.String()); err != nil {
+		return err
+	}
+	if info.RawRequest.Truncated {
+		_, err := io.WriteString(w, "\n[body truncated]\n")
+		return err
+	}
+	return nil
+}
+
+// formatQuery formats query parameters as "key=value" pairs in key order.
+func formatQuery(query map[string][]string) string {
+	if len(query) == 0 {
+		return "(none)"
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	return strings.Join(pairs, " ")
+}
+
+// formatPlace formats a location as "City, Region, Country (CC)", leaving
+// out the parts the databases did not provide.
+func formatPlace(loc *geoip.Location) string {
+	var parts []string
+	for _, part := range []string{loc.City, loc.Region, loc.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	place := strings.Join(parts, ", ")
+	if loc.CountryCode != "" {
+		place += " (" + loc.CountryCode + ")"
+	}
+	return strings.TrimPrefix(place, " ")
+}
+
+// formatReverseDNS lists the PTR names, marking the forward-confirmed ones.
+func formatReverseDNS(res *rdns.Result) string {
+	switch {
+	case res.Error != "":
+		return "(lookup failed: " + res.Error + ")"
+	case len(res.Names) == 0:
+		return "(no PTR record)"
+	}
+
+	names := make([]string, 0, len(res.Names))
+	for _, n := range res.Names {
+		if n.Confirmed {
+			names = append(names, n.Name+" (confirmed)")
+		} else {
+			names = append(names, n.Name+" (unconfirmed)")
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// formatTLS summarizes a TLS connection on one line, e.g.
+// "TLS 1.3, TLS_AES_128_GCM_SHA256, X25519, ALPN h2, SNI example.com".
+func formatTLS(t *TLSInfo) string {
+	parts := []string{t.Version, t.CipherSuite}
+	if t.Curve != "" {
+		parts = append(parts, t.Curve)
+	}
+	if t.ALPN != "" {
+		parts = append(parts, "ALPN "+t.ALPN)
+	}
+	if t.ServerName != "" {
+		parts = append(parts, "SNI "+t.ServerName)
+	}
+	if t.Resumed {
+		parts = append(parts, "resumed")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatClientCert summarizes the client's leaf certificate and whether its
+// chain verified, e.g. "CN=alice, issued by CN=Example CA, verified".
+func formatClientCert(t *TLSInfo) string {
+	leaf := t.ClientCerts[0]
+	line := leaf.Subject + ", issued by " + leaf.Issuer
+	if t.ClientVerified {
+		return line + ", verified"
+	}
+	return line + ", not verified: " + t.ClientVerifyError
+}
+
+// weighted formats a preference the way clients send it, e.g. "en;q=0.9",
+// leaving out the default quality of 1.
+func weighted(value string, q float64) string {
+	if q == 1 {
+		return value
+	}
+	return value + ";q=" + strconv.FormatFloat(q, 'g', -1, 64)
+}
+
+func orNotProvided(s string) string {
+	if s == "" {
+		return "(not provided)"
+	}
+	return s
+}
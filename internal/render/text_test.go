@@ -0,0 +1,259 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"connectionInfo/internal/geoip"
+	"connectionInfo/internal/parser"
+	"connectionInfo/internal/rdns"
+)
+
+func TestRenderText(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:     "203.0.113.50",
+		ClientIPInfo: parser.ClassifyIP("203.0.113.50"),
+		Location: &geoip.Location{
+			CountryCode: "DE",
+			Country:     "Germany",
+			City:        "Berlin",
+			TimeZone:    "Europe/Berlin",
+			ASN:         64500,
+		},
+		ReverseDNS: &rdns.Result{
+			Names: []rdns.Name{{Name: "host.example", Confirmed: true}, {Name: "alias.example"}},
+		},
+		RawRemoteAddr: "127.0.0.1:51234",
+		TLS: &TLSInfo{
+			Version:           "TLS 1.3",
+			CipherSuite:       "TLS_AES_128_GCM_SHA256",
+			ServerName:        "example.com",
+			ALPN:              "h2",
+			Resumed:           true,
+			Curve:             "X25519",
+			ClientCerts:       []CertInfo{{Subject: "CN=alice", Issuer: "CN=Example CA"}},
+			ClientVerifyError: "x509: certificate signed by unknown authority",
+			ClientHello: &ClientHelloInfo{
+				JA3Hash: "ada70206e40642a3e4461f35503241d5",
+				JA4:     "t13d1516h2_8daaf6152771_e5627efa2ab1",
+			},
+		},
+		HTTP2:       &HTTP2Info{Akamai: "1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p"},
+		Method:      "GET",
+		Protocol:    "HTTP/2.0",
+		Path:        "/",
+		QueryParams: map[string][]string{"b": {"2"}, "a": {"1"}},
+		Headers: []HeaderPair{
+			{Name: "Accept", Value: "*/*"},
+			{Name: "User-Agent", Value: "curl/8.4.0"},
+		},
+		UserAgent: parser.UserAgentInfo{
+			Raw:            "curl/8.4.0",
+			BrowserName:    "curl",
+			BrowserVersion: "8.4",
+			OSName:         "Unknown",
+			CLI:            true,
+			Parsed:         true,
+		},
+		Timestamp: time.Date(2024, 1, 15, 12, 30, 45, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+	if err := RenderText(&buf, info); err != nil {
+		t.Fatalf("RenderText() error = %v", err)
+	}
+
+	body := buf.String()
+	expectedLines := []string{
+		"IP:          203.0.113.50\n",
+		"IP Type:     IPv4, Documentation\n",
+		"Location:    Berlin, Germany (DE)\n",
+		"Time Zone:   Europe/Berlin\n",
+		"Network:     AS64500\n",
+		"Reverse DNS: host.example (confirmed), alias.example (unconfirmed)\n",
+		"Remote Addr: 127.0.0.1:51234\n",
+		"TLS:         TLS 1.3, TLS_AES_128_GCM_SHA256, X25519, ALPN h2, SNI example.com, resumed\n",
+		"Client Cert: CN=alice, issued by CN=Example CA, not verified: x509: certificate signed by unknown authority\n",
+		"JA3:         ada70206e40642a3e4461f35503241d5\n",
+		"JA4:         t13d1516h2_8daaf6152771_e5627efa2ab1\n",
+		"Protocol:    HTTP/2.0\n",
+		"HTTP/2:      1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p\n",
+		"Query:       a=1 b=2\n",
+		"Client:      curl 8.4\n",
+		"Timestamp:   2024-01-15T12:30:45Z\n",
+		"\nHeaders:\n  Accept: */*\n  User-Agent: curl/8.4.0\n",
+	}
+	for _, expected := range expectedLines {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", expected, body)
+		}
+	}
+
+	if strings.Contains(body, "<") {
+		t.Errorf("plain-text output should not contain markup, got:\n%s", body)
+	}
+}
+
+func TestRenderIP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderIP(&buf, ConnectionInfo{ClientIP: "2001:db8::1"}); err != nil {
+		t.Fatalf("RenderIP() error = %v", err)
+	}
+
+	if got := buf.String(); got != "2001:db8::1\n" {
+		t.Errorf("RenderIP() = %q, want %q", got, "2001:db8::1\n")
+	}
+}
+
+func TestRenderText_HeaderOrder(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP: "192.0.2.10",
+		Method:   "GET",
+		Headers:  []HeaderPair{{Name: "Accept", Value: "*/*"}, {Name: "X-Dup", Value: "a, b"}},
+		HeaderOrder: &HeaderOrderInfo{
+			Headers:     []HeaderPair{{Name: "host", Value: "example.com"}, {Name: "X-Dup", Value: "a"}, {Name: "ACCEPT", Value: "*/*"}, {Name: "X-Dup", Value: "b"}},
+			Fingerprint: "5e1f0d6c2a9b",
+		},
+		UserAgent: parser.UserAgentInfo{OSName: "Unknown"},
+		Redacted:  true,
+	}
+
+	var buf bytes.Buffer
+	if err := RenderText(&buf, info); err != nil {
+		t.Fatalf("RenderText() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{
+		"Header Hash: 5e1f0d6c2a9b\n",
+		"\nHeaders:\n  host: example.com\n  X-Dup: a\n  ACCEPT: */*\n  X-Dup: b\n  (sensitive values are masked)\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestRenderText_Negotiation(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP: "192.0.2.10",
+		Negotiation: NegotiationInfo{
+			Languages: parser.ParseAcceptLanguage("en-US, en;q=0.9, *;q=0.25"),
+			Encodings: parser.ParseAcceptEncoding("gzip;q=0.5, br"),
+		},
+		UserAgent: parser.UserAgentInfo{OSName: "Unknown"},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderText(&buf, info); err != nil {
+		t.Fatalf("RenderText() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{
+		"Languages:   en-US, en;q=0.9, *;q=0.25\n",
+		"Encodings:   br, gzip;q=0.5\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestRenderRaw(t *testing.T) {
+	info := ConnectionInfo{
+		RawRequest: &RawRequest{
+			RequestLine: "GET / HTTP/1.1",
+			Headers:     []HeaderPair{{Name: "host", Value: "example.com"}, {Name: "Accept", Value: "*/*"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderRaw(&buf, info); err != nil {
+		t.Fatalf("RenderRaw() error = %v", err)
+	}
+	if want := "GET / HTTP/1.1\nhost: example.com\nAccept: */*\n\n"; buf.String() != want {
+		t.Errorf("RenderRaw() = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	info.RawRequest.Body = "abc"
+	info.RawRequest.Truncated = true
+	if err := RenderRaw(&buf, info); err != nil {
+		t.Fatalf("RenderRaw() error = %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "\n\nabc\n[body truncated]\n") {
+		t.Errorf("RenderRaw() = %q, want the body and a truncation note", buf.String())
+	}
+}
+
+func TestRenderText_Cookies(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP: "192.0.2.10",
+		Method:   "GET",
+		Cookies: parser.CookieInfo{
+			Cookies:  []parser.Cookie{{Name: "sid", Value: "abc", Size: 6}, {Name: "sid", Value: "def", Size: 6}},
+			Size:     15,
+			Warnings: []string{`"sid" is sent 2 times, usually for different paths or domains`},
+		},
+		UserAgent: parser.UserAgentInfo{OSName: "Unknown"},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderText(&buf, info); err != nil {
+		t.Fatalf("RenderText() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{
+		"Cookies:     2, 15 bytes\n",
+		"\nCookie warnings:\n  \"sid\" is sent 2 times, usually for different paths or domains\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestRenderText_Bot(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want string
+	}{
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "Client:      Googlebot 2.1 (bot, verified)\n"},
+		{"python-requests/2.31.0", "Client:      python-requests 2.31.0 (library)\n"},
+		{"curl/8.4.0", "Client:      curl 8.4.0\n"},
+	}
+	for _, tt := range tests {
+		info := ConnectionInfo{ClientIP: "66.249.66.1", Method: "GET", UserAgent: parser.ParseUserAgent(tt.ua)}
+		if info.UserAgent.Bot != nil {
+			info.UserAgent.Bot.Verify([]string{"crawl-66-249-66-1.googlebot.com"})
+		}
+
+		var buf bytes.Buffer
+		if err := RenderText(&buf, info); err != nil {
+			t.Fatalf("RenderText() error = %v", err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", tt.want, buf.String())
+		}
+	}
+}
+
+func TestRenderText_Device(t *testing.T) {
+	info := ConnectionInfo{
+		ClientIP:  "192.0.2.10",
+		Method:    "GET",
+		UserAgent: parser.ParseUserAgent("Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1"),
+	}
+
+	var buf bytes.Buffer
+	if err := RenderText(&buf, info); err != nil {
+		t.Fatalf("RenderText() error = %v", err)
+	}
+	if want := "Device:      mobile, Apple iPhone\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("rendered output does not contain %q, got:\n%s", want, buf.String())
+	}
+}
@@ -0,0 +1,115 @@
+package render
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The YAML, XML and CSV renderers share the JSON schema of report. Rather
+// than keeping four sets of field names in sync, they walk an ordered tree
+// built from the report's json tags.
+
+// object is a tree node with fixed, schema-defined keys in struct order.
+type object []member
+
+// mapObject is a tree node whose keys come from request data (such as query
+// parameter names) and so may not be valid identifiers in every format.
+type mapObject []member
+
+// array is a tree node holding an ordered list of values.
+type array []any
+
+// member is one key/value pair of an object or mapObject.
+type member struct {
+	Key   string
+	Value any
+}
+
+// reportTree converts a ConnectionInfo into the ordered tree form of report.
+func reportTree(info ConnectionInfo) object {
+	return toTree(reflect.ValueOf(newReport(info))).(object)
+}
+
+// toTree converts a value into tree nodes. Leaves are string, bool, int64,
+// uint64, float64 or nil.
+func toTree(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return toTree(v.Elem())
+	case reflect.Struct:
+		var obj object
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if !sf.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			fv := v.Field(i)
+			if opts == "omitempty" && isEmptyValue(fv) {
+				continue
+			}
+			obj = append(obj, member{Key: name, Value: toTree(fv)})
+		}
+		return obj
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		obj := make(mapObject, 0, len(keys))
+		for _, k := range keys {
+			obj = append(obj, member{Key: k, Value: toTree(v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key())))})
+		}
+		return obj
+	case reflect.Slice, reflect.Array:
+		arr := make(array, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			arr = append(arr, toTree(v.Index(i)))
+		}
+		return arr
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	default:
+		return nil
+	}
+}
+
+// isEmptyValue mirrors encoding/json's definition of empty for omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// RenderXML writes the connection info as an XML document. Element names
+// follow the JSON field names; array entries are <item> elements, and
+// entries keyed by request data (such as query parameters) are <entry>
+// elements carrying the key in a "key" attribute.
+func RenderXML(w io.Writer, info ConnectionInfo) error {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	writeXMLElement(&buf, "connection_info", "", reportTree(info), 0)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeXMLElement(buf *bytes.Buffer, name, key string, v any, indent int) {
+	pad := strings.Repeat("  ", indent)
+	buf.WriteString(pad + "<" + name)
+	if key != "" {
+		buf.WriteString(` key="`)
+		xmlEscape(buf, key)
+		buf.WriteString(`"`)
+	}
+
+	switch v := v.(type) {
+	case object:
+		if len(v) == 0 {
+			buf.WriteString("/>\n")
+			return
+		}
+		buf.WriteString(">\n")
+		for _, m := range v {
+			writeXMLElement(buf, m.Key, "", m.Value, indent+1)
+		}
+	case mapObject:
+		if len(v) == 0 {
+			buf.WriteString("/>\n")
+			return
+		}
+		buf.WriteString(">\n")
+		for _, m := range v {
+			writeXMLElement(buf, "entry", m.Key, m.Value, indent+1)
+		}
+	case array:
+		if len(v) == 0 {
+			buf.WriteString("/>\n")
+			return
+		}
+		buf.WriteString(">\n")
+		for _, item := range v {
+			writeXMLElement(buf, "item", "", item, indent+1)
+		}
+	case nil:
+		buf.WriteString("/>\n")
+		return
+	default:
+		buf.WriteString(">")
+		xmlEscape(buf, scalarString(v))
+		buf.WriteString("</" + name + ">\n")
+		return
+	}
+
+	buf.WriteString(pad + "</" + name + ">\n")
+}
+
+func xmlEscape(buf *bytes.Buffer, s string) {
+	// xml.EscapeText only fails if the writer does; bytes.Buffer never does.
+	_ = xml.EscapeText(buf, []byte(s))
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRenderXML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderXML(&buf, testInfo()); err != nil {
+		t.Fatalf("RenderXML() error = %v", err)
+	}
+
+	body := buf.String()
+
+	// The document must be well-formed
+	dec := xml.NewDecoder(strings.NewReader(body))
+	for {
+		_, err := dec.Token()
+		if err != nil {
+			if err != io.EOF {
+				t.Fatalf("output is not well-formed XML: %v\n%s", err, body)
+			}
+			break
+		}
+	}
+
+	expectedContents := []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		"<connection_info>",
+		"<client_ip>203.0.113.50</client_ip>",
+		`<entry key="&lt;key&gt;">`,
+		"<item>a&amp;b</item>",
+		"<name>Accept</name>",
+		"<browser_name>Firefox</browser_name>",
+		"<timestamp>2024-01-15T12:30:45Z</timestamp>",
+	}
+	for _, expected := range expectedContents {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestRenderXML_EmptyCollections(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderXML(&buf, ConnectionInfo{}); err != nil {
+		t.Fatalf("RenderXML() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{"<query_params/>", "<headers/>"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", expected, body)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RenderYAML writes the connection info as a YAML document using the same
+// field names as the JSON output.
+func RenderYAML(w io.Writer, info ConnectionInfo) error {
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	writeYAMLObject(&buf, reportTree(info), 0)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeYAMLObject writes the members of an object or mapObject as block
+// mappings indented by indent spaces.
+func writeYAMLObject(buf *bytes.Buffer, members []member, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for _, m := range members {
+		buf.WriteString(pad + yamlString(m.Key) + ":")
+		writeYAMLValue(buf, m.Value, indent)
+	}
+}
+
+// writeYAMLValue writes the value that follows a "key:" or "-" marker.
+// Scalars and empty collections stay on the same line; non-empty
+// collections start on the next line, nested one level deeper.
+func writeYAMLValue(buf *bytes.Buffer, v any, indent int) {
+	switch v := v.(type) {
+	case object:
+		writeYAMLMapping(buf, v, indent)
+	case mapObject:
+		writeYAMLMapping(buf, v, indent)
+	case array:
+		if len(v) == 0 {
+			buf.WriteString(" []\n")
+			return
+		}
+		buf.WriteString("\n")
+		writeYAMLArray(buf, v, indent+2)
+	default:
+		buf.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
+
+func writeYAMLMapping(buf *bytes.Buffer, members []member, indent int) {
+	if len(members) == 0 {
+		buf.WriteString(" {}\n")
+		return
+	}
+	buf.WriteString("\n")
+	writeYAMLObject(buf, members, indent+2)
+}
+
+// writeYAMLArray writes a block sequence. Object items put their first
+// member on the "- " line, as is conventional.
+func writeYAMLArray(buf *bytes.Buffer, items array, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for _, item := range items {
+		var members []member
+		switch item := item.(type) {
+		case object:
+			members = item
+		case mapObject:
+			members = item
+		}
+		if len(members) == 0 {
+			buf.WriteString(pad + "-")
+			writeYAMLValue(buf, item, indent)
+			continue
+		}
+
+		var nested bytes.Buffer
+		writeYAMLObject(&nested, members, indent+2)
+		buf.WriteString(pad + "- ")
+		buf.Write(nested.Bytes()[indent+2:])
+	}
+}
+
+// yamlPlain matches strings that are safe to emit unquoted: they cannot be
+// mistaken for numbers, booleans, null or YAML syntax.
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_ ./+()-]*$`)
+
+// yamlReserved lists plain scalars that YAML 1.1 parsers read as non-strings.
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true,
+}
+
+func yamlScalar(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return yamlString(v)
+	default:
+		return scalarString(v)
+	}
+}
+
+// yamlString returns s as a plain scalar when that is unambiguous, and as a
+// double-quoted scalar otherwise. Go's escape sequences are a subset of
+// YAML's, so strconv.Quote produces valid YAML.
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !strings.HasSuffix(s, " ") && !yamlReserved[strings.ToLower(s)] {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// scalarString formats a non-nil tree leaf as text.
+func scalarString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return ""
+	}
+}
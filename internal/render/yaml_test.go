@@ -0,0 +1,76 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"connectionInfo/internal/parser"
+)
+
+func testInfo() ConnectionInfo {
+	return ConnectionInfo{
+		ClientIP:      "203.0.113.50",
+		ClientIPInfo:  parser.ClassifyIP("203.0.113.50"),
+		RawRemoteAddr: "127.0.0.1:51234",
+		Method:        "GET",
+		Protocol:      "HTTP/1.1",
+		Path:          "/",
+		QueryParams:   map[string][]string{"q": {"a&b"}, "<key>": {"true"}},
+		Headers: []HeaderPair{
+			{Name: "Accept", Value: "*/*"},
+			{Name: "User-Agent", Value: "Mozilla/5.0 (X11; Linux x86_64) Firefox/121.0"},
+		},
+		UserAgent: parser.UserAgentInfo{
+			Raw:            "Mozilla/5.0 (X11; Linux x86_64) Firefox/121.0",
+			BrowserName:    "Firefox",
+			BrowserVersion: "121.0",
+			OSName:         "Linux",
+			Parsed:         true,
+		},
+		Timestamp: time.Date(2024, 1, 15, 12, 30, 45, 0, time.UTC),
+	}
+}
+
+func TestRenderYAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderYAML(&buf, testInfo()); err != nil {
+		t.Fatalf("RenderYAML() error = %v", err)
+	}
+
+	body := buf.String()
+	if !strings.HasPrefix(body, "---\n") {
+		t.Errorf("YAML document should start with ---, got:\n%s", body)
+	}
+
+	expectedFragments := []string{
+		"\nclient_ip: \"203.0.113.50\"\nclient_ip_info:\n  version: 4\n",
+		"\n  documentation: true\n",
+		"\nremote_addr: \"127.0.0.1:51234\"\nmethod: GET\nprotocol: HTTP/1.1\npath: /\n",
+		"\nquery_params:\n  \"<key>\":\n    - \"true\"\n  q:\n    - \"a&b\"\n",
+		"\nheaders:\n  - name: Accept\n    value: \"*/*\"\n  - name: User-Agent\n    value: \"Mozilla/5.0 (X11; Linux x86_64) Firefox/121.0\"\n",
+		"\nuser_agent:\n  raw: \"Mozilla/5.0 (X11; Linux x86_64) Firefox/121.0\"\n  browser_name: Firefox\n  browser_version: \"121.0\"\n",
+		"\n  parsed: true\n",
+		"\ntimestamp: \"2024-01-15T12:30:45Z\"\n",
+	}
+	for _, expected := range expectedFragments {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestRenderYAML_EmptyCollections(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderYAML(&buf, ConnectionInfo{}); err != nil {
+		t.Fatalf("RenderYAML() error = %v", err)
+	}
+
+	body := buf.String()
+	for _, expected := range []string{"query_params: {}\n", "headers: []\n", `client_ip: ""`} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("rendered output does not contain %q, got:\n%s", expected, body)
+		}
+	}
+}
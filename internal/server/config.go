@@ -0,0 +1,201 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"connectionInfo/internal/parser"
+	"connectionInfo/internal/rdns"
+	"connectionInfo/internal/redact"
+)
+
+// Config holds the server settings, normally read from the environment.
+type Config struct {
+	Port           string                // PORT: TCP port to listen on
+	TrustedProxies parser.TrustedProxies // TRUSTED_PROXIES: CIDRs whose forwarding headers are trusted
+
+	ProxyProtocol        bool           // PROXY_PROTOCOL: accept PROXY protocol v1/v2 headers
+	ProxyProtocolAllowed []netip.Prefix // PROXY_PROTOCOL_ALLOWED: upstreams that must send a PROXY header
+
+	GeoIPDatabases []string // GEOIP_DATABASES: MMDB files used for location and ASN lookups
+	UserAgentRules string   // USER_AGENT_RULES: regexes.yaml file replacing the built-in user-agent rules
+
+	ReverseDNS         bool          // REVERSE_DNS: look up the PTR names of the client IP
+	ReverseDNSResolver string        // REVERSE_DNS_RESOLVER: name server "host:port"; empty uses the system's
+	ReverseDNSTimeout  time.Duration // REVERSE_DNS_TIMEOUT: bound on each lookup
+	VerifyBots         bool          // VERIFY_BOTS: check claimed search engine bots with reverse and forward DNS
+
+	TLSCert string // TLS_CERT: PEM certificate chain; serve HTTPS when set
+	TLSKey  string // TLS_KEY: PEM private key for TLS_CERT
+
+	TLSClientAuth tls.ClientAuthType // TLS_CLIENT_AUTH: none, request, verify-if-given or require
+	TLSClientCA   string             // TLS_CLIENT_CA: PEM bundle client certificates are verified against; empty uses the system roots
+
+	RedactHeaders  []string         // REDACT_HEADERS: headers whose values are masked
+	RedactPatterns []*regexp.Regexp // REDACT_PATTERNS: whitespace-separated regexps masked in any header value
+	RedactKeep     int              // REDACT_KEEP: leading characters kept of masked values
+	RedactReveal   bool             // REDACT_REVEAL: allow ?reveal=1 to show values unmasked
+}
+
+// clientAuthTypes maps TLS_CLIENT_AUTH values to their handshake policy.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":            tls.NoClientCert,
+	"request":         tls.RequestClientCert,
+	"verify-if-given": tls.VerifyClientCertIfGiven,
+	"require":         tls.RequireAndVerifyClientCert,
+}
+
+// LoadConfig builds a Config from environment variables, using getenv
+// (typically os.Getenv) to look them up. Unset variables take defaults.
+func LoadConfig(getenv func(string) string) (Config, error) {
+	cfg := Config{
+		Port:           getenv("PORT"),
+		TrustedProxies: parser.DefaultTrustedProxies,
+		RedactHeaders:  redact.DefaultHeaders,
+		RedactPatterns: redact.DefaultPatterns,
+		RedactKeep:     redact.DefaultKeep,
+	}
+	if cfg.Port == "" {
+		cfg.Port = "8080"
+	}
+
+	if v := getenv("TRUSTED_PROXIES"); v != "" {
+		trusted, err := parser.ParseTrustedProxies(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+		}
+		cfg.TrustedProxies = trusted
+	}
+
+	if v := getenv("PROXY_PROTOCOL"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("PROXY_PROTOCOL: %w", err)
+		}
+		cfg.ProxyProtocol = enabled
+	}
+
+	if cfg.ProxyProtocol {
+		allowed, err := parser.ParseTrustedProxies(getenv("PROXY_PROTOCOL_ALLOWED"))
+		if err != nil {
+			return Config{}, fmt.Errorf("PROXY_PROTOCOL_ALLOWED: %w", err)
+		}
+		if len(allowed) == 0 {
+			return Config{}, errors.New("PROXY_PROTOCOL_ALLOWED must list the upstreams allowed to send PROXY headers")
+		}
+		cfg.ProxyProtocolAllowed = allowed
+	}
+
+	for _, path := range strings.Split(getenv("GEOIP_DATABASES"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			cfg.GeoIPDatabases = append(cfg.GeoIPDatabases, path)
+		}
+	}
+
+	cfg.UserAgentRules = getenv("USER_AGENT_RULES")
+
+	if v := getenv("REVERSE_DNS"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("REVERSE_DNS: %w", err)
+		}
+		cfg.ReverseDNS = enabled
+	}
+
+	if v := getenv("REVERSE_DNS_RESOLVER"); v != "" {
+		// A bare address means the standard DNS port
+		if _, _, err := net.SplitHostPort(v); err != nil {
+			if _, err := netip.ParseAddr(strings.Trim(v, "[]")); err != nil {
+				return Config{}, fmt.Errorf("REVERSE_DNS_RESOLVER: %q is not an address or address:port", v)
+			}
+			v = net.JoinHostPort(strings.Trim(v, "[]"), "53")
+		}
+		cfg.ReverseDNSResolver = v
+	}
+
+	cfg.ReverseDNSTimeout = rdns.DefaultTimeout
+	if v := getenv("REVERSE_DNS_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("REVERSE_DNS_TIMEOUT: %w", err)
+		}
+		if timeout <= 0 {
+			return Config{}, errors.New("REVERSE_DNS_TIMEOUT must be positive")
+		}
+		cfg.ReverseDNSTimeout = timeout
+	}
+
+	if v := getenv("VERIFY_BOTS"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("VERIFY_BOTS: %w", err)
+		}
+		cfg.VerifyBots = enabled
+	}
+
+	cfg.TLSCert, cfg.TLSKey = getenv("TLS_CERT"), getenv("TLS_KEY")
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return Config{}, errors.New("TLS_CERT and TLS_KEY must be set together")
+	}
+
+	if v := getenv("TLS_CLIENT_AUTH"); v != "" {
+		auth, ok := clientAuthTypes[strings.ToLower(v)]
+		if !ok {
+			return Config{}, fmt.Errorf("TLS_CLIENT_AUTH: %q is not one of none, request, verify-if-given, require", v)
+		}
+		cfg.TLSClientAuth = auth
+	}
+	cfg.TLSClientCA = getenv("TLS_CLIENT_CA")
+	if (cfg.TLSClientAuth != tls.NoClientCert || cfg.TLSClientCA != "") && cfg.TLSCert == "" {
+		return Config{}, errors.New("TLS_CLIENT_AUTH and TLS_CLIENT_CA require TLS_CERT")
+	}
+
+	// Both lists replace the defaults; "none" empties them
+	if v := getenv("REDACT_HEADERS"); v != "" {
+		cfg.RedactHeaders = []string{}
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" && name != "none" {
+				cfg.RedactHeaders = append(cfg.RedactHeaders, name)
+			}
+		}
+	}
+	if v := getenv("REDACT_PATTERNS"); v != "" {
+		cfg.RedactPatterns = []*regexp.Regexp{}
+		for _, expr := range strings.Fields(v) {
+			if expr == "none" {
+				continue
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return Config{}, fmt.Errorf("REDACT_PATTERNS: %w", err)
+			}
+			cfg.RedactPatterns = append(cfg.RedactPatterns, re)
+		}
+	}
+	if v := getenv("REDACT_KEEP"); v != "" {
+		keep, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("REDACT_KEEP: %w", err)
+		}
+		if keep < 0 {
+			return Config{}, errors.New("REDACT_KEEP must not be negative")
+		}
+		cfg.RedactKeep = keep
+	}
+	if v := getenv("REDACT_REVEAL"); v != "" {
+		allowed, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("REDACT_REVEAL: %w", err)
+		}
+		cfg.RedactReveal = allowed
+	}
+
+	return cfg, nil
+}
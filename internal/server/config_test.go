@@ -0,0 +1,254 @@
+package server
+
+import (
+	"crypto/tls"
+	"slices"
+	"testing"
+	"time"
+
+	"connectionInfo/internal/parser"
+	"connectionInfo/internal/redact"
+)
+
+func envFunc(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, err := LoadConfig(envFunc(nil))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.Port != "8080" {
+		t.Errorf("Port = %q, want %q", cfg.Port, "8080")
+	}
+	if len(cfg.TrustedProxies) != len(parser.DefaultTrustedProxies) {
+		t.Errorf("TrustedProxies = %v, want %v", cfg.TrustedProxies, parser.DefaultTrustedProxies)
+	}
+}
+
+func TestLoadConfig_TrustedProxies(t *testing.T) {
+	cfg, err := LoadConfig(envFunc(map[string]string{
+		"PORT":            "9090",
+		"TRUSTED_PROXIES": "10.0.0.0/8,192.0.2.1",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.Port != "9090" {
+		t.Errorf("Port = %q, want %q", cfg.Port, "9090")
+	}
+	if len(cfg.TrustedProxies) != 2 || cfg.TrustedProxies[1].String() != "192.0.2.1/32" {
+		t.Errorf("TrustedProxies = %v, want [10.0.0.0/8 192.0.2.1/32]", cfg.TrustedProxies)
+	}
+}
+
+func TestLoadConfig_InvalidTrustedProxies(t *testing.T) {
+	if _, err := LoadConfig(envFunc(map[string]string{"TRUSTED_PROXIES": "not-a-cidr"})); err == nil {
+		t.Error("LoadConfig() should fail for an invalid TRUSTED_PROXIES value")
+	}
+}
+
+func TestLoadConfig_ProxyProtocol(t *testing.T) {
+	cfg, err := LoadConfig(envFunc(map[string]string{
+		"PROXY_PROTOCOL":         "true",
+		"PROXY_PROTOCOL_ALLOWED": "10.0.0.0/8, 192.0.2.1",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if !cfg.ProxyProtocol {
+		t.Errorf("ProxyProtocol = false, want true")
+	}
+	if len(cfg.ProxyProtocolAllowed) != 2 || cfg.ProxyProtocolAllowed[0].String() != "10.0.0.0/8" {
+		t.Errorf("ProxyProtocolAllowed = %v, want [10.0.0.0/8 192.0.2.1/32]", cfg.ProxyProtocolAllowed)
+	}
+}
+
+func TestLoadConfig_ProxyProtocolRequiresAllowlist(t *testing.T) {
+	for _, allowed := range []string{"", "none"} {
+		_, err := LoadConfig(envFunc(map[string]string{
+			"PROXY_PROTOCOL":         "true",
+			"PROXY_PROTOCOL_ALLOWED": allowed,
+		}))
+		if err == nil {
+			t.Errorf("LoadConfig() should fail when PROXY_PROTOCOL_ALLOWED = %q", allowed)
+		}
+	}
+
+	if _, err := LoadConfig(envFunc(map[string]string{"PROXY_PROTOCOL": "maybe"})); err == nil {
+		t.Error("LoadConfig() should fail for an invalid PROXY_PROTOCOL value")
+	}
+}
+
+func TestLoadConfig_GeoIPDatabases(t *testing.T) {
+	cfg, err := LoadConfig(envFunc(map[string]string{
+		"GEOIP_DATABASES": "/var/lib/GeoIP/GeoLite2-City.mmdb, /var/lib/GeoIP/GeoLite2-ASN.mmdb,",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	want := []string{"/var/lib/GeoIP/GeoLite2-City.mmdb", "/var/lib/GeoIP/GeoLite2-ASN.mmdb"}
+	if len(cfg.GeoIPDatabases) != 2 || cfg.GeoIPDatabases[0] != want[0] || cfg.GeoIPDatabases[1] != want[1] {
+		t.Errorf("GeoIPDatabases = %v, want %v", cfg.GeoIPDatabases, want)
+	}
+}
+
+func TestLoadConfig_ReverseDNS(t *testing.T) {
+	cfg, err := LoadConfig(envFunc(nil))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.ReverseDNS || cfg.ReverseDNSResolver != "" || cfg.ReverseDNSTimeout != time.Second {
+		t.Errorf("defaults = %v, %q, %v; want disabled, system resolver, 1s", cfg.ReverseDNS, cfg.ReverseDNSResolver, cfg.ReverseDNSTimeout)
+	}
+
+	tests := []struct {
+		resolver string
+		want     string
+	}{
+		{"192.0.2.53", "192.0.2.53:53"},
+		{"192.0.2.53:5353", "192.0.2.53:5353"},
+		{"2001:db8::53", "[2001:db8::53]:53"},
+		{"[2001:db8::53]:5353", "[2001:db8::53]:5353"},
+	}
+	for _, tt := range tests {
+		cfg, err := LoadConfig(envFunc(map[string]string{
+			"REVERSE_DNS":          "true",
+			"REVERSE_DNS_RESOLVER": tt.resolver,
+			"REVERSE_DNS_TIMEOUT":  "250ms",
+		}))
+		if err != nil {
+			t.Fatalf("LoadConfig(%q) error = %v", tt.resolver, err)
+		}
+		if !cfg.ReverseDNS || cfg.ReverseDNSResolver != tt.want || cfg.ReverseDNSTimeout != 250*time.Millisecond {
+			t.Errorf("LoadConfig(%q) = %v, %q, %v; want enabled, %q, 250ms", tt.resolver, cfg.ReverseDNS, cfg.ReverseDNSResolver, cfg.ReverseDNSTimeout, tt.want)
+		}
+	}
+
+	cfg, err = LoadConfig(envFunc(map[string]string{"VERIFY_BOTS": "true"}))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.VerifyBots || cfg.ReverseDNS {
+		t.Errorf("VerifyBots, ReverseDNS = %v, %v; want true, false", cfg.VerifyBots, cfg.ReverseDNS)
+	}
+
+	for _, env := range []map[string]string{
+		{"REVERSE_DNS": "sometimes"},
+		{"REVERSE_DNS_RESOLVER": "dns.example"},
+		{"REVERSE_DNS_TIMEOUT": "soon"},
+		{"REVERSE_DNS_TIMEOUT": "0s"},
+		{"VERIFY_BOTS": "maybe"},
+	} {
+		if _, err := LoadConfig(envFunc(env)); err == nil {
+			t.Errorf("LoadConfig(%v) should fail", env)
+		}
+	}
+}
+
+func TestLoadConfig_TLS(t *testing.T) {
+	cfg, err := LoadConfig(envFunc(map[string]string{"TLS_CERT": "/run/cert.pem", "TLS_KEY": "/run/key.pem"}))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.TLSCert != "/run/cert.pem" || cfg.TLSKey != "/run/key.pem" {
+		t.Errorf("TLSCert, TLSKey = %q, %q", cfg.TLSCert, cfg.TLSKey)
+	}
+
+	if _, err := LoadConfig(envFunc(map[string]string{"TLS_CERT": "/run/cert.pem"})); err == nil {
+		t.Error("LoadConfig() should fail when TLS_KEY is missing")
+	}
+}
+
+func TestLoadConfig_TLSClientAuth(t *testing.T) {
+	env := map[string]string{
+		"TLS_CERT":        "/run/cert.pem",
+		"TLS_KEY":         "/run/key.pem",
+		"TLS_CLIENT_AUTH": "verify-if-given",
+		"TLS_CLIENT_CA":   "/run/clients.pem",
+	}
+	cfg, err := LoadConfig(envFunc(env))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.TLSClientAuth != tls.VerifyClientCertIfGiven || cfg.TLSClientCA != "/run/clients.pem" {
+		t.Errorf("TLSClientAuth, TLSClientCA = %v, %q", cfg.TLSClientAuth, cfg.TLSClientCA)
+	}
+
+	cfg, err = LoadConfig(envFunc(map[string]string{}))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.TLSClientAuth != tls.NoClientCert {
+		t.Errorf("default TLSClientAuth = %v, want NoClientCert", cfg.TLSClientAuth)
+	}
+
+	invalid := []map[string]string{
+		{"TLS_CERT": "/run/cert.pem", "TLS_KEY": "/run/key.pem", "TLS_CLIENT_AUTH": "optional"},
+		{"TLS_CLIENT_AUTH": "request"},
+		{"TLS_CLIENT_CA": "/run/clients.pem"},
+	}
+	for _, env := range invalid {
+		if _, err := LoadConfig(envFunc(env)); err == nil {
+			t.Errorf("LoadConfig(%v) should fail", env)
+		}
+	}
+}
+
+func TestLoadConfig_Redaction(t *testing.T) {
+	cfg, err := LoadConfig(envFunc(nil))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.RedactHeaders) != len(redact.DefaultHeaders) || len(cfg.RedactPatterns) != len(redact.DefaultPatterns) ||
+		cfg.RedactKeep != redact.DefaultKeep || cfg.RedactReveal {
+		t.Errorf("defaults = %v, %v, %d, %v", cfg.RedactHeaders, cfg.RedactPatterns, cfg.RedactKeep, cfg.RedactReveal)
+	}
+
+	env := map[string]string{
+		"REDACT_HEADERS":  "Authorization, X-Secret",
+		"REDACT_PATTERNS": `key=(\w+) \bsk_live_\w+`,
+		"REDACT_KEEP":     "0",
+		"REDACT_REVEAL":   "true",
+	}
+	cfg, err = LoadConfig(envFunc(env))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !slices.Equal(cfg.RedactHeaders, []string{"Authorization", "X-Secret"}) {
+		t.Errorf("RedactHeaders = %v", cfg.RedactHeaders)
+	}
+	if len(cfg.RedactPatterns) != 2 || cfg.RedactPatterns[1].String() != `\bsk_live_\w+` {
+		t.Errorf("RedactPatterns = %v", cfg.RedactPatterns)
+	}
+	if cfg.RedactKeep != 0 || !cfg.RedactReveal {
+		t.Errorf("RedactKeep, RedactReveal = %d, %v", cfg.RedactKeep, cfg.RedactReveal)
+	}
+
+	cfg, err = LoadConfig(envFunc(map[string]string{"REDACT_HEADERS": "none", "REDACT_PATTERNS": "none"}))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.RedactHeaders) != 0 || len(cfg.RedactPatterns) != 0 {
+		t.Errorf("none = %v, %v; want empty lists", cfg.RedactHeaders, cfg.RedactPatterns)
+	}
+
+	invalid := []map[string]string{
+		{"REDACT_PATTERNS": "a("},
+		{"REDACT_KEEP": "-1"},
+		{"REDACT_KEEP": "four"},
+		{"REDACT_REVEAL": "sometimes"},
+	}
+	for _, env := range invalid {
+		if _, err := LoadConfig(envFunc(env)); err == nil {
+			t.Errorf("LoadConfig(%v) should fail", env)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"connectionInfo/internal/wire"
+)
+
+// h2cTimeout bounds how long an upgraded client may take to send the HTTP/2
+// preface and its SETTINGS frame.
+const h2cTimeout = 10 * time.Second
+
+// maxFrameSize is the largest frame a peer must accept before SETTINGS say
+// otherwise.
+const maxFrameSize = 16384
+
+const http2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// Headers that are specific to the HTTP/1.1 connection and must not be
+// carried over to HTTP/2 (RFC 9113, section 8.2.2).
+var hopByHopHeaders = []string{"Connection", "Http2-Settings", "Keep-Alive", "Proxy-Connection", "Te", "Transfer-Encoding", "Upgrade"}
+
+// h2cUpgrade switches cleartext HTTP/1.1 requests that ask for it with
+// "Upgrade: h2c" to HTTP/2 (RFC 7540, section 3.2), which net/http does not
+// do itself. The connection is hijacked and passed on through conns, with
+// the request that asked for the upgrade replayed as stream 1. Requests with
+// a body are served over HTTP/1.1 instead, as the RFC allows.
+func h2cUpgrade(next http.Handler, conns chan<- net.Conn) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !wantsH2C(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		settings, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(r.Header.Get("Http2-Settings"), "="))
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		headers, ok := headersFrame(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		upgraded, err := switchToHTTP2(conn, brw, settings, headers)
+		if err != nil {
+			log.Printf("h2c upgrade from %s failed: %v", conn.RemoteAddr(), err)
+			conn.Close()
+			return
+		}
+		conns <- upgraded
+	})
+}
+
+// wantsH2C reports whether r is a cleartext HTTP/1.1 request without a body
+// that asks to be upgraded to h2c.
+func wantsH2C(r *http.Request) bool {
+	return r.ProtoMajor == 1 && r.TLS == nil &&
+		hasToken(r.Header["Upgrade"], "h2c") &&
+		hasToken(r.Header["Connection"], "upgrade") &&
+		len(r.Header["Http2-Settings"]) == 1 &&
+		r.ContentLength == 0 && len(r.TransferEncoding) == 0
+}
+
+// hasToken reports whether the comma-separated header values contain token,
+// ignoring case.
+func hasToken(values []string, token string) bool {
+	for _, v := range values {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// switchToHTTP2 confirms the upgrade, reads the client's preface and first
+// SETTINGS frame, and returns a connection on which the server sees the
+// preface, the SETTINGS frame and then the upgraded request as a HEADERS
+// frame, followed by whatever the client sends next. Recording resumes with
+// the settings of the HTTP2-Settings header, as the HEADERS frame is the
+// server's own.
+func switchToHTTP2(conn net.Conn, brw *bufio.ReadWriter, settings, headers []byte) (net.Conn, error) {
+	if _, err := brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n"); err != nil {
+		return nil, err
+	}
+	if err := brw.Flush(); err != nil {
+		return nil, err
+	}
+	if wc, ok := conn.(*wire.Conn); ok {
+		wc.ExpectHTTP2(settings)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(h2cTimeout)); err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, len(http2Preface)+9)
+	if _, err := io.ReadFull(brw, prefix); err != nil {
+		return nil, err
+	}
+	if string(prefix[:len(http2Preface)]) != http2Preface {
+		return nil, errors.New("missing HTTP/2 preface")
+	}
+	frame := prefix[len(http2Preface):]
+	length := int(frame[0])<<16 | int(frame[1])<<8 | int(frame[2])
+	if frame[3] != 0x4 || length > maxFrameSize {
+		return nil, errors.New("preface not followed by SETTINGS")
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(brw, payload); err != nil {
+		return nil, err
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	prefix = append(prefix, payload...)
+	prefix = append(prefix, headers...)
+	return &upgradedConn{Conn: conn, reader: io.MultiReader(bytes.NewReader(prefix), brw)}, nil
+}
+
+// headersFrame encodes r as the HEADERS frame of stream 1, ending the
+// stream. It reports false if the headers do not fit in one frame.
+func headersFrame(r *http.Request) ([]byte, bool) {
+	var block []byte
+	field := func(name, value string) {
+		// Literal field without indexing, with a literal name (RFC 7541, section 6.2.2)
+		block = append(block, 0)
+		block = appendHPACKString(block, name)
+		block = appendHPACKString(block, value)
+	}
+	field(":method", r.Method)
+	field(":scheme", "http")
+	field(":authority", r.Host)
+	field(":path", r.RequestURI)
+
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		if !slices.Contains(hopByHopHeaders, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, value := range r.Header[name] {
+			field(strings.ToLower(name), value)
+		}
+	}
+
+	if len(block) > maxFrameSize {
+		return nil, false
+	}
+	const endStream, endHeaders = 0x1, 0x4
+	frame := []byte{byte(len(block) >> 16), byte(len(block) >> 8), byte(len(block)), 0x1, endStream | endHeaders}
+	frame = binary.BigEndian.AppendUint32(frame, 1)
+	return append(frame, block...), true
+}
+
+// appendHPACKString appends s as a string literal without Huffman coding.
+func appendHPACKString(b []byte, s string) []byte {
+	// Length as an integer with a 7-bit prefix (RFC 7541, section 5.1)
+	n := uint64(len(s))
+	if n < 127 {
+		b = append(b, byte(n))
+	} else {
+		b = append(b, 127)
+		for n -= 127; n >= 128; n >>= 7 {
+			b = append(b, byte(n&0x7f)|0x80)
+		}
+		b = append(b, byte(n))
+	}
+	return append(b, s...)
+}
+
+// upgradedConn is a hijacked connection that continues as HTTP/2.
+type upgradedConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+// Read reads the replayed prefix, then from the client.
+func (c *upgradedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// NetConn returns the hijacked connection.
+func (c *upgradedConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// connListener is a net.Listener for connections the server hands to
+// itself, such as those upgraded to h2c.
+type connListener struct {
+	conns chan net.Conn
+	addr  net.Addr
+}
+
+// Accept waits for the next connection.
+func (l *connListener) Accept() (net.Conn, error) {
+	return <-l.conns, nil
+}
+
+// Close does nothing; the listener lives as long as the server.
+func (l *connListener) Close() error {
+	return nil
+}
+
+// Addr returns the address of the listener the connections came from.
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
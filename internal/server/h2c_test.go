@@ -0,0 +1,237 @@
+package server
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"connectionInfo/internal/handler"
+	"connectionInfo/internal/wire"
+)
+
+// observed is what the handler saw of a request.
+type observed struct {
+	proto  string
+	path   string
+	tls    bool
+	akamai string
+}
+
+// startServer serves on a local listener, wrapped by wrap if not nil, and
+// returns its address and the requests the handler sees.
+func startServer(t *testing.T, wrap func(net.Listener) net.Listener) (string, <-chan observed) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	requests := make(chan observed, 1)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o := observed{proto: r.Proto, path: r.URL.Path, tls: r.TLS != nil}
+		if h2 := wire.FromContext(r.Context()).HTTP2(); h2 != nil {
+			o.akamai = h2.Akamai()
+		}
+		requests <- o
+		io.WriteString(w, "ok")
+	})
+
+	addr := ln.Addr().String()
+	if wrap != nil {
+		ln = wrap(ln)
+	}
+	go serve(ln, h)
+	return addr, requests
+}
+
+func TestServe_PriorKnowledge(t *testing.T) {
+	addr, requests := startServer(t, nil)
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+	resp, err := client.Get("http://" + addr + "/h2c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	got := <-requests
+	if got.proto != "HTTP/2.0" || got.path != "/h2c" || got.tls {
+		t.Errorf("request = %+v, want cleartext HTTP/2.0 for /h2c", got)
+	}
+	if !strings.HasSuffix(got.akamai, "|a,m,p,s") {
+		t.Errorf("Akamai() = %q, want Go's pseudo-header order", got.akamai)
+	}
+}
+
+func TestServe_Upgrade(t *testing.T) {
+	addr, requests := startServer(t, nil)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	upgrade(t, conn, addr, "/upgraded")
+
+	got := <-requests
+	if got.proto != "HTTP/2.0" || got.path != "/upgraded" {
+		t.Errorf("request = %+v, want the upgraded request served over HTTP/2.0", got)
+	}
+	if want := "3:100;4:65535|00|0|"; got.akamai != want {
+		t.Errorf("Akamai() = %q, want %q: the HTTP2-Settings and no pseudo-header order", got.akamai, want)
+	}
+}
+
+func TestServe_UpgradeReport(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go serve(ln, handler.New())
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	br := upgrade(t, conn, ln.Addr().String(), "/json")
+
+	// Collect the DATA frames of stream 1, ignoring the server's SETTINGS
+	// and the HEADERS, whose HPACK block is not needed
+	var body []byte
+	for {
+		var header [9]byte
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			t.Fatalf("reading frame: %v", err)
+		}
+		payload := make([]byte, int(header[0])<<16|int(header[1])<<8|int(header[2]))
+		if _, err := io.ReadFull(br, payload); err != nil {
+			t.Fatalf("reading frame: %v", err)
+		}
+		const frameData, endStream = 0x0, 0x1
+		if header[3] == frameData && binary.BigEndian.Uint32(header[5:]) == 1 {
+			body = append(body, payload...)
+			if header[4]&endStream != 0 {
+				break
+			}
+		}
+	}
+
+	var got struct {
+		Protocol string `json:"protocol"`
+		HTTP2    *struct {
+			Akamai   string `json:"akamai"`
+			Settings []struct {
+				Name  string `json:"name"`
+				Value uint32 `json:"value"`
+			} `json:"settings"`
+			PseudoHeaderOrder []string `json:"pseudo_header_order"`
+			H2CUpgrade        bool     `json:"h2c_upgrade"`
+		} `json:"http2"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("response is not valid JSON: %v\n%s", err, body)
+	}
+	h2 := got.HTTP2
+	if got.Protocol != "HTTP/2.0" || h2 == nil {
+		t.Fatalf("protocol, http2 = %q, %v; want an HTTP/2 report", got.Protocol, h2)
+	}
+	if h2.Akamai != "3:100;4:65535|00|0|" || !h2.H2CUpgrade || h2.PseudoHeaderOrder != nil {
+		t.Errorf("http2 = %+v, want the HTTP2-Settings, an h2c upgrade and no pseudo-header order", h2)
+	}
+	if len(h2.Settings) != 2 || h2.Settings[0].Name != "MAX_CONCURRENT_STREAMS" || h2.Settings[1].Value != 65535 {
+		t.Errorf("settings = %+v, want those of the HTTP2-Settings header", h2.Settings)
+	}
+}
+
+// upgrade sends a GET request for path that asks for h2c, with settings
+// in HTTP2-Settings that differ from those of the SETTINGS frame sent after
+// the upgrade, and returns the reader for the server's HTTP/2 frames.
+func upgrade(t *testing.T, conn net.Conn, host, path string) *bufio.Reader {
+	t.Helper()
+
+	// MAX_CONCURRENT_STREAMS 100, INITIAL_WINDOW_SIZE 65535
+	settings := base64.RawURLEncoding.EncodeToString([]byte{0, 3, 0, 0, 0, 100, 0, 4, 0, 0, 0xff, 0xff})
+	io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: "+host+"\r\n"+
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: "+settings+"\r\n\r\n")
+	br := bufio.NewReader(conn)
+	status, err := br.ReadString('\n')
+	if err != nil || !strings.HasPrefix(status, "HTTP/1.1 101 ") {
+		t.Fatalf("status line = %q, %v; want 101 Switching Protocols", status, err)
+	}
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line == "\r\n" {
+			break
+		}
+	}
+
+	// Preface and a SETTINGS frame with ENABLE_PUSH 0
+	io.WriteString(conn, http2Preface+"\x00\x00\x06\x04\x00\x00\x00\x00\x00\x00\x02\x00\x00\x00\x00")
+	return br
+}
+
+func TestServe_UpgradeWithBody(t *testing.T) {
+	addr, requests := startServer(t, nil)
+
+	req, _ := http.NewRequest("POST", "http://"+addr+"/", strings.NewReader("body"))
+	req.Header.Set("Connection", "Upgrade, HTTP2-Settings")
+	req.Header.Set("Upgrade", "h2c")
+	req.Header.Set("HTTP2-Settings", "")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := <-requests; got.proto != "HTTP/1.1" || resp.StatusCode != http.StatusOK {
+		t.Errorf("request = %+v, status %d; want it served over HTTP/1.1", got, resp.StatusCode)
+	}
+}
+
+func TestServe_TLS(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "localhost")
+	certs, err := newCertLoader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, requests := startServer(t, func(ln net.Listener) net.Listener {
+		return &tlsListener{Listener: ln, config: certs.tlsConfig(tls.NoClientCert, nil)}
+	})
+
+	for _, proto := range []string{"HTTP/2.0", "HTTP/1.1"} {
+		transport := &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: proto == "HTTP/2.0",
+		}
+		resp, err := (&http.Client{Transport: transport}).Get("https://" + addr + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		got := <-requests
+		if got.proto != proto || !got.tls {
+			t.Errorf("request = %+v, want %s over TLS", got, proto)
+		}
+		if (got.akamai != "") != (proto == "HTTP/2.0") {
+			t.Errorf("%s: Akamai() = %q", proto, got.akamai)
+		}
+	}
+}
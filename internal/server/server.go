@@ -1,16 +1,171 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"connectionInfo/internal/clienthello"
+	"connectionInfo/internal/geoip"
 	"connectionInfo/internal/handler"
+	"connectionInfo/internal/parser"
+	"connectionInfo/internal/proxyproto"
+	"connectionInfo/internal/rdns"
+	"connectionInfo/internal/redact"
+	"connectionInfo/internal/wire"
 )
 
-// Run starts the HTTP server on the specified port.
-func Run(port string) error {
-	h := handler.New()
+// Timeouts that keep clients which open connections and send nothing, or
+// send slowly, from holding them open.
+const (
+	readHeaderTimeout = 10 * time.Second // Includes the TLS handshake
+	idleTimeout       = 2 * time.Minute  // Between requests on a connection
+)
+
+// Run starts the HTTP server with the given configuration.
+func Run(cfg Config) error {
+	// SIGHUP is handled from the start, as it would otherwise terminate the
+	// process; the reloads it triggers are collected along the way
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	var onHangup []func()
+
+	opts := []handler.Option{
+		handler.WithTrustedProxies(cfg.TrustedProxies),
+		handler.WithRedaction(redact.Policy{
+			Headers:     cfg.RedactHeaders,
+			Patterns:    cfg.RedactPatterns,
+			Keep:        cfg.RedactKeep,
+			AllowReveal: cfg.RedactReveal,
+		}),
+	}
+	if len(cfg.GeoIPDatabases) > 0 {
+		dbs, err := geoip.OpenAll(cfg.GeoIPDatabases)
+		if err != nil {
+			return fmt.Errorf("GeoIP: %w", err)
+		}
+		go dbs.Watch(geoip.DefaultReloadInterval, nil)
+		onHangup = append(onHangup, dbs.Check)
+		opts = append(opts, handler.WithGeoIP(dbs))
+	}
+	if cfg.UserAgentRules != "" {
+		rules, err := parser.OpenRules(cfg.UserAgentRules)
+		if err != nil {
+			return fmt.Errorf("user-agent rules: %w", err)
+		}
+		go rules.Watch(parser.DefaultRulesReloadInterval, nil)
+		onHangup = append(onHangup, rules.Check)
+		opts = append(opts, handler.WithUserAgentRules(rules))
+	}
+	if cfg.ReverseDNS || cfg.VerifyBots {
+		// Both share one resolver, and so its cache
+		resolver := rdns.New(cfg.ReverseDNSResolver, cfg.ReverseDNSTimeout)
+		if cfg.ReverseDNS {
+			opts = append(opts, handler.WithReverseDNS(resolver))
+		}
+		if cfg.VerifyBots {
+			opts = append(opts, handler.WithBotVerification(resolver))
+		}
+	}
+
+	var clientCAs *x509.CertPool
+	if cfg.TLSClientCA != "" {
+		pool, err := loadCertPool(cfg.TLSClientCA)
+		if err != nil {
+			return fmt.Errorf("TLS client CA: %w", err)
+		}
+		clientCAs = pool
+		opts = append(opts, handler.WithClientCAs(pool))
+	}
+	h := handler.New(opts...)
+
+	addr := fmt.Sprintf(":%s", cfg.Port)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	if cfg.ProxyProtocol {
+		ln = &proxyproto.Listener{Listener: ln, Allowed: cfg.ProxyProtocolAllowed}
+	}
+
+	if cfg.TLSCert != "" {
+		certs, err := newCertLoader(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return fmt.Errorf("TLS: %w", err)
+		}
+		go certs.watch(certReloadInterval)
+		onHangup = append(onHangup, func() { certs.check(true) })
+		ln = &tlsListener{Listener: &clienthello.Listener{Listener: ln}, config: certs.tlsConfig(cfg.TLSClientAuth, clientCAs)}
+	}
+	go reloadOnHangup(hup, onHangup)
+	return serve(ln, h)
+}
+
+// reloadOnHangup runs each reload function whenever hup receives a signal,
+// as on systemctl reload. It runs until the process exits.
+func reloadOnHangup(hup <-chan os.Signal, reloads []func()) {
+	for range hup {
+		for _, reload := range reloads {
+			reload()
+		}
+	}
+}
+
+// serve serves h on ln, recording each connection. Every connection looks
+// like cleartext to net/http, which therefore serves HTTP/2 over TLS, and
+// h2c with prior knowledge, as unencrypted HTTP/2. Connections upgraded to
+// h2c are served on a second listener.
+func serve(ln net.Listener, h http.Handler) error {
+	ln = &wire.Listener{Listener: ln}
+
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	upgrades := &connListener{conns: make(chan net.Conn), addr: ln.Addr()}
+
+	srv := &http.Server{
+		Handler:           withTLSState(h2cUpgrade(h, upgrades.conns)),
+		ConnContext:       connContext,
+		Protocols:         &protocols,
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	go srv.Serve(upgrades)
+	return srv.Serve(ln)
+}
+
+// connContext exposes the connection wrappers a request arrived through to
+// the handler, by walking from the outermost net.Conn inwards.
+func connContext(ctx context.Context, c net.Conn) context.Context {
+	for c != nil {
+		switch conn := c.(type) {
+		case *proxyproto.Conn:
+			ctx = proxyproto.NewContext(ctx, conn)
+		case *clienthello.Conn:
+			ctx = clienthello.NewContext(ctx, conn)
+		case *tls.Conn:
+			ctx = context.WithValue(ctx, tlsConnKey{}, conn)
+		case *wire.Conn:
+			ctx = wire.NewContext(ctx, conn)
+		}
+		c = unwrap(c)
+	}
+	return ctx
+}
 
-	addr := fmt.Sprintf(":%s", port)
-	return http.ListenAndServe(addr, h)
+// unwrap returns the connection c wraps, or nil. Wrappers follow
+// crypto/tls.Conn in exposing it through a NetConn method.
+func unwrap(c net.Conn) net.Conn {
+	if w, ok := c.(interface{ NetConn() net.Conn }); ok {
+		return w.NetConn()
+	}
+	return nil
 }
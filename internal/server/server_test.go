@@ -0,0 +1,31 @@
+package server
+
+import (
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReloadOnHangup(t *testing.T) {
+	hup := make(chan os.Signal)
+	var first, second atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		reloadOnHangup(hup, []func(){func() { first.Add(1) }, func() { second.Add(1) }})
+		close(done)
+	}()
+
+	hup <- syscall.SIGHUP
+	hup <- syscall.SIGHUP
+	close(hup)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reloadOnHangup() did not return after the channel closed")
+	}
+	if first.Load() != 2 || second.Load() != 2 {
+		t.Errorf("reloads ran %d and %d times, want 2 each", first.Load(), second.Load())
+	}
+}
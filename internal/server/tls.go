@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// certReloadInterval is how often the certificate files are checked for
+// changes.
+const certReloadInterval = time.Minute
+
+// tlsHandshakeTimeout bounds how long a client may take to complete the TLS
+// handshake.
+const tlsHandshakeTimeout = 10 * time.Second
+
+// certLoader serves a certificate loaded from disk and reloads it when
+// either file changes, or on SIGHUP (see reloadOnHangup).
+type certLoader struct {
+	certFile, keyFile string
+
+	cert atomic.Pointer[tls.Certificate]
+
+	mu      sync.Mutex // Serializes reloads
+	modTime [2]time.Time
+}
+
+// newCertLoader loads the certificate and key from the given PEM files.
+func newCertLoader(certFile, keyFile string) (*certLoader, error) {
+	c := &certLoader{certFile: certFile, keyFile: keyFile}
+	if _, err := c.reload(true); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// reload loads the files again if force is set or either modification time
+// changed, and reports whether it did. If the files cannot be loaded, for
+// example because only one of them was replaced so far, the previous
+// certificate stays in use.
+func (c *certLoader) reload(force bool) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var modTime [2]time.Time
+	for i, path := range []string{c.certFile, c.keyFile} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		modTime[i] = fi.ModTime()
+	}
+	if !force && modTime == c.modTime {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return false, err
+	}
+	c.cert.Store(&cert)
+	c.modTime = modTime
+	return true, nil
+}
+
+// getCertificate implements tls.Config.GetCertificate.
+func (c *certLoader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// watch reloads the certificate when the files change, checking every
+// interval. It runs until the process exits.
+func (c *certLoader) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		c.check(false)
+	}
+}
+
+// check reloads the certificate if force is set or the files changed,
+// logging the outcome.
+func (c *certLoader) check(force bool) {
+	reloaded, err := c.reload(force)
+	switch {
+	case err != nil:
+		log.Printf("TLS certificate reload failed, keeping the current one: %v", err)
+	case reloaded:
+		log.Printf("Reloaded TLS certificate %s", c.certFile)
+	}
+}
+
+// tlsConfig returns the server TLS configuration. HTTP/2 is offered through
+// ALPN alongside HTTP/1.1. Client certificates are asked for according to
+// clientAuth and verified against clientCAs, or the system roots if nil.
+func (c *certLoader) tlsConfig(clientAuth tls.ClientAuthType, clientCAs *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		GetCertificate: c.getCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+		ClientAuth:     clientAuth,
+		ClientCAs:      clientCAs,
+	}
+}
+
+// loadCertPool reads a PEM bundle of CA certificates. Unlike the server
+// certificate, it is only read at startup.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no PEM certificates found", path)
+	}
+	return pool, nil
+}
+
+// tlsListener serves TLS on the connections of the wrapped listener, like
+// tls.NewListener, but makes the handshake explicit; see tlsConn.
+type tlsListener struct {
+	net.Listener
+	config *tls.Config
+}
+
+// Accept waits for the next connection. The handshake happens on first read.
+func (l *tlsListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &tlsConn{Conn: tls.Server(c, l.config)}, nil
+}
+
+// tlsConn completes the handshake before the first read and logs failures.
+// net/http does both for connections it recognizes as TLS, but it only sees
+// the recording wrapper around this one.
+type tlsConn struct {
+	*tls.Conn
+
+	once sync.Once
+	err  error
+}
+
+// Read reads application data once the handshake has succeeded. The
+// handshake is bounded by its own timeout rather than a deadline, which
+// would replace the one net/http set for reading the request.
+func (c *tlsConn) Read(b []byte) (int, error) {
+	c.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tlsHandshakeTimeout)
+		defer cancel()
+		if c.err = c.HandshakeContext(ctx); c.err != nil {
+			log.Printf("http: TLS handshake error from %s: %v", c.RemoteAddr(), c.err)
+		}
+	})
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.Conn.Read(b)
+}
+
+// NetConn returns the TLS connection.
+func (c *tlsConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+type tlsConnKey struct{}
+
+// withTLSState sets r.TLS for requests that arrived over TLS, which net/http
+// leaves nil when it does not see the *tls.Conn itself.
+func withTLSState(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := r.Context().Value(tlsConnKey{}).(*tls.Conn); ok && r.TLS == nil {
+			state := c.ConnectionState()
+			r2 := new(http.Request)
+			*r2 = *r
+			r2.TLS = &state
+			r = r2
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate for commonName and its key to
+// dir, returning the file paths.
+func writeCert(t *testing.T, dir, commonName string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// leafName returns the common name of the certificate currently served.
+func leafName(t *testing.T, c *certLoader) string {
+	t.Helper()
+	cert, err := c.getCertificate(nil)
+	if err != nil {
+		t.Fatalf("getCertificate() error = %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertLoader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "old.example")
+
+	c, err := newCertLoader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("newCertLoader() error = %v", err)
+	}
+	if got := leafName(t, c); got != "old.example" {
+		t.Errorf("serving %q, want old.example", got)
+	}
+
+	if reloaded, err := c.reload(false); reloaded || err != nil {
+		t.Errorf("reload() of unchanged files = %v, %v; want false, nil", reloaded, err)
+	}
+
+	writeCert(t, dir, "new.example")
+	later := time.Now().Add(time.Minute)
+	for _, path := range []string{certFile, keyFile} {
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reloaded, err := c.reload(false); !reloaded || err != nil {
+		t.Fatalf("reload() of changed files = %v, %v; want true, nil", reloaded, err)
+	}
+	if got := leafName(t, c); got != "new.example" {
+		t.Errorf("serving %q after reload, want new.example", got)
+	}
+
+	// A certificate without its matching key is rejected
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.reload(true); err == nil {
+		t.Error("reload() with a corrupt key should fail")
+	}
+	if got := leafName(t, c); got != "new.example" {
+		t.Errorf("serving %q after a failed reload, want new.example", got)
+	}
+}
+
+func TestNewCertLoader_Missing(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := newCertLoader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")); err == nil {
+		t.Error("newCertLoader() should fail for missing files")
+	}
+}
+
+func TestLoadCertPool(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "ca.example")
+
+	if _, err := loadCertPool(certFile); err != nil {
+		t.Errorf("loadCertPool() error = %v", err)
+	}
+	if _, err := loadCertPool(keyFile); err == nil {
+		t.Error("loadCertPool() should fail for a file without certificates")
+	}
+	if _, err := loadCertPool(filepath.Join(dir, "missing.pem")); err == nil {
+		t.Error("loadCertPool() should fail for a missing file")
+	}
+}
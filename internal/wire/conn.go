@@ -0,0 +1,174 @@
+// Package wire records what a client sends on a connection, below
+// net/http, so that details the parsed request no longer carries can be
+// reported: for HTTP/1.x, each request head as sent, and for HTTP/2, the
+// frames a client opens the connection with.
+package wire
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxRecorded bounds how much of a connection is buffered while recording.
+const maxRecorded = 64 << 10
+
+// Listener wraps a net.Listener so that every accepted connection is
+// recorded. It belongs directly beneath the HTTP server, above TLS.
+type Listener struct {
+	net.Listener
+}
+
+// Accept waits for the next connection.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{Conn: c, recording: true}, nil
+}
+
+// Conn passes reads through unchanged while decoding a copy of them. An
+// HTTP/2 connection is recorded until its first HEADERS frame; an HTTP/1.x
+// connection for as long as its requests can be followed.
+type Conn struct {
+	net.Conn
+
+	mu        sync.Mutex
+	buf       []byte // Bytes read but not yet decoded
+	recording bool
+	http1     *http1
+	http2     *HTTP2
+	upgrade   *HTTP2 // Record to continue once an upgraded connection sends the preface
+}
+
+// Read reads from the underlying connection.
+func (c *Conn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.capture(b[:n])
+	}
+	return n, err
+}
+
+func (c *Conn) capture(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.recording {
+		return
+	}
+	c.buf = append(c.buf, data...)
+
+	if c.http1 == nil && c.http2 == nil {
+		switch {
+		case len(c.buf) < len(http2Preface) && strings.HasPrefix(http2Preface, string(c.buf)):
+			return
+		case strings.HasPrefix(string(c.buf), http2Preface):
+			c.http2, c.upgrade = c.upgrade, nil
+			if c.http2 == nil {
+				c.http2 = &HTTP2{}
+			}
+			c.buf = c.buf[len(http2Preface):]
+		default:
+			c.http1 = &http1{}
+		}
+	}
+
+	if c.http1 != nil {
+		var ok bool
+		if c.buf, ok = c.http1.consume(c.buf); !ok {
+			c.stop()
+		}
+		if len(c.buf) > maxRecorded {
+			c.stop()
+		}
+		return
+	}
+
+	for len(c.buf) >= frameHeaderLen {
+		length := int(c.buf[0])<<16 | int(c.buf[1])<<8 | int(c.buf[2])
+		if len(c.buf) < frameHeaderLen+length {
+			break
+		}
+		typ, flags := c.buf[3], c.buf[4]
+		stream := binary.BigEndian.Uint32(c.buf[5:]) & 0x7fffffff
+		payload := c.buf[frameHeaderLen : frameHeaderLen+length]
+		c.buf = c.buf[frameHeaderLen+length:]
+		if c.http2.frame(typ, flags, stream, payload) {
+			c.stop()
+			return
+		}
+	}
+	if len(c.buf) > maxRecorded {
+		c.stop()
+	}
+}
+
+// stop ends recording. Heads already recorded can still be taken.
+func (c *Conn) stop() {
+	c.recording, c.buf = false, nil
+}
+
+// ExpectHTTP2 starts recording again, for a connection that switches to
+// HTTP/2 after an HTTP/1.1 Upgrade and sends the preface only then. settings
+// is the decoded payload of the request's HTTP2-Settings header, which
+// stands in for the client's first SETTINGS frame.
+func (c *Conn) ExpectHTTP2(settings []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recording, c.buf, c.http1, c.http2 = true, nil, nil, nil
+	c.upgrade = &HTTP2{Settings: parseSettings(settings), Upgraded: true, sawSettings: true}
+}
+
+// Head returns the head of an HTTP/1.x request with the given method and
+// request target, as in http.Request.Method and RequestURI, or nil if it
+// was not recorded. Each head is returned once, in the order received, and
+// the heads of earlier requests that were never asked for are dropped.
+func (c *Conn) Head(method, target string) *Head {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.http1 == nil {
+		return nil
+	}
+	return c.http1.take(method, target)
+}
+
+// HTTP2 returns what was recorded of an HTTP/2 connection so far, or nil if
+// the connection is not HTTP/2.
+func (c *Conn) HTTP2() *HTTP2 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.http2 == nil {
+		return nil
+	}
+	h := *c.http2
+	h.Settings = slices.Clone(h.Settings)
+	h.Priorities = slices.Clone(h.Priorities)
+	h.PseudoHeaders = slices.Clone(h.PseudoHeaders)
+	return &h
+}
+
+// NetConn returns the underlying connection.
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the recording connection.
+func NewContext(ctx context.Context, c *Conn) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the connection stored by NewContext, or nil if the
+// request did not arrive through one.
+func FromContext(ctx context.Context) *Conn {
+	c, _ := ctx.Value(contextKey{}).(*Conn)
+	return c
+}
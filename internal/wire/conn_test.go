@@ -0,0 +1,142 @@
+package wire
+
+import (
+	"io"
+	"net"
+	"slices"
+	"testing"
+)
+
+// readThrough writes stream to a Conn in small pieces and returns the Conn
+// and everything read from it.
+func readThrough(t *testing.T, stream []byte) (*Conn, []byte) {
+	t.Helper()
+
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		for i := 0; i < len(stream); i += 5 {
+			client.Write(stream[i:min(i+5, len(stream))])
+		}
+		client.Close()
+	}()
+
+	conn := &Conn{Conn: server, recording: true}
+	data, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	return conn, data
+}
+
+func TestConn_RecordsHTTP2(t *testing.T) {
+	stream := append(chromeOpening(), frameBytes(frameSettings, flagAck, 0, nil)...)
+	conn, data := readThrough(t, stream)
+
+	if string(data) != string(stream) {
+		t.Errorf("ReadAll() = %q, want the stream passed through", data)
+	}
+	h := conn.HTTP2()
+	if h == nil {
+		t.Fatal("HTTP2() = nil, want a recording")
+	}
+	if want := "1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p"; h.Akamai() != want {
+		t.Errorf("Akamai() = %q, want %q", h.Akamai(), want)
+	}
+	if conn.recording || conn.buf != nil {
+		t.Error("still recording after the first HEADERS frame")
+	}
+}
+
+func TestConn_RecordsHTTP1(t *testing.T) {
+	stream := "POST /form HTTP/1.1\r\nHost: example.com\r\ncontent-length: 12\r\n\r\nGET / HTTP/\n" +
+		"PUT /chunked HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"4;ext=1\r\nGET \r\n0\r\nX-Trailer: 1\r\n\r\n" +
+		"GET /?q=1 HTTP/1.1\nhost: example.com\nX-Dup: a\nX-Dup:  b \nX-Fold: one\n two\n\n"
+	conn, data := readThrough(t, []byte(stream))
+
+	if string(data) != stream {
+		t.Errorf("ReadAll() = %q, want the stream passed through", data)
+	}
+	if h := conn.HTTP2(); h != nil {
+		t.Errorf("HTTP2() = %+v, want nil", h)
+	}
+	if !conn.recording {
+		t.Error("stopped recording a well-formed HTTP/1.1 connection")
+	}
+
+	if head := conn.Head("GET", "/"); head != nil {
+		t.Errorf("Head(GET /) = %+v, want nil: that was a request body", head)
+	}
+	head := conn.Head("GET", "/?q=1")
+	if head == nil {
+		t.Fatal("Head(GET /?q=1) = nil")
+	}
+	want := []Field{{"host", "example.com"}, {"X-Dup", "a"}, {"X-Dup", "b"}, {"X-Fold", "one two"}}
+	if head.RequestLine != "GET /?q=1 HTTP/1.1" || !slices.Equal(head.Fields, want) {
+		t.Errorf("Head(GET /?q=1) = %+v, want fields %v", head, want)
+	}
+
+	// Taking a later head drops the earlier ones
+	if head := conn.Head("POST", "/form"); head != nil {
+		t.Errorf("Head(POST /form) = %+v, want nil after a later request was taken", head)
+	}
+}
+
+func TestConn_EmptyLinesBetweenRequests(t *testing.T) {
+	stream := "\r\nGET /a HTTP/1.1\r\nHost: example.com\r\n\r\n\r\nGET /b HTTP/1.1\r\nHost: example.com\r\n\r\n\n\r\nGET /c HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	conn, _ := readThrough(t, []byte(stream))
+
+	if !conn.recording {
+		t.Error("stopped recording at an empty line before a request line")
+	}
+	for _, target := range []string{"/a", "/b", "/c"} {
+		if head := conn.Head("GET", target); head == nil || head.RequestLine != "GET "+target+" HTTP/1.1" {
+			t.Errorf("Head(GET %s) = %+v, want the request as sent", target, head)
+		}
+	}
+}
+
+func TestConn_MalformedHTTP1(t *testing.T) {
+	conn, _ := readThrough(t, []byte("GET / HTTP/1.1\r\nContent-Length: x\r\n\r\n"))
+	if conn.recording {
+		t.Error("still recording after an invalid Content-Length")
+	}
+	if head := conn.Head("GET", "/"); head == nil {
+		t.Error("Head() = nil, want the head recorded before the error")
+	}
+}
+
+func TestHead_OrderHash(t *testing.T) {
+	a := &Head{Fields: []Field{{"Host", "a"}, {"Accept", "*/*"}}}
+	b := &Head{Fields: []Field{{"Host", "b"}, {"Accept", "text/html"}}}
+	c := &Head{Fields: []Field{{"host", "a"}, {"Accept", "*/*"}}}
+	d := &Head{Fields: []Field{{"Accept", "*/*"}, {"Host", "a"}}}
+
+	if len(a.OrderHash()) != 12 {
+		t.Errorf("OrderHash() = %q, want 12 hex digits", a.OrderHash())
+	}
+	if a.OrderHash() != b.OrderHash() {
+		t.Error("OrderHash() depends on header values")
+	}
+	if a.OrderHash() == c.OrderHash() || a.OrderHash() == d.OrderHash() {
+		t.Error("OrderHash() ignores casing or order")
+	}
+}
+
+func TestConn_ExpectHTTP2(t *testing.T) {
+	conn := &Conn{}
+	conn.capture([]byte("GET / HTTP/1.1\r\n"))
+	conn.ExpectHTTP2([]byte{0, 3, 0, 0, 0, 100, 0, 4, 0, 0, 0xff, 0xff})
+	conn.capture(chromeOpening())
+
+	// The settings come from the HTTP2-Settings header, not the frame, and
+	// the first request's pseudo-headers were never sent
+	h := conn.HTTP2()
+	if h == nil || !h.Upgraded || h.PseudoHeaders != nil {
+		t.Fatalf("HTTP2() = %+v, want the upgraded connection recorded without pseudo-headers", h)
+	}
+	if want := "3:100;4:65535|15663105|0|"; h.Akamai() != want {
+		t.Errorf("Akamai() = %q, want %q", h.Akamai(), want)
+	}
+}
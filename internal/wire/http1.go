@@ -0,0 +1,222 @@
+package wire
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// maxHeads bounds how many request heads are kept for requests the handler
+// has not asked for yet, such as pipelined requests.
+const maxHeads = 16
+
+// Head is the head of an HTTP/1.x request as the client sent it, before
+// net/http canonicalizes header names and merges repeated headers.
+type Head struct {
+	RequestLine string // e.g. "GET /path HTTP/1.1"
+	Method      string
+	Target      string // Request target as sent, like http.Request.RequestURI
+	Fields      []Field
+}
+
+// Field is a header field as sent, with its original casing.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Names returns the header names in the order they were sent.
+func (h *Head) Names() []string {
+	names := make([]string, len(h.Fields))
+	for i, f := range h.Fields {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// OrderHash returns a fingerprint of the header names: the first 12 hex
+// digits of the SHA-256 of the names in the order sent, with their original
+// casing, separated by commas. Clients that send the same headers in the
+// same order with the same casing share it.
+func (h *Head) OrderHash() string {
+	sum := sha256.Sum256([]byte(strings.Join(h.Names(), ",")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// States of the HTTP/1.x recorder between requests.
+const (
+	stateHead      = iota // Before or within a request head
+	stateBody             // Within a body of known length
+	stateChunkSize        // At a chunk-size line of a chunked body
+	stateChunkData        // Within a chunk and its trailing CRLF
+	stateTrailer          // Within the trailer section of a chunked body
+)
+
+// http1 records the request heads of an HTTP/1.x connection. Bodies are
+// skipped according to their framing, so that every request is found
+// without keeping the bodies.
+type http1 struct {
+	heads     []*Head // Recorded, not yet taken
+	state     int
+	remaining int64 // Bytes left in the body or the current chunk
+}
+
+// consume processes as much of buf as possible and returns what is left
+// for later. It reports false if the stream cannot be followed, in which
+// case net/http will not serve it either.
+func (h *http1) consume(buf []byte) ([]byte, bool) {
+	for len(buf) > 0 {
+		switch h.state {
+		case stateBody, stateChunkData:
+			n := min(h.remaining, int64(len(buf)))
+			buf, h.remaining = buf[n:], h.remaining-n
+			if h.remaining > 0 {
+				return buf, true
+			}
+			if h.state == stateBody {
+				h.state = stateHead
+			} else {
+				h.state = stateChunkSize
+			}
+
+		case stateHead:
+			// Empty lines before a request line are ignored (RFC 9112,
+			// section 2.2), as keep-alive clients may send CRLF after a body
+			if buf = bytes.TrimLeft(buf, "\r\n"); len(buf) == 0 {
+				return buf, true
+			}
+			end := headEnd(buf)
+			if end < 0 {
+				return buf, true
+			}
+			head, ok := parseHead(buf[:end])
+			if !ok {
+				return nil, false
+			}
+			buf = buf[end:]
+			if h.heads = append(h.heads, head); len(h.heads) > maxHeads {
+				h.heads = h.heads[1:]
+			}
+			if h.state, h.remaining, ok = bodyFraming(head); !ok {
+				return nil, false
+			}
+
+		case stateChunkSize, stateTrailer:
+			i := bytes.IndexByte(buf, '\n')
+			if i < 0 {
+				return buf, true
+			}
+			line := strings.TrimSuffix(string(buf[:i]), "\r")
+			buf = buf[i+1:]
+			if h.state == stateTrailer {
+				if line == "" {
+					h.state = stateHead
+				}
+				continue
+			}
+			size, _, _ := strings.Cut(line, ";")
+			n, err := strconv.ParseInt(strings.TrimSpace(size), 16, 64)
+			if err != nil || n < 0 {
+				return nil, false
+			}
+			if n == 0 {
+				h.state = stateTrailer
+			} else {
+				h.state, h.remaining = stateChunkData, n+2
+			}
+		}
+	}
+	return buf, true
+}
+
+// take returns the first head for method and target and forgets it along
+// with any heads before it, or returns nil if there is none.
+func (h *http1) take(method, target string) *Head {
+	for i, head := range h.heads {
+		if head.Method == method && head.Target == target {
+			h.heads = h.heads[i+1:]
+			return head
+		}
+	}
+	return nil
+}
+
+// headEnd returns the length of the request head at the start of buf,
+// including the empty line that ends it, or -1 if it is incomplete. Lines
+// may end in CRLF or, as net/http also accepts, a bare LF.
+func headEnd(buf []byte) int {
+	for i := 0; ; {
+		j := bytes.IndexByte(buf[i:], '\n')
+		if j < 0 {
+			return -1
+		}
+		line := buf[i : i+j]
+		i += j + 1
+		if len(line) == 0 || len(line) == 1 && line[0] == '\r' {
+			return i
+		}
+	}
+}
+
+// parseHead parses a complete request head.
+func parseHead(b []byte) (*Head, bool) {
+	lines := strings.Split(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+	lines = lines[:len(lines)-2] // Drop the empty line and what follows it
+	if len(lines) == 0 {
+		return nil, false
+	}
+
+	method, rest, ok1 := strings.Cut(lines[0], " ")
+	target, _, ok2 := strings.Cut(rest, " ")
+	if !ok1 || !ok2 || method == "" || target == "" {
+		return nil, false
+	}
+	head := &Head{RequestLine: lines[0], Method: method, Target: target, Fields: []Field{}}
+
+	for _, line := range lines[1:] {
+		if line[0] == ' ' || line[0] == '\t' {
+			// Obsolete line folding continues the previous value
+			if len(head.Fields) == 0 {
+				return nil, false
+			}
+			f := &head.Fields[len(head.Fields)-1]
+			f.Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || name == "" {
+			return nil, false
+		}
+		head.Fields = append(head.Fields, Field{Name: name, Value: strings.TrimSpace(value)})
+	}
+	return head, true
+}
+
+// bodyFraming returns the state after the head and how many body bytes
+// follow it, from Transfer-Encoding and Content-Length (RFC 9112, section 6).
+func bodyFraming(head *Head) (int, int64, bool) {
+	var chunked bool
+	var length int64
+	for _, f := range head.Fields {
+		switch {
+		case strings.EqualFold(f.Name, "Transfer-Encoding"):
+			codings := strings.Split(f.Value, ",")
+			chunked = strings.EqualFold(strings.TrimSpace(codings[len(codings)-1]), "chunked")
+		case strings.EqualFold(f.Name, "Content-Length"):
+			n, err := strconv.ParseInt(strings.TrimSpace(f.Value), 10, 64)
+			if err != nil || n < 0 {
+				return 0, 0, false
+			}
+			length = n
+		}
+	}
+	switch {
+	case chunked:
+		return stateChunkSize, 0, true
+	case length > 0:
+		return stateBody, length, true
+	}
+	return stateHead, 0, true
+}
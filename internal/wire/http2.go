@@ -0,0 +1,310 @@
+package wire
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+// http2Preface is the fixed string an HTTP/2 client opens a connection with.
+const http2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// Frame types and flags used while recording.
+const (
+	frameHeaders      = 0x1
+	framePriority     = 0x2
+	frameSettings     = 0x4
+	frameWindowUpdate = 0x8
+
+	flagAck        = 0x1
+	flagPadded     = 0x8
+	flagPriority   = 0x20
+	frameHeaderLen = 9
+)
+
+// HTTP2 is what a client sent at the start of an HTTP/2 connection, up to
+// and including its first HEADERS frame. Together these make up the Akamai
+// fingerprint of the client's HTTP/2 implementation.
+type HTTP2 struct {
+	Settings        []Setting  // First SETTINGS frame, in the order sent
+	WindowUpdate    uint32     // Increment of the first connection-level WINDOW_UPDATE; 0 if none
+	Priorities      []Priority // PRIORITY frames
+	HeadersPriority *Priority  // Priority carried by the first HEADERS frame, if any
+	PseudoHeaders   []string   // Pseudo-header names of the first request, e.g. ":method"
+
+	// Upgraded is true for a connection that switched from HTTP/1.1 with
+	// "Upgrade: h2c". Its settings are those of the HTTP2-Settings header,
+	// and as its first request was sent as HTTP/1.1, no pseudo-header
+	// order is observed.
+	Upgraded bool
+
+	sawSettings bool
+}
+
+// Setting is an HTTP/2 SETTINGS parameter.
+type Setting struct {
+	ID    uint16
+	Value uint32
+}
+
+// Name returns the registered name of the setting, e.g.
+// "INITIAL_WINDOW_SIZE", or an empty string if it is unknown.
+func (s Setting) Name() string {
+	return settingNames[s.ID]
+}
+
+var settingNames = map[uint16]string{
+	0x1: "HEADER_TABLE_SIZE",
+	0x2: "ENABLE_PUSH",
+	0x3: "MAX_CONCURRENT_STREAMS",
+	0x4: "INITIAL_WINDOW_SIZE",
+	0x5: "MAX_FRAME_SIZE",
+	0x6: "MAX_HEADER_LIST_SIZE",
+	0x8: "ENABLE_CONNECT_PROTOCOL",
+	0x9: "NO_RFC7540_PRIORITIES",
+}
+
+// Priority is a stream dependency from a PRIORITY frame or HEADERS frame.
+type Priority struct {
+	StreamID  uint32
+	Exclusive bool
+	DependsOn uint32
+	Weight    int // 1 to 256; the frame carries the weight minus one
+}
+
+// String formats the priority the way the Akamai fingerprint does, e.g.
+// "3:0:0:201".
+func (p Priority) String() string {
+	exclusive := "0"
+	if p.Exclusive {
+		exclusive = "1"
+	}
+	return strconv.FormatUint(uint64(p.StreamID), 10) + ":" + exclusive + ":" +
+		strconv.FormatUint(uint64(p.DependsOn), 10) + ":" + strconv.Itoa(p.Weight)
+}
+
+// Akamai returns the fingerprint described in Akamai's "Passive
+// Fingerprinting of HTTP/2 Clients": the settings, the window update, the
+// PRIORITY frames and the pseudo-header order, separated by "|", e.g.
+// "1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p".
+func (h *HTTP2) Akamai() string {
+	settings := make([]string, len(h.Settings))
+	for i, s := range h.Settings {
+		settings[i] = strconv.Itoa(int(s.ID)) + ":" + strconv.FormatUint(uint64(s.Value), 10)
+	}
+
+	windowUpdate := "00"
+	if h.WindowUpdate != 0 {
+		windowUpdate = strconv.FormatUint(uint64(h.WindowUpdate), 10)
+	}
+
+	priorities := "0"
+	if len(h.Priorities) > 0 {
+		parts := make([]string, len(h.Priorities))
+		for i, p := range h.Priorities {
+			parts[i] = p.String()
+		}
+		priorities = strings.Join(parts, ",")
+	}
+
+	var order []string
+	for _, name := range h.PseudoHeaders {
+		switch name {
+		case ":method", ":authority", ":scheme", ":path":
+			order = append(order, name[1:2])
+		}
+	}
+
+	return strings.Join([]string{strings.Join(settings, ";"), windowUpdate, priorities, strings.Join(order, ",")}, "|")
+}
+
+// frame processes one complete frame and reports whether recording is
+// finished, which it is after the first HEADERS frame.
+func (h *HTTP2) frame(typ, flags byte, stream uint32, payload []byte) bool {
+	switch typ {
+	case frameSettings:
+		if flags&flagAck != 0 || h.sawSettings {
+			return false
+		}
+		h.sawSettings = true
+		h.Settings = parseSettings(payload)
+	case frameWindowUpdate:
+		if stream == 0 && h.WindowUpdate == 0 && len(payload) == 4 {
+			h.WindowUpdate = binary.BigEndian.Uint32(payload) & 0x7fffffff
+		}
+	case framePriority:
+		if len(payload) == 5 {
+			h.Priorities = append(h.Priorities, parsePriority(stream, payload))
+		}
+	case frameHeaders:
+		if h.Upgraded {
+			return true
+		}
+		block := payload
+		if flags&flagPadded != 0 {
+			if len(block) < 1 || int(block[0]) > len(block)-1 {
+				return true
+			}
+			block = block[1 : len(block)-int(block[0])]
+		}
+		if flags&flagPriority != 0 {
+			if len(block) < 5 {
+				return true
+			}
+			p := parsePriority(stream, block)
+			h.HeadersPriority = &p
+			block = block[5:]
+		}
+		h.PseudoHeaders = pseudoHeaders(block)
+		return true
+	}
+	return false
+}
+
+// parseSettings decodes the parameters of a SETTINGS frame payload.
+func parseSettings(payload []byte) []Setting {
+	var settings []Setting
+	for p := payload; len(p) >= 6; p = p[6:] {
+		settings = append(settings, Setting{ID: binary.BigEndian.Uint16(p), Value: binary.BigEndian.Uint32(p[2:])})
+	}
+	return settings
+}
+
+func parsePriority(stream uint32, b []byte) Priority {
+	dep := binary.BigEndian.Uint32(b)
+	return Priority{
+		StreamID:  stream,
+		Exclusive: dep&0x80000000 != 0,
+		DependsOn: dep & 0x7fffffff,
+		Weight:    int(b[4]) + 1,
+	}
+}
+
+// pseudoHeaders returns the pseudo-header names at the start of an HPACK
+// header block, in order. Only names are needed, and pseudo-headers are in
+// the static table, so this is far from a full HPACK decoder: it stops at the
+// first regular header or anything it cannot decode, such as a Huffman-coded
+// name.
+func pseudoHeaders(block []byte) []string {
+	names := []string{}
+	var dynamic []string // Names added to the dynamic table, newest first
+
+	nameAt := func(index uint64) (string, bool) {
+		switch {
+		case index == 0:
+			return "", false
+		case index <= 61:
+			return staticName(index), true
+		case index-62 < uint64(len(dynamic)):
+			return dynamic[index-62], true
+		}
+		return "", false
+	}
+
+	for len(block) > 0 {
+		b := block[0]
+		var name string
+		var ok bool
+		switch {
+		case b&0x80 != 0: // Indexed field
+			var index uint64
+			if index, block, ok = hpackInt(block, 7); !ok {
+				return names
+			}
+			if name, ok = nameAt(index); !ok {
+				return names
+			}
+		case b&0xe0 == 0x20: // Dynamic table size update
+			if _, block, ok = hpackInt(block, 5); !ok {
+				return names
+			}
+			continue
+		default: // Literal, with incremental indexing (01) or without (0000, 0001)
+			prefix := uint8(4)
+			indexing := b&0xc0 == 0x40
+			if indexing {
+				prefix = 6
+			}
+			var index uint64
+			if index, block, ok = hpackInt(block, prefix); !ok {
+				return names
+			}
+			if index == 0 {
+				var literal []byte
+				var huffman bool
+				if literal, huffman, block, ok = hpackString(block); !ok || huffman {
+					return names
+				}
+				name = string(literal)
+			} else if name, ok = nameAt(index); !ok {
+				return names
+			}
+			if _, _, block, ok = hpackString(block); !ok {
+				return names
+			}
+			if indexing {
+				dynamic = append([]string{name}, dynamic...)
+			}
+		}
+
+		if !strings.HasPrefix(name, ":") {
+			return names
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// staticName returns the name of a static table entry if it is a
+// pseudo-header, or an empty string for the regular headers that follow.
+func staticName(index uint64) string {
+	switch {
+	case index == 1:
+		return ":authority"
+	case index <= 3:
+		return ":method"
+	case index <= 5:
+		return ":path"
+	case index <= 7:
+		return ":scheme"
+	case index <= 14:
+		return ":status"
+	}
+	return ""
+}
+
+// hpackInt decodes an integer with an n-bit prefix (RFC 7541, section 5.1).
+func hpackInt(b []byte, n uint8) (uint64, []byte, bool) {
+	if len(b) == 0 {
+		return 0, b, false
+	}
+	limit := uint64(1)<<n - 1
+	v := uint64(b[0]) & limit
+	b = b[1:]
+	if v < limit {
+		return v, b, true
+	}
+	for shift := uint(0); len(b) > 0 && shift < 63; shift += 7 {
+		c := b[0]
+		b = b[1:]
+		v += uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return v, b, true
+		}
+	}
+	return 0, b, false
+}
+
+// hpackString returns a string literal's raw bytes and whether they are
+// Huffman-coded (RFC 7541, section 5.2).
+func hpackString(b []byte) ([]byte, bool, []byte, bool) {
+	if len(b) == 0 {
+		return nil, false, b, false
+	}
+	huffman := b[0]&0x80 != 0
+	length, rest, ok := hpackInt(b, 7)
+	if !ok || length > uint64(len(rest)) {
+		return nil, false, b, false
+	}
+	return rest[:length], huffman, rest[length:], true
+}
@@ -0,0 +1,85 @@
+package wire
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+// frameBytes encodes an HTTP/2 frame.
+func frameBytes(typ, flags byte, stream uint32, payload []byte) []byte {
+	b := []byte{byte(len(payload) >> 16), byte(len(payload) >> 8), byte(len(payload)), typ, flags}
+	b = binary.BigEndian.AppendUint32(b, stream)
+	return append(b, payload...)
+}
+
+// chromeOpening is the start of an HTTP/2 connection as Chrome opens it,
+// with a header block that uses indexed and literal entries.
+func chromeOpening() []byte {
+	var settings []byte
+	for _, s := range []Setting{{1, 65536}, {2, 0}, {4, 6291456}, {6, 262144}} {
+		settings = binary.BigEndian.AppendUint16(settings, s.ID)
+		settings = binary.BigEndian.AppendUint32(settings, s.Value)
+	}
+	windowUpdate := binary.BigEndian.AppendUint32(nil, 15663105)
+
+	headers := []byte{0x80, 0, 0, 0, 0xff} // Exclusive dependency on stream 0, weight 256
+	headers = append(headers,
+		0x82,                                                            // :method GET
+		0x41, 11, 'e', 'x', 'a', 'm', 'p', 'l', 'e', '.', 'c', 'o', 'm', // :authority, with incremental indexing
+		0x87,         // :scheme https
+		0x04, 1, '/', // :path, without indexing
+		0x40, 2, 'x', '-', 1, 'y', // Regular header, which ends the pseudo-headers
+	)
+
+	return slices.Concat(
+		[]byte(http2Preface),
+		frameBytes(frameSettings, 0, 0, settings),
+		frameBytes(frameWindowUpdate, 0, 0, windowUpdate),
+		frameBytes(frameHeaders, flagPriority|0x4|0x1, 1, headers),
+	)
+}
+
+func TestHTTP2_Akamai(t *testing.T) {
+	h := &HTTP2{}
+	stream := chromeOpening()[len(http2Preface):]
+	for len(stream) > 0 {
+		length := int(stream[0])<<16 | int(stream[1])<<8 | int(stream[2])
+		payload := stream[frameHeaderLen : frameHeaderLen+length]
+		done := h.frame(stream[3], stream[4], binary.BigEndian.Uint32(stream[5:]), payload)
+		stream = stream[frameHeaderLen+length:]
+		if done != (len(stream) == 0) {
+			t.Fatalf("frame() = %v with %d bytes left", done, len(stream))
+		}
+	}
+
+	if want := "1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p"; h.Akamai() != want {
+		t.Errorf("Akamai() = %q, want %q", h.Akamai(), want)
+	}
+	if want := []string{":method", ":authority", ":scheme", ":path"}; !slices.Equal(h.PseudoHeaders, want) {
+		t.Errorf("PseudoHeaders = %v, want %v", h.PseudoHeaders, want)
+	}
+	if h.HeadersPriority == nil || h.HeadersPriority.String() != "1:1:0:256" {
+		t.Errorf("HeadersPriority = %v, want 1:1:0:256", h.HeadersPriority)
+	}
+	if h.Settings[2].Name() != "INITIAL_WINDOW_SIZE" {
+		t.Errorf("Settings[2].Name() = %q", h.Settings[2].Name())
+	}
+}
+
+func TestHTTP2_AkamaiDefaults(t *testing.T) {
+	h := &HTTP2{
+		Priorities: []Priority{{StreamID: 3, DependsOn: 0, Weight: 201}, {StreamID: 5, DependsOn: 0, Weight: 101}},
+	}
+	if want := "|00|3:0:0:201,5:0:0:101|"; h.Akamai() != want {
+		t.Errorf("Akamai() = %q, want %q", h.Akamai(), want)
+	}
+}
+
+func TestPseudoHeaders_Huffman(t *testing.T) {
+	// A Huffman-coded literal name cannot be decoded, so decoding stops there
+	block := []byte{0x82, 0x00, 0x81, 0xff, 0x01, 'x'}
+	if got := pseudoHeaders(block); !slices.Equal(got, []string{":method"}) {
+		t.Errorf("pseudoHeaders() = %v, want [:method]", got)
+	}
+}
@@ -8,13 +8,13 @@ import (
 )
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	cfg, err := server.LoadConfig(os.Getenv)
+	if err != nil {
+		log.Fatalf("Configuration error: %v", err)
 	}
 
-	log.Printf("Starting connectionInfo server on port %s", port)
-	if err := server.Run(port); err != nil {
+	log.Printf("Starting connectionInfo server on port %s", cfg.Port)
+	if err := server.Run(cfg); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 }